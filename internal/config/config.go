@@ -3,6 +3,7 @@ package config
 import (
 	"encoding/json"
 	"os"
+	"time"
 
 	"github.com/go-playground/validator/v10"
 	"github.com/ilyakaznacheev/cleanenv"
@@ -17,10 +18,74 @@ type Config struct {
 type Dvault struct {
 	MountPath        string `json:"mount_path" validate:"required" env:"MOUNT_PATH"`
 	EncryptionMethod string `json:"encryption_method" validate:"required" env:"ENCRYPTION_METHOD"`
+	// ExpirationInterval is how often expired tokens are revoked, one minute
+	// when unset.
+	ExpirationInterval time.Duration `json:"expiration_interval" env:"EXPIRATION_INTERVAL"`
+	// UnsealTimeout is how long an unseal may take before the submitted
+	// shares are discarded, ten minutes when unset.
+	UnsealTimeout time.Duration `json:"unseal_timeout" env:"UNSEAL_TIMEOUT"`
+	UserLockout   UserLockout   `json:"user_lockout"`
+	Seal          Seal          `json:"seal"`
+}
+
+// Seal selects how the root key is protected. The Shamir seal splits it into
+// key shares; the other seals wrap it with an external KMS, so the vault
+// unseals itself on startup and the shares become recovery keys.
+type Seal struct {
+	Type    string      `json:"type" validate:"omitempty,oneof=shamir transit pkcs11" env:"SEAL_TYPE"`
+	Transit TransitSeal `json:"transit"`
+	PKCS11  PKCS11Seal  `json:"pkcs11"`
+}
+
+// TransitSeal configures a Vault transit key, by default in the engine
+// mounted at "transit".
+type TransitSeal struct {
+	Address   string `json:"address" env:"SEAL_TRANSIT_ADDRESS"`
+	Token     string `json:"token" env:"SEAL_TRANSIT_TOKEN"`
+	MountPath string `json:"mount_path" env:"SEAL_TRANSIT_MOUNT_PATH"`
+	KeyName   string `json:"key_name" env:"SEAL_TRANSIT_KEY_NAME"`
+	CACert    string `json:"ca_cert" env:"SEAL_TRANSIT_CA_CERT"`
+}
+
+// PKCS11Seal configures an AES or RSA key in an HSM. The token is selected by
+// slot ID or by its label. The seal is only available in builds with the
+// pkcs11 tag.
+type PKCS11Seal struct {
+	Lib        string `json:"lib" env:"SEAL_PKCS11_LIB"`
+	Slot       string `json:"slot" env:"SEAL_PKCS11_SLOT"`
+	TokenLabel string `json:"token_label" env:"SEAL_PKCS11_TOKEN_LABEL"`
+	PIN        string `json:"pin" env:"SEAL_PKCS11_PIN"`
+	KeyLabel   string `json:"key_label" env:"SEAL_PKCS11_KEY_LABEL"`
+}
+
+// UserLockout configures how auth methods lock users out after repeated
+// failed logins. Unset values fall back to Vault's defaults.
+type UserLockout struct {
+	Threshold    int           `json:"lockout_threshold" env:"USER_LOCKOUT_THRESHOLD"`
+	Duration     time.Duration `json:"lockout_duration" env:"USER_LOCKOUT_DURATION"`
+	CounterReset time.Duration `json:"lockout_counter_reset" env:"USER_LOCKOUT_COUNTER_RESET"`
+	Disable      bool          `json:"disable_lockout" env:"USER_LOCKOUT_DISABLE"`
 }
 
 type Server struct {
 	Addr string `json:"addr" validate:"required,hostname_port" env:"PORT"`
+	// TLSCertFile and TLSKeyFile enable the TLS listener. Clients are asked
+	// for a certificate, which the cert auth method uses to log in.
+	TLSCertFile string `json:"tls_cert_file" validate:"required_with=TLSKeyFile" env:"TLS_CERT_FILE"`
+	TLSKeyFile  string `json:"tls_key_file" validate:"required_with=TLSCertFile" env:"TLS_KEY_FILE"`
+	// TLSClientCAFile optionally restricts client certificates to the CAs in
+	// the file already during the handshake.
+	TLSClientCAFile string `json:"tls_client_ca_file" validate:"excluded_without=TLSCertFile" env:"TLS_CLIENT_CA_FILE"`
+	// ForwardedForDepth is the number of trusted proxies in front of the
+	// server. Each of them appends to X-Forwarded-For, so the client address
+	// is taken from that many entries from the right. The header is only
+	// read from connections of ForwardedForAuthorizedAddrs and rejected from
+	// any other peer. Zero ignores the header and uses the address of the
+	// connection.
+	ForwardedForDepth int `json:"x_forwarded_for_depth" validate:"gte=0" env:"X_FORWARDED_FOR_DEPTH"`
+	// ForwardedForAuthorizedAddrs lists the CIDRs or addresses of the
+	// proxies allowed to set X-Forwarded-For.
+	ForwardedForAuthorizedAddrs []string `json:"x_forwarded_for_authorized_addrs" validate:"required_unless=ForwardedForDepth 0" env:"X_FORWARDED_FOR_AUTHORIZED_ADDRS"`
 }
 
 func Default() (Config, error) {
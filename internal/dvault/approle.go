@@ -0,0 +1,424 @@
+package dvault
+
+import (
+	"context"
+	"path/filepath"
+
+	"github.com/Burzich/dvault/internal/dvault/auth/approle"
+	"github.com/Burzich/dvault/internal/tools"
+)
+
+func (d *DVault) ListAppRoles(ctx context.Context, mount string) (Response, error) {
+	d.mu.RLock()
+	defer d.mu.RUnlock()
+
+	if d.isSealed {
+		return Response{}, ErrSealed
+	}
+
+	backend, err := d.appRole(mount)
+	if err != nil {
+		return Response{}, err
+	}
+
+	roles, err := backend.ListRoles(ctx)
+	if err != nil {
+		return Response{}, err
+	}
+
+	var response Response
+	response.Data = KeyList{Keys: roles}
+	response.MountType = "approle"
+	response.RequestId = tools.GenerateXRequestID()
+
+	return response, nil
+}
+
+func (d *DVault) GetAppRole(ctx context.Context, mount string, name string) (Response, error) {
+	d.mu.RLock()
+	defer d.mu.RUnlock()
+
+	if d.isSealed {
+		return Response{}, ErrSealed
+	}
+
+	backend, err := d.appRole(mount)
+	if err != nil {
+		return Response{}, err
+	}
+
+	role, err := backend.GetRole(ctx, name)
+	if err != nil {
+		return Response{}, err
+	}
+
+	var response Response
+	response.Data = AppRoleData{
+		BindSecretId:       role.BindSecretID,
+		SecretIdBoundCidrs: role.SecretIDBoundCIDRs,
+		SecretIdNumUses:    role.SecretIDNumUses,
+		SecretIdTtl:        int(role.SecretIDTTL.Seconds()),
+		TokenParamsData:    tokenParamsData(role.TokenParams),
+	}
+	response.MountType = "approle"
+	response.RequestId = tools.GenerateXRequestID()
+
+	return response, nil
+}
+
+func (d *DVault) SaveAppRole(ctx context.Context, mount string, name string, role AppRole) (Response, error) {
+	d.mu.RLock()
+	defer d.mu.RUnlock()
+
+	if d.isSealed {
+		return Response{}, ErrSealed
+	}
+
+	backend, err := d.appRole(mount)
+	if err != nil {
+		return Response{}, err
+	}
+
+	bindSecretID := true
+	if role.BindSecretID != nil {
+		bindSecretID = *role.BindSecretID
+	}
+
+	_, err = backend.PutRole(ctx, approle.Role{
+		Name:               name,
+		BindSecretID:       bindSecretID,
+		SecretIDBoundCIDRs: role.SecretIDBoundCIDRs,
+		SecretIDNumUses:    role.SecretIDNumUses,
+		SecretIDTTL:        role.SecretIDTTL,
+		TokenParams:        role.TokenParams,
+	})
+	if err != nil {
+		return Response{}, err
+	}
+
+	var response Response
+	response.MountType = "approle"
+	response.RequestId = tools.GenerateXRequestID()
+
+	return response, nil
+}
+
+func (d *DVault) DeleteAppRole(ctx context.Context, mount string, name string) (Response, error) {
+	d.mu.RLock()
+	defer d.mu.RUnlock()
+
+	if d.isSealed {
+		return Response{}, ErrSealed
+	}
+
+	backend, err := d.appRole(mount)
+	if err != nil {
+		return Response{}, err
+	}
+
+	if err = backend.DeleteRole(ctx, name); err != nil {
+		return Response{}, err
+	}
+
+	var response Response
+	response.MountType = "approle"
+	response.RequestId = tools.GenerateXRequestID()
+
+	return response, nil
+}
+
+func (d *DVault) GetAppRoleRoleID(ctx context.Context, mount string, name string) (Response, error) {
+	d.mu.RLock()
+	defer d.mu.RUnlock()
+
+	if d.isSealed {
+		return Response{}, ErrSealed
+	}
+
+	backend, err := d.appRole(mount)
+	if err != nil {
+		return Response{}, err
+	}
+
+	role, err := backend.GetRole(ctx, name)
+	if err != nil {
+		return Response{}, err
+	}
+
+	var response Response
+	response.Data = AppRoleRoleID{RoleId: role.RoleID}
+	response.MountType = "approle"
+	response.RequestId = tools.GenerateXRequestID()
+
+	return response, nil
+}
+
+func (d *DVault) SaveAppRoleRoleID(ctx context.Context, mount string, name string, roleID string) (Response, error) {
+	d.mu.RLock()
+	defer d.mu.RUnlock()
+
+	if d.isSealed {
+		return Response{}, ErrSealed
+	}
+
+	backend, err := d.appRole(mount)
+	if err != nil {
+		return Response{}, err
+	}
+
+	if err = backend.SetRoleID(ctx, name, roleID); err != nil {
+		return Response{}, err
+	}
+
+	var response Response
+	response.MountType = "approle"
+	response.RequestId = tools.GenerateXRequestID()
+
+	return response, nil
+}
+
+func (d *DVault) GenerateAppRoleSecretID(ctx context.Context, mount string, name string, secretID AppRoleSecretID) (Response, error) {
+	d.mu.RLock()
+	defer d.mu.RUnlock()
+
+	if d.isSealed {
+		return Response{}, ErrSealed
+	}
+
+	backend, err := d.appRole(mount)
+	if err != nil {
+		return Response{}, err
+	}
+
+	id, entry, err := backend.GenerateSecretID(ctx, name, secretID.SecretID, approle.SecretID{
+		Metadata:        secretID.Metadata,
+		CIDRList:        secretID.CIDRList,
+		TokenBoundCIDRs: secretID.TokenBoundCIDRs,
+		NumUses:         secretID.NumUses,
+		TTL:             secretID.TTL,
+	})
+	if err != nil {
+		return Response{}, err
+	}
+
+	var response Response
+	response.Data = AppRoleSecretIDData{
+		SecretId:         id,
+		SecretIdAccessor: entry.Accessor,
+		SecretIdTtl:      int(entry.TTL.Seconds()),
+		SecretIdNumUses:  entry.NumUses,
+	}
+	response.MountType = "approle"
+	response.RequestId = tools.GenerateXRequestID()
+
+	return response, nil
+}
+
+func (d *DVault) ListAppRoleSecretIDAccessors(ctx context.Context, mount string, name string) (Response, error) {
+	d.mu.RLock()
+	defer d.mu.RUnlock()
+
+	if d.isSealed {
+		return Response{}, ErrSealed
+	}
+
+	backend, err := d.appRole(mount)
+	if err != nil {
+		return Response{}, err
+	}
+
+	accessors, err := backend.ListSecretIDAccessors(ctx, name)
+	if err != nil {
+		return Response{}, err
+	}
+
+	var response Response
+	response.Data = KeyList{Keys: accessors}
+	response.MountType = "approle"
+	response.RequestId = tools.GenerateXRequestID()
+
+	return response, nil
+}
+
+func (d *DVault) LookupAppRoleSecretID(ctx context.Context, mount string, name string, secretID string) (Response, error) {
+	d.mu.RLock()
+	defer d.mu.RUnlock()
+
+	if d.isSealed {
+		return Response{}, ErrSealed
+	}
+
+	backend, err := d.appRole(mount)
+	if err != nil {
+		return Response{}, err
+	}
+
+	entry, err := backend.LookupSecretID(ctx, name, secretID)
+	if err != nil {
+		return Response{}, err
+	}
+
+	var response Response
+	response.Data = appRoleSecretIDLookup(entry)
+	response.MountType = "approle"
+	response.RequestId = tools.GenerateXRequestID()
+
+	return response, nil
+}
+
+func (d *DVault) DestroyAppRoleSecretID(ctx context.Context, mount string, name string, secretID string) (Response, error) {
+	d.mu.RLock()
+	defer d.mu.RUnlock()
+
+	if d.isSealed {
+		return Response{}, ErrSealed
+	}
+
+	backend, err := d.appRole(mount)
+	if err != nil {
+		return Response{}, err
+	}
+
+	if err = backend.DestroySecretID(ctx, name, secretID); err != nil {
+		return Response{}, err
+	}
+
+	var response Response
+	response.MountType = "approle"
+	response.RequestId = tools.GenerateXRequestID()
+
+	return response, nil
+}
+
+func (d *DVault) LookupAppRoleSecretIDAccessor(ctx context.Context, mount string, name string, accessor string) (Response, error) {
+	d.mu.RLock()
+	defer d.mu.RUnlock()
+
+	if d.isSealed {
+		return Response{}, ErrSealed
+	}
+
+	backend, err := d.appRole(mount)
+	if err != nil {
+		return Response{}, err
+	}
+
+	entry, err := backend.LookupSecretIDAccessor(ctx, name, accessor)
+	if err != nil {
+		return Response{}, err
+	}
+
+	var response Response
+	response.Data = appRoleSecretIDLookup(entry)
+	response.MountType = "approle"
+	response.RequestId = tools.GenerateXRequestID()
+
+	return response, nil
+}
+
+func (d *DVault) DestroyAppRoleSecretIDAccessor(ctx context.Context, mount string, name string, accessor string) (Response, error) {
+	d.mu.RLock()
+	defer d.mu.RUnlock()
+
+	if d.isSealed {
+		return Response{}, ErrSealed
+	}
+
+	backend, err := d.appRole(mount)
+	if err != nil {
+		return Response{}, err
+	}
+
+	if err = backend.DestroySecretIDAccessor(ctx, name, accessor); err != nil {
+		return Response{}, err
+	}
+
+	var response Response
+	response.MountType = "approle"
+	response.RequestId = tools.GenerateXRequestID()
+
+	return response, nil
+}
+
+func (d *DVault) TidyAppRoleSecretIDs(ctx context.Context, mount string) (Response, error) {
+	d.mu.RLock()
+	defer d.mu.RUnlock()
+
+	if d.isSealed {
+		return Response{}, ErrSealed
+	}
+
+	backend, err := d.appRole(mount)
+	if err != nil {
+		return Response{}, err
+	}
+
+	removed, err := backend.Tidy(ctx)
+	if err != nil {
+		return Response{}, err
+	}
+
+	var response Response
+	response.Data = AppRoleTidy{RemovedEntries: removed}
+	response.MountType = "approle"
+	response.RequestId = tools.GenerateXRequestID()
+
+	return response, nil
+}
+
+// LoginAppRole exchanges a role ID and secret ID for a token carrying the
+// token settings of the role.
+func (d *DVault) LoginAppRole(ctx context.Context, mount string, roleID string, secretID string, remoteIP string) (Response, error) {
+	d.mu.RLock()
+	defer d.mu.RUnlock()
+
+	if d.isSealed {
+		return Response{}, ErrSealed
+	}
+
+	backend, err := d.appRole(mount)
+	if err != nil {
+		return Response{}, err
+	}
+
+	role, entry, err := backend.Login(ctx, roleID, secretID, remoteIP)
+	if err != nil {
+		return Response{}, err
+	}
+
+	params := role.TokenParams
+	if len(entry.TokenBoundCIDRs) > 0 {
+		params.TokenBoundCIDRs = entry.TokenBoundCIDRs
+	}
+
+	meta := map[string]string{"role_name": role.Name}
+	for k, v := range entry.Metadata {
+		if k != "role_name" {
+			meta[k] = v
+		}
+	}
+
+	return d.issueLoginToken(ctx, login{
+		Mount:       mount,
+		MountType:   "approle",
+		Path:        filepath.Join(authPath, mount, "login"),
+		DisplayName: "approle",
+		Alias:       roleID,
+		Meta:        meta,
+		Params:      params,
+	})
+}
+
+func appRoleSecretIDLookup(entry approle.SecretID) AppRoleSecretIDLookup {
+	return AppRoleSecretIDLookup{
+		CidrList:         entry.CIDRList,
+		CreationTime:     entry.CreationTime,
+		ExpirationTime:   entry.ExpirationTime,
+		LastUpdatedTime:  entry.LastUpdatedTime,
+		Metadata:         entry.Metadata,
+		SecretIdAccessor: entry.Accessor,
+		SecretIdNumUses:  entry.NumUses,
+		SecretIdTtl:      int(entry.TTL.Seconds()),
+		TokenBoundCidrs:  entry.TokenBoundCIDRs,
+	}
+}
@@ -0,0 +1,144 @@
+package dvault
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"slices"
+
+	"github.com/Burzich/dvault/internal/dvault/auth"
+	"github.com/Burzich/dvault/internal/dvault/identity"
+	"github.com/Burzich/dvault/internal/dvault/policy"
+	"github.com/Burzich/dvault/internal/dvault/token"
+)
+
+// Authenticate resolves the client token and returns a context carrying the
+// token entry for the DVault methods called further down the request. Tokens
+// bound to CIDRs are only accepted from clientIP, and every request uses up
+// one use of a use-limited token.
+func (d *DVault) Authenticate(ctx context.Context, id string, clientIP string) (context.Context, error) {
+	d.mu.RLock()
+	defer d.mu.RUnlock()
+
+	if d.isSealed {
+		return nil, ErrSealed
+	}
+
+	if id == "" {
+		return nil, ErrPermissionDenied
+	}
+
+	entry, err := d.tokens.Lookup(ctx, id)
+	if errors.Is(err, token.ErrTokenNotFound) {
+		return nil, ErrPermissionDenied
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	if !auth.CIDRsContain(entry.BoundCIDRs, clientIP) {
+		return nil, fmt.Errorf("%w: source address %q unauthorized through CIDR restrictions on the token", ErrPermissionDenied, clientIP)
+	}
+
+	if entry.NumUses > 0 {
+		entry, err = d.tokens.Use(ctx, id)
+		if errors.Is(err, token.ErrTokenNotFound) {
+			return nil, ErrPermissionDenied
+		}
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	return withToken(ctx, entry), nil
+}
+
+// RevokeExhausted revokes the token resolved by Authenticate once the request
+// used its last use. Tokens that are not revoked here are left to the
+// expiration, which revokes exhausted tokens as well.
+func (d *DVault) RevokeExhausted(ctx context.Context) error {
+	entry, ok := tokenFromContext(ctx)
+	if !ok || !entry.UsesExhausted() {
+		return nil
+	}
+
+	d.mu.RLock()
+	defer d.mu.RUnlock()
+
+	if d.isSealed {
+		return ErrSealed
+	}
+
+	err := d.tokens.Revoke(ctx, entry.ID)
+	if errors.Is(err, token.ErrTokenNotFound) {
+		return nil
+	}
+
+	return err
+}
+
+// Authorize checks the policies of the token resolved by Authenticate against
+// the requested path, e.g. "kv/data/app" or "auth/token/create". Tokens of an
+// entity also get the policies of the entity and its groups. Paths with MFA
+// methods in the policy additionally need a passcode on every request.
+func (d *DVault) Authorize(ctx context.Context, path string, operation string) error {
+	entry, ok := tokenFromContext(ctx)
+	if !ok {
+		return ErrPermissionDenied
+	}
+
+	d.mu.RLock()
+	defer d.mu.RUnlock()
+
+	if d.isSealed {
+		return ErrSealed
+	}
+
+	identityPolicies, err := d.identity.Policies(ctx, entry.EntityID)
+	if errors.Is(err, identity.ErrEntityDisabled) {
+		return ErrPermissionDenied
+	}
+	if err != nil {
+		return err
+	}
+
+	acl, err := d.policies.ACL(ctx, mergePolicies(entry.Policies, identityPolicies))
+	if err != nil {
+		return err
+	}
+
+	if !acl.Allowed(path, policy.Operation(operation), isSudoPath(path)) {
+		return ErrPermissionDenied
+	}
+
+	return d.validateMFA(ctx, entry.EntityID, acl.MFAMethods(path))
+}
+
+// sudoPaths require the sudo capability in addition to the regular one.
+var sudoPaths = []string{
+	"auth/token/accessors",
+	"auth/token/revoke-orphan",
+	"sys/auth/*",
+	"sys/rotate",
+	"sys/seal",
+	"sys/pprof/*",
+}
+
+func isSudoPath(path string) bool {
+	return slices.ContainsFunc(sudoPaths, func(pattern string) bool {
+		return policy.Match(pattern, path)
+	})
+}
+
+// mergePolicies returns the token policies together with the policies granted
+// through identity, without duplicates.
+func mergePolicies(tokenPolicies []string, identityPolicies []string) []string {
+	if len(identityPolicies) == 0 {
+		return tokenPolicies
+	}
+
+	policies := slices.Concat(tokenPolicies, identityPolicies)
+	slices.Sort(policies)
+
+	return slices.Compact(policies)
+}
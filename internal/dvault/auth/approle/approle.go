@@ -0,0 +1,598 @@
+package approle
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"path/filepath"
+	"regexp"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/Burzich/dvault/internal/dvault/auth"
+	"github.com/Burzich/dvault/internal/dvault/storage"
+	"github.com/Burzich/dvault/internal/dvault/token"
+	"github.com/Burzich/dvault/internal/tools"
+	"github.com/google/uuid"
+)
+
+var validRoleName = regexp.MustCompile(`^[a-zA-Z0-9_-][a-zA-Z0-9_.-]*$`)
+
+type Role struct {
+	Name               string        `json:"name"`
+	RoleID             string        `json:"role_id"`
+	BindSecretID       bool          `json:"bind_secret_id"`
+	SecretIDBoundCIDRs []string      `json:"secret_id_bound_cidrs"`
+	SecretIDNumUses    int           `json:"secret_id_num_uses"`
+	SecretIDTTL        time.Duration `json:"secret_id_ttl"`
+	auth.TokenParams
+}
+
+type SecretID struct {
+	Accessor        string            `json:"accessor"`
+	Metadata        map[string]string `json:"metadata"`
+	CIDRList        []string          `json:"cidr_list"`
+	TokenBoundCIDRs []string          `json:"token_bound_cidrs"`
+	NumUses         int               `json:"num_uses"`
+	TTL             time.Duration     `json:"ttl"`
+	CreationTime    time.Time         `json:"creation_time"`
+	ExpirationTime  time.Time         `json:"expiration_time"`
+	LastUpdatedTime time.Time         `json:"last_updated_time"`
+}
+
+func (s SecretID) Expired(now time.Time) bool {
+	return !s.ExpirationTime.IsZero() && !now.Before(s.ExpirationTime)
+}
+
+// Backend stores AppRole roles and their secret IDs below path. Secret IDs
+// and role IDs are only stored hashed.
+type Backend struct {
+	path      string
+	storage   storage.Storage
+	encryptor tools.Encryptor
+
+	mu sync.Mutex
+}
+
+func New(path string, s storage.Storage, encryptor tools.Encryptor) *Backend {
+	return &Backend{
+		path:      path,
+		storage:   s,
+		encryptor: encryptor,
+	}
+}
+
+func (b *Backend) ListRoles(ctx context.Context) ([]string, error) {
+	return b.storage.List(ctx, filepath.Join(b.path, "role"))
+}
+
+func (b *Backend) GetRole(ctx context.Context, name string) (Role, error) {
+	if !validRoleName.MatchString(name) {
+		return Role{}, auth.ErrRoleNotFound
+	}
+
+	var role Role
+	err := b.read(ctx, b.rolePath(name), &role)
+	if errors.Is(err, storage.ErrPathNotFound) {
+		return Role{}, auth.ErrRoleNotFound
+	}
+	if err != nil {
+		return Role{}, err
+	}
+
+	return role, nil
+}
+
+// PutRole creates or updates a role. A new role gets a random role ID unless
+// one is given.
+func (b *Backend) PutRole(ctx context.Context, role Role) (Role, error) {
+	if !validRoleName.MatchString(role.Name) {
+		return Role{}, fmt.Errorf("%w: invalid role name %q", auth.ErrInvalidConfig, role.Name)
+	}
+
+	if err := role.TokenParams.Validate(); err != nil {
+		return Role{}, err
+	}
+
+	if err := auth.ValidateCIDRs(role.SecretIDBoundCIDRs); err != nil {
+		return Role{}, err
+	}
+
+	if role.SecretIDNumUses < 0 || role.SecretIDTTL < 0 {
+		return Role{}, fmt.Errorf("%w: secret ID settings can not be negative", auth.ErrInvalidConfig)
+	}
+
+	if !role.BindSecretID && len(role.SecretIDBoundCIDRs) == 0 && len(role.TokenBoundCIDRs) == 0 {
+		return Role{}, fmt.Errorf("%w: at least one constraint should be enabled on the role", auth.ErrInvalidConfig)
+	}
+
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	old, err := b.GetRole(ctx, role.Name)
+	if err != nil && !errors.Is(err, auth.ErrRoleNotFound) {
+		return Role{}, err
+	}
+
+	if role.RoleID == "" {
+		role.RoleID = old.RoleID
+	}
+	if role.RoleID == "" {
+		role.RoleID = uuid.NewString()
+	}
+
+	if err = b.putRole(ctx, old, role); err != nil {
+		return Role{}, err
+	}
+
+	return role, nil
+}
+
+// SetRoleID replaces the role ID of an existing role.
+func (b *Backend) SetRoleID(ctx context.Context, name string, roleID string) error {
+	if roleID == "" {
+		return fmt.Errorf("%w: missing role_id", auth.ErrInvalidConfig)
+	}
+
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	role, err := b.GetRole(ctx, name)
+	if err != nil {
+		return err
+	}
+
+	updated := role
+	updated.RoleID = roleID
+
+	return b.putRole(ctx, role, updated)
+}
+
+func (b *Backend) DeleteRole(ctx context.Context, name string) error {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	role, err := b.GetRole(ctx, name)
+	if err != nil {
+		return err
+	}
+
+	hashes, err := b.storage.List(ctx, b.secretIDsPath(name))
+	if err != nil {
+		return err
+	}
+
+	for _, hash := range hashes {
+		if err = b.destroySecretID(ctx, name, hash); err != nil {
+			return err
+		}
+	}
+
+	if err = b.delete(ctx, b.roleIDPath(role.RoleID)); err != nil {
+		return err
+	}
+
+	return b.delete(ctx, b.rolePath(name))
+}
+
+// GenerateSecretID issues a new secret ID for the role. A custom secret ID may
+// be supplied, otherwise a random one is generated. The secret ID is returned
+// only here.
+func (b *Backend) GenerateSecretID(ctx context.Context, name string, secretID string, params SecretID) (string, SecretID, error) {
+	if err := auth.ValidateCIDRs(params.CIDRList); err != nil {
+		return "", SecretID{}, err
+	}
+
+	if err := auth.ValidateCIDRs(params.TokenBoundCIDRs); err != nil {
+		return "", SecretID{}, err
+	}
+
+	if params.NumUses < 0 || params.TTL < 0 {
+		return "", SecretID{}, fmt.Errorf("%w: secret ID settings can not be negative", auth.ErrInvalidConfig)
+	}
+
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	role, err := b.GetRole(ctx, name)
+	if err != nil {
+		return "", SecretID{}, err
+	}
+
+	if !role.BindSecretID {
+		return "", SecretID{}, fmt.Errorf("%w: bind_secret_id is not set on the role", auth.ErrInvalidConfig)
+	}
+
+	for _, cidr := range params.CIDRList {
+		if !cidrWithin(cidr, role.SecretIDBoundCIDRs) {
+			return "", SecretID{}, fmt.Errorf("%w: cidr_list %q is not within the role's secret_id_bound_cidrs", auth.ErrInvalidConfig, cidr)
+		}
+	}
+
+	if secretID == "" {
+		secretID = uuid.NewString()
+	}
+
+	hash := hashID(secretID)
+	if _, err = b.readSecretID(ctx, name, hash); err == nil {
+		return "", SecretID{}, fmt.Errorf("%w: secret ID already registered", auth.ErrInvalidConfig)
+	}
+
+	if role.SecretIDNumUses > 0 && (params.NumUses == 0 || params.NumUses > role.SecretIDNumUses) {
+		params.NumUses = role.SecretIDNumUses
+	}
+
+	if role.SecretIDTTL > 0 && (params.TTL == 0 || params.TTL > role.SecretIDTTL) {
+		params.TTL = role.SecretIDTTL
+	}
+
+	now := time.Now()
+	params.Accessor = uuid.NewString()
+	params.CreationTime = now
+	params.LastUpdatedTime = now
+	params.ExpirationTime = time.Time{}
+	if params.TTL > 0 {
+		params.ExpirationTime = now.Add(params.TTL)
+	}
+
+	if err = b.write(ctx, b.secretIDPath(name, hash), params); err != nil {
+		return "", SecretID{}, err
+	}
+
+	if err = b.write(ctx, b.accessorPath(name, params.Accessor), hash); err != nil {
+		return "", SecretID{}, err
+	}
+
+	return secretID, params, nil
+}
+
+func (b *Backend) ListSecretIDAccessors(ctx context.Context, name string) ([]string, error) {
+	if _, err := b.GetRole(ctx, name); err != nil {
+		return nil, err
+	}
+
+	return b.storage.List(ctx, b.accessorsPath(name))
+}
+
+func (b *Backend) LookupSecretID(ctx context.Context, name string, secretID string) (SecretID, error) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	return b.readSecretID(ctx, name, hashID(secretID))
+}
+
+func (b *Backend) DestroySecretID(ctx context.Context, name string, secretID string) error {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	hash := hashID(secretID)
+	if _, err := b.readSecretID(ctx, name, hash); err != nil {
+		return err
+	}
+
+	return b.destroySecretID(ctx, name, hash)
+}
+
+func (b *Backend) LookupSecretIDAccessor(ctx context.Context, name string, accessor string) (SecretID, error) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	hash, err := b.readAccessor(ctx, name, accessor)
+	if err != nil {
+		return SecretID{}, err
+	}
+
+	return b.readSecretID(ctx, name, hash)
+}
+
+func (b *Backend) DestroySecretIDAccessor(ctx context.Context, name string, accessor string) error {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	hash, err := b.readAccessor(ctx, name, accessor)
+	if err != nil {
+		return err
+	}
+
+	return b.destroySecretID(ctx, name, hash)
+}
+
+// Login validates a role ID and secret ID pair presented from remoteIP. Using
+// the secret ID counts against its use limit, and it is destroyed once the
+// limit is reached.
+func (b *Backend) Login(ctx context.Context, roleID string, secretID string, remoteIP string) (Role, SecretID, error) {
+	invalid := fmt.Errorf("%w: invalid role or secret ID", auth.ErrInvalidCredentials)
+
+	if roleID == "" {
+		return Role{}, SecretID{}, invalid
+	}
+
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	var name string
+	err := b.read(ctx, b.roleIDPath(roleID), &name)
+	if errors.Is(err, storage.ErrPathNotFound) {
+		return Role{}, SecretID{}, invalid
+	}
+	if err != nil {
+		return Role{}, SecretID{}, err
+	}
+
+	role, err := b.GetRole(ctx, name)
+	if errors.Is(err, auth.ErrRoleNotFound) {
+		return Role{}, SecretID{}, invalid
+	}
+	if err != nil {
+		return Role{}, SecretID{}, err
+	}
+
+	if !auth.CIDRsContain(role.SecretIDBoundCIDRs, remoteIP) {
+		return Role{}, SecretID{}, fmt.Errorf("%w: source address %q unauthorized through CIDR restrictions on the role", auth.ErrInvalidCredentials, remoteIP)
+	}
+
+	if !role.BindSecretID {
+		return role, SecretID{}, nil
+	}
+
+	hash := hashID(secretID)
+	entry, err := b.readSecretID(ctx, name, hash)
+	if errors.Is(err, ErrSecretIDNotFound) {
+		return Role{}, SecretID{}, invalid
+	}
+	if err != nil {
+		return Role{}, SecretID{}, err
+	}
+
+	if !auth.CIDRsContain(entry.CIDRList, remoteIP) {
+		return Role{}, SecretID{}, fmt.Errorf("%w: source address %q unauthorized through CIDR restrictions on the secret ID", auth.ErrInvalidCredentials, remoteIP)
+	}
+
+	switch {
+	case entry.NumUses == 1:
+		err = b.destroySecretID(ctx, name, hash)
+	case entry.NumUses > 1:
+		entry.NumUses--
+		entry.LastUpdatedTime = time.Now()
+		err = b.write(ctx, b.secretIDPath(name, hash), entry)
+	}
+	if err != nil {
+		return Role{}, SecretID{}, err
+	}
+
+	return role, entry, nil
+}
+
+// Tidy removes expired secret IDs and accessors whose secret ID is gone.
+func (b *Backend) Tidy(ctx context.Context) (int, error) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	names, err := b.storage.List(ctx, filepath.Join(b.path, "secret-id"))
+	if err != nil {
+		return 0, err
+	}
+
+	now := time.Now()
+	removed := 0
+	for _, name := range names {
+		name = strings.TrimSuffix(name, "/")
+
+		hashes, err := b.storage.List(ctx, b.secretIDsPath(name))
+		if err != nil {
+			return removed, err
+		}
+
+		for _, hash := range hashes {
+			var entry SecretID
+			if err = b.read(ctx, b.secretIDPath(name, hash), &entry); err != nil {
+				return removed, err
+			}
+
+			if entry.Expired(now) {
+				if err = b.destroySecretID(ctx, name, hash); err != nil {
+					return removed, err
+				}
+				removed++
+			}
+		}
+
+		accessors, err := b.storage.List(ctx, b.accessorsPath(name))
+		if err != nil {
+			return removed, err
+		}
+
+		for _, accessor := range accessors {
+			var hash string
+			if err = b.read(ctx, b.accessorPath(name, accessor), &hash); err != nil {
+				return removed, err
+			}
+
+			if _, err = b.storage.Get(ctx, b.secretIDPath(name, hash)); errors.Is(err, storage.ErrPathNotFound) {
+				if err = b.delete(ctx, b.accessorPath(name, accessor)); err != nil {
+					return removed, err
+				}
+				removed++
+			}
+		}
+	}
+
+	return removed, nil
+}
+
+func (b *Backend) putRole(ctx context.Context, old Role, role Role) error {
+	if role.RoleID != old.RoleID {
+		var owner string
+		err := b.read(ctx, b.roleIDPath(role.RoleID), &owner)
+		if err == nil && owner != role.Name {
+			return fmt.Errorf("%w: role_id already in use", auth.ErrInvalidConfig)
+		}
+		if err != nil && !errors.Is(err, storage.ErrPathNotFound) {
+			return err
+		}
+
+		if err = b.write(ctx, b.roleIDPath(role.RoleID), role.Name); err != nil {
+			return err
+		}
+
+		if old.RoleID != "" {
+			if err = b.delete(ctx, b.roleIDPath(old.RoleID)); err != nil {
+				return err
+			}
+		}
+	}
+
+	return b.write(ctx, b.rolePath(role.Name), role)
+}
+
+func (b *Backend) readSecretID(ctx context.Context, name string, hash string) (SecretID, error) {
+	if !validRoleName.MatchString(name) {
+		return SecretID{}, ErrSecretIDNotFound
+	}
+
+	var entry SecretID
+	err := b.read(ctx, b.secretIDPath(name, hash), &entry)
+	if errors.Is(err, storage.ErrPathNotFound) {
+		return SecretID{}, ErrSecretIDNotFound
+	}
+	if err != nil {
+		return SecretID{}, err
+	}
+
+	if entry.Expired(time.Now()) {
+		return SecretID{}, ErrSecretIDNotFound
+	}
+
+	return entry, nil
+}
+
+func (b *Backend) readAccessor(ctx context.Context, name string, accessor string) (string, error) {
+	if !validRoleName.MatchString(name) || accessor == "" || strings.ContainsAny(accessor, "./") {
+		return "", ErrSecretIDNotFound
+	}
+
+	var hash string
+	err := b.read(ctx, b.accessorPath(name, accessor), &hash)
+	if errors.Is(err, storage.ErrPathNotFound) {
+		return "", ErrSecretIDNotFound
+	}
+	if err != nil {
+		return "", err
+	}
+
+	return hash, nil
+}
+
+func (b *Backend) destroySecretID(ctx context.Context, name string, hash string) error {
+	var entry SecretID
+	err := b.read(ctx, b.secretIDPath(name, hash), &entry)
+	if err != nil && !errors.Is(err, storage.ErrPathNotFound) {
+		return err
+	}
+
+	if entry.Accessor != "" {
+		if err = b.delete(ctx, b.accessorPath(name, entry.Accessor)); err != nil {
+			return err
+		}
+	}
+
+	return b.delete(ctx, b.secretIDPath(name, hash))
+}
+
+func (b *Backend) read(ctx context.Context, path string, v any) error {
+	data, err := b.storage.Get(ctx, path)
+	if err != nil {
+		return err
+	}
+
+	decryptedData, err := b.encryptor.Decrypt(data)
+	if err != nil {
+		return err
+	}
+
+	return json.Unmarshal(decryptedData, v)
+}
+
+func (b *Backend) write(ctx context.Context, path string, v any) error {
+	d, err := json.Marshal(v)
+	if err != nil {
+		return err
+	}
+
+	encryptedData, err := b.encryptor.Encrypt(d)
+	if err != nil {
+		return err
+	}
+
+	return b.storage.Put(ctx, path, encryptedData)
+}
+
+func (b *Backend) delete(ctx context.Context, path string) error {
+	err := b.storage.Delete(ctx, path)
+	if errors.Is(err, storage.ErrPathNotFound) {
+		return nil
+	}
+
+	return err
+}
+
+func (b *Backend) rolePath(name string) string {
+	return filepath.Join(b.path, "role", name)
+}
+
+func (b *Backend) roleIDPath(roleID string) string {
+	return filepath.Join(b.path, "role-id", hashID(roleID))
+}
+
+func (b *Backend) secretIDsPath(name string) string {
+	return filepath.Join(b.path, "secret-id", name)
+}
+
+func (b *Backend) secretIDPath(name string, hash string) string {
+	return filepath.Join(b.path, "secret-id", name, hash)
+}
+
+func (b *Backend) accessorsPath(name string) string {
+	return filepath.Join(b.path, "accessor", name)
+}
+
+func (b *Backend) accessorPath(name string, accessor string) string {
+	return filepath.Join(b.path, "accessor", name, accessor)
+}
+
+// cidrWithin reports whether cidr lies inside one of bounds. Without bounds
+// any CIDR is allowed.
+func cidrWithin(cidr string, bounds []string) bool {
+	if len(bounds) == 0 {
+		return true
+	}
+
+	ipNet, err := token.ParseCIDR(cidr)
+	if err != nil {
+		return false
+	}
+
+	ones, _ := ipNet.Mask.Size()
+	for _, bound := range bounds {
+		boundNet, err := token.ParseCIDR(bound)
+		if err != nil {
+			continue
+		}
+
+		boundOnes, _ := boundNet.Mask.Size()
+		if boundNet.Contains(ipNet.IP) && boundOnes <= ones {
+			return true
+		}
+	}
+
+	return false
+}
+
+func hashID(id string) string {
+	sum := sha256.Sum256([]byte(id))
+	return hex.EncodeToString(sum[:])
+}
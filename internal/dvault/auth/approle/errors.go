@@ -0,0 +1,5 @@
+package approle
+
+import "errors"
+
+var ErrSecretIDNotFound = errors.New("secret ID not found")
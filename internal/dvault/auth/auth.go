@@ -0,0 +1,72 @@
+package auth
+
+import (
+	"fmt"
+	"net"
+	"time"
+
+	"github.com/Burzich/dvault/internal/dvault/token"
+)
+
+// TokenParams are the token settings shared by the roles and users of all
+// auth methods. They mirror the token_* fields of Vault.
+type TokenParams struct {
+	TokenPolicies        []string      `json:"token_policies"`
+	TokenTTL             time.Duration `json:"token_ttl"`
+	TokenMaxTTL          time.Duration `json:"token_max_ttl"`
+	TokenPeriod          time.Duration `json:"token_period"`
+	TokenNumUses         int           `json:"token_num_uses"`
+	TokenBoundCIDRs      []string      `json:"token_bound_cidrs"`
+	TokenType            string        `json:"token_type"`
+	TokenNoDefaultPolicy bool          `json:"token_no_default_policy"`
+}
+
+func (p TokenParams) Validate() error {
+	switch p.TokenType {
+	case "", token.TypeService, token.TypeBatch:
+	default:
+		return fmt.Errorf("%w %q", token.ErrInvalidType, p.TokenType)
+	}
+
+	if p.TokenTTL < 0 || p.TokenMaxTTL < 0 || p.TokenPeriod < 0 || p.TokenNumUses < 0 {
+		return fmt.Errorf("%w: token settings can not be negative", ErrInvalidConfig)
+	}
+
+	if p.TokenMaxTTL > 0 && p.TokenTTL > p.TokenMaxTTL {
+		return fmt.Errorf("%w: token_ttl can not be greater than token_max_ttl", ErrInvalidConfig)
+	}
+
+	return ValidateCIDRs(p.TokenBoundCIDRs)
+}
+
+func ValidateCIDRs(cidrs []string) error {
+	for _, cidr := range cidrs {
+		if _, err := token.ParseCIDR(cidr); err != nil {
+			return fmt.Errorf("%w: %s", ErrInvalidConfig, err)
+		}
+	}
+
+	return nil
+}
+
+// CIDRsContain reports whether ip lies in one of cidrs. An empty list allows
+// every address.
+func CIDRsContain(cidrs []string, ip string) bool {
+	if len(cidrs) == 0 {
+		return true
+	}
+
+	addr := net.ParseIP(ip)
+	if addr == nil {
+		return false
+	}
+
+	for _, cidr := range cidrs {
+		ipNet, err := token.ParseCIDR(cidr)
+		if err == nil && ipNet.Contains(addr) {
+			return true
+		}
+	}
+
+	return false
+}
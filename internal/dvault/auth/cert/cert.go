@@ -0,0 +1,229 @@
+package cert
+
+import (
+	"context"
+	"crypto/x509"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"path"
+	"path/filepath"
+	"regexp"
+	"slices"
+	"sync"
+
+	"github.com/Burzich/dvault/internal/dvault/auth"
+	"github.com/Burzich/dvault/internal/dvault/storage"
+	"github.com/Burzich/dvault/internal/tools"
+)
+
+var validRoleName = regexp.MustCompile(`^[a-zA-Z0-9_-][a-zA-Z0-9_.-]*$`)
+
+// Role trusts client certificates issued by the CAs in Certificate. The
+// allowed_* lists are glob patterns, a certificate has to match one entry of
+// every list that is set.
+type Role struct {
+	Name                       string   `json:"name"`
+	Certificate                string   `json:"certificate"`
+	DisplayName                string   `json:"display_name"`
+	AllowedCommonNames         []string `json:"allowed_common_names"`
+	AllowedDNSSANs             []string `json:"allowed_dns_sans"`
+	AllowedOrganizationalUnits []string `json:"allowed_organizational_units"`
+	auth.TokenParams
+}
+
+// Backend stores the trusted certificate roles below path.
+type Backend struct {
+	path      string
+	storage   storage.Storage
+	encryptor tools.Encryptor
+
+	mu sync.Mutex
+}
+
+func New(path string, s storage.Storage, encryptor tools.Encryptor) *Backend {
+	return &Backend{
+		path:      path,
+		storage:   s,
+		encryptor: encryptor,
+	}
+}
+
+func (b *Backend) ListRoles(ctx context.Context) ([]string, error) {
+	return b.storage.List(ctx, filepath.Join(b.path, "certs"))
+}
+
+func (b *Backend) GetRole(ctx context.Context, name string) (Role, error) {
+	if !validRoleName.MatchString(name) {
+		return Role{}, auth.ErrRoleNotFound
+	}
+
+	var role Role
+	err := b.read(ctx, b.rolePath(name), &role)
+	if errors.Is(err, storage.ErrPathNotFound) {
+		return Role{}, auth.ErrRoleNotFound
+	}
+	if err != nil {
+		return Role{}, err
+	}
+
+	return role, nil
+}
+
+func (b *Backend) PutRole(ctx context.Context, role Role) error {
+	if !validRoleName.MatchString(role.Name) {
+		return fmt.Errorf("%w: invalid role name %q", auth.ErrInvalidConfig, role.Name)
+	}
+
+	if role.Certificate == "" {
+		return fmt.Errorf("%w: missing certificate", auth.ErrInvalidConfig)
+	}
+
+	if !x509.NewCertPool().AppendCertsFromPEM([]byte(role.Certificate)) {
+		return fmt.Errorf("%w: could not parse certificate", auth.ErrInvalidConfig)
+	}
+
+	for _, patterns := range [][]string{role.AllowedCommonNames, role.AllowedDNSSANs, role.AllowedOrganizationalUnits} {
+		for _, pattern := range patterns {
+			if _, err := path.Match(pattern, ""); err != nil {
+				return fmt.Errorf("%w: invalid pattern %q", auth.ErrInvalidConfig, pattern)
+			}
+		}
+	}
+
+	if err := role.TokenParams.Validate(); err != nil {
+		return err
+	}
+
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	return b.write(ctx, b.rolePath(role.Name), role)
+}
+
+func (b *Backend) DeleteRole(ctx context.Context, name string) error {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	if _, err := b.GetRole(ctx, name); err != nil {
+		return err
+	}
+
+	return b.storage.Delete(ctx, b.rolePath(name))
+}
+
+// Login returns the role trusting the client certificate chain. The leaf
+// certificate comes first, the rest are intermediates. Without a name all
+// roles are tried in order.
+func (b *Backend) Login(ctx context.Context, name string, chain []*x509.Certificate) (Role, error) {
+	if len(chain) == 0 {
+		return Role{}, fmt.Errorf("%w: client certificate must be supplied", auth.ErrInvalidCredentials)
+	}
+
+	names := []string{name}
+	if name == "" {
+		var err error
+		names, err = b.ListRoles(ctx)
+		if err != nil {
+			return Role{}, err
+		}
+	}
+
+	for _, n := range names {
+		role, err := b.GetRole(ctx, n)
+		if err != nil {
+			return Role{}, err
+		}
+
+		if role.verify(chain) {
+			return role, nil
+		}
+	}
+
+	return Role{}, fmt.Errorf("%w: invalid certificate or no client certificate supplied", auth.ErrInvalidCredentials)
+}
+
+func (r Role) verify(chain []*x509.Certificate) bool {
+	roots := x509.NewCertPool()
+	if !roots.AppendCertsFromPEM([]byte(r.Certificate)) {
+		return false
+	}
+
+	intermediates := x509.NewCertPool()
+	for _, c := range chain[1:] {
+		intermediates.AddCert(c)
+	}
+
+	leaf := chain[0]
+	_, err := leaf.Verify(x509.VerifyOptions{
+		Roots:         roots,
+		Intermediates: intermediates,
+		KeyUsages:     []x509.ExtKeyUsage{x509.ExtKeyUsageClientAuth},
+	})
+	if err != nil {
+		return false
+	}
+
+	return matchAny(r.AllowedCommonNames, []string{leaf.Subject.CommonName}) &&
+		matchAny(r.AllowedDNSSANs, leaf.DNSNames) &&
+		matchAny(r.AllowedOrganizationalUnits, leaf.Subject.OrganizationalUnit)
+}
+
+// matchAny reports whether one of values matches one of patterns. An empty
+// list of patterns allows everything.
+func matchAny(patterns []string, values []string) bool {
+	if len(patterns) == 0 {
+		return true
+	}
+
+	return slices.ContainsFunc(patterns, func(pattern string) bool {
+		return slices.ContainsFunc(values, func(value string) bool {
+			ok, _ := path.Match(pattern, value)
+			return ok
+		})
+	})
+}
+
+// Metadata describes the certificate a token was issued for.
+func Metadata(role Role, leaf *x509.Certificate) map[string]string {
+	return map[string]string{
+		"cert_name":        role.Name,
+		"common_name":      leaf.Subject.CommonName,
+		"serial_number":    leaf.SerialNumber.String(),
+		"subject_key_id":   hex.EncodeToString(leaf.SubjectKeyId),
+		"authority_key_id": hex.EncodeToString(leaf.AuthorityKeyId),
+	}
+}
+
+func (b *Backend) read(ctx context.Context, path string, v any) error {
+	data, err := b.storage.Get(ctx, path)
+	if err != nil {
+		return err
+	}
+
+	decryptedData, err := b.encryptor.Decrypt(data)
+	if err != nil {
+		return err
+	}
+
+	return json.Unmarshal(decryptedData, v)
+}
+
+func (b *Backend) write(ctx context.Context, path string, v any) error {
+	d, err := json.Marshal(v)
+	if err != nil {
+		return err
+	}
+
+	encryptedData, err := b.encryptor.Encrypt(d)
+	if err != nil {
+		return err
+	}
+
+	return b.storage.Put(ctx, path, encryptedData)
+}
+
+func (b *Backend) rolePath(name string) string {
+	return filepath.Join(b.path, "certs", name)
+}
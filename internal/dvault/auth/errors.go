@@ -0,0 +1,8 @@
+package auth
+
+import "errors"
+
+var ErrInvalidCredentials = errors.New("invalid credentials")
+var ErrInvalidConfig = errors.New("invalid configuration")
+var ErrRoleNotFound = errors.New("role not found")
+var ErrUserLocked = errors.New("user is locked out")
@@ -0,0 +1,151 @@
+package jwt
+
+import (
+	"fmt"
+	"math"
+	"slices"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/Burzich/dvault/internal/dvault/auth"
+)
+
+const (
+	defaultClockSkewLeeway  = 60 * time.Second
+	defaultExpirationLeeway = 150 * time.Second
+	defaultNotBeforeLeeway  = 150 * time.Second
+)
+
+// leeway returns the configured leeway, the default when unset and none when
+// negative, like Vault does.
+func leeway(configured time.Duration, def time.Duration) time.Duration {
+	switch {
+	case configured < 0:
+		return 0
+	case configured == 0:
+		return def
+	default:
+		return configured
+	}
+}
+
+// validateClaims checks the registered claims and the bindings of role.
+func validateClaims(claims map[string]any, cfg Config, role Role, now time.Time) error {
+	skew := leeway(role.ClockSkewLeeway, defaultClockSkewLeeway)
+
+	exp, ok := numericClaim(claims, "exp")
+	if !ok {
+		return invalidToken("missing exp claim")
+	}
+	if now.After(exp.Add(leeway(role.ExpirationLeeway, defaultExpirationLeeway) + skew)) {
+		return invalidToken("token is expired")
+	}
+
+	nbf, ok := numericClaim(claims, "nbf")
+	if !ok {
+		nbf, ok = numericClaim(claims, "iat")
+	}
+	if ok && now.Before(nbf.Add(-leeway(role.NotBeforeLeeway, defaultNotBeforeLeeway)-skew)) {
+		return invalidToken("token is not yet valid")
+	}
+
+	if cfg.BoundIssuer != "" && claims["iss"] != cfg.BoundIssuer {
+		return invalidToken("invalid issuer claim")
+	}
+
+	audiences := stringsClaim(claims["aud"])
+	if len(role.BoundAudiences) > 0 {
+		if !slices.ContainsFunc(audiences, func(aud string) bool { return slices.Contains(role.BoundAudiences, aud) }) {
+			return invalidToken("invalid audience claim")
+		}
+	} else if len(audiences) > 0 {
+		return invalidToken("audience claim found in JWT but no audiences bound to the role")
+	}
+
+	if role.BoundSubject != "" && claims["sub"] != role.BoundSubject {
+		return invalidToken("invalid subject claim")
+	}
+
+	for name, expected := range role.BoundClaims {
+		actual := stringsClaim(lookupClaim(claims, name))
+		allowed := stringsClaim(expected)
+		if !slices.ContainsFunc(actual, func(value string) bool { return slices.Contains(allowed, value) }) {
+			return invalidToken(fmt.Sprintf("claim %q does not match any associated bound claim values", name))
+		}
+	}
+
+	return nil
+}
+
+// lookupClaim returns a claim by name. Names starting with a slash are JSON
+// pointers into nested claims, e.g. "/groups/primary".
+func lookupClaim(claims map[string]any, name string) any {
+	if !strings.HasPrefix(name, "/") {
+		return claims[name]
+	}
+
+	var current any = claims
+	for _, segment := range strings.Split(name[1:], "/") {
+		segment = strings.ReplaceAll(strings.ReplaceAll(segment, "~1", "/"), "~0", "~")
+
+		object, ok := current.(map[string]any)
+		if !ok {
+			return nil
+		}
+		current = object[segment]
+	}
+
+	return current
+}
+
+func numericClaim(claims map[string]any, name string) (time.Time, bool) {
+	value, ok := claims[name].(float64)
+	if !ok {
+		return time.Time{}, false
+	}
+
+	seconds, fraction := math.Modf(value)
+
+	return time.Unix(int64(seconds), int64(fraction*1e9)), true
+}
+
+// stringsClaim converts a claim that is either a single value or a list to a
+// list of strings. Values that are neither strings, numbers nor booleans are
+// skipped.
+func stringsClaim(value any) []string {
+	switch v := value.(type) {
+	case []any:
+		values := make([]string, 0, len(v))
+		for _, item := range v {
+			if s, ok := stringClaim(item); ok {
+				values = append(values, s)
+			}
+		}
+		return values
+	case []string:
+		return v
+	default:
+		if s, ok := stringClaim(v); ok {
+			return []string{s}
+		}
+		return nil
+	}
+}
+
+func stringClaim(value any) (string, bool) {
+	switch v := value.(type) {
+	case string:
+		return v, true
+	case float64:
+		return strconv.FormatFloat(v, 'f', -1, 64), true
+	case bool:
+		return strconv.FormatBool(v), true
+	default:
+		return "", false
+	}
+}
+
+func invalidToken(reason string) error {
+	return fmt.Errorf("%w: %s", auth.ErrInvalidCredentials, reason)
+}
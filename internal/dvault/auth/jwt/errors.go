@@ -0,0 +1,5 @@
+package jwt
+
+import "errors"
+
+var ErrNotConfigured = errors.New("jwt auth method is not configured")
@@ -0,0 +1,393 @@
+package jwt
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"path/filepath"
+	"regexp"
+	"slices"
+	"sync"
+	"time"
+
+	"github.com/Burzich/dvault/internal/dvault/auth"
+	"github.com/Burzich/dvault/internal/dvault/storage"
+	"github.com/Burzich/dvault/internal/tools"
+	"github.com/go-jose/go-jose/v4"
+)
+
+const RoleTypeJWT = "jwt"
+
+var validRoleName = regexp.MustCompile(`^[a-zA-Z0-9_-][a-zA-Z0-9_.-]*$`)
+
+var supportedAlgs = []jose.SignatureAlgorithm{
+	jose.RS256, jose.RS384, jose.RS512,
+	jose.PS256, jose.PS384, jose.PS512,
+	jose.ES256, jose.ES384, jose.ES512,
+	jose.EdDSA,
+}
+
+// Config holds the keys tokens are verified with. Either a JWKS URL or a list
+// of static PEM keys is used.
+type Config struct {
+	JWKSURL              string   `json:"jwks_url"`
+	JWKSCAPEM            string   `json:"jwks_ca_pem"`
+	JWTValidationPubKeys []string `json:"jwt_validation_pubkeys"`
+	BoundIssuer          string   `json:"bound_issuer"`
+	JWTSupportedAlgs     []string `json:"jwt_supported_algs"`
+	DefaultRole          string   `json:"default_role"`
+}
+
+// Role binds the claims a token must carry to log in. Bound claim values are
+// either a string or a list of strings, any of which must match.
+type Role struct {
+	Name             string            `json:"name"`
+	RoleType         string            `json:"role_type"`
+	BoundAudiences   []string          `json:"bound_audiences"`
+	BoundSubject     string            `json:"bound_subject"`
+	BoundClaims      map[string]any    `json:"bound_claims"`
+	ClaimMappings    map[string]string `json:"claim_mappings"`
+	UserClaim        string            `json:"user_claim"`
+	GroupsClaim      string            `json:"groups_claim"`
+	ClockSkewLeeway  time.Duration     `json:"clock_skew_leeway"`
+	ExpirationLeeway time.Duration     `json:"expiration_leeway"`
+	NotBeforeLeeway  time.Duration     `json:"not_before_leeway"`
+	auth.TokenParams
+}
+
+// Result describes a successful login.
+type Result struct {
+	Role     Role
+	User     string
+	Groups   []string
+	Metadata map[string]string
+}
+
+// Backend stores the JWT configuration and roles below path.
+type Backend struct {
+	path      string
+	storage   storage.Storage
+	encryptor tools.Encryptor
+
+	mu   sync.Mutex
+	keys *keySet
+}
+
+func New(path string, s storage.Storage, encryptor tools.Encryptor) *Backend {
+	return &Backend{
+		path:      path,
+		storage:   s,
+		encryptor: encryptor,
+	}
+}
+
+func (b *Backend) GetConfig(ctx context.Context) (Config, error) {
+	var config Config
+	err := b.read(ctx, b.configPath(), &config)
+	if errors.Is(err, storage.ErrPathNotFound) {
+		return Config{}, ErrNotConfigured
+	}
+	if err != nil {
+		return Config{}, err
+	}
+
+	return config, nil
+}
+
+// PutConfig validates and stores the configuration. A JWKS URL is fetched
+// once, so a wrong URL is reported here and not on the first login.
+func (b *Backend) PutConfig(ctx context.Context, config Config) error {
+	if (config.JWKSURL == "") == (len(config.JWTValidationPubKeys) == 0) {
+		return fmt.Errorf("%w: exactly one of jwks_url and jwt_validation_pubkeys must be set", auth.ErrInvalidConfig)
+	}
+
+	if config.JWKSCAPEM != "" && config.JWKSURL == "" {
+		return fmt.Errorf("%w: jwks_ca_pem requires jwks_url", auth.ErrInvalidConfig)
+	}
+
+	if _, err := signatureAlgs(config.JWTSupportedAlgs); err != nil {
+		return err
+	}
+
+	if _, err := parsePublicKeys(config.JWTValidationPubKeys); err != nil {
+		return err
+	}
+
+	var keys *keySet
+	if config.JWKSURL != "" {
+		keys = newKeySet(config.JWKSURL, config.JWKSCAPEM)
+		if _, err := keys.Keys(ctx, ""); err != nil {
+			return fmt.Errorf("%w: %s", auth.ErrInvalidConfig, err)
+		}
+	}
+
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	if err := b.write(ctx, b.configPath(), config); err != nil {
+		return err
+	}
+
+	b.keys = keys
+
+	return nil
+}
+
+func (b *Backend) ListRoles(ctx context.Context) ([]string, error) {
+	return b.storage.List(ctx, filepath.Join(b.path, "role"))
+}
+
+func (b *Backend) GetRole(ctx context.Context, name string) (Role, error) {
+	if !validRoleName.MatchString(name) {
+		return Role{}, auth.ErrRoleNotFound
+	}
+
+	var role Role
+	err := b.read(ctx, b.rolePath(name), &role)
+	if errors.Is(err, storage.ErrPathNotFound) {
+		return Role{}, auth.ErrRoleNotFound
+	}
+	if err != nil {
+		return Role{}, err
+	}
+
+	return role, nil
+}
+
+func (b *Backend) PutRole(ctx context.Context, role Role) error {
+	if !validRoleName.MatchString(role.Name) {
+		return fmt.Errorf("%w: invalid role name %q", auth.ErrInvalidConfig, role.Name)
+	}
+
+	if role.RoleType == "" {
+		role.RoleType = RoleTypeJWT
+	}
+	if role.RoleType != RoleTypeJWT {
+		return fmt.Errorf("%w: unsupported role_type %q", auth.ErrInvalidConfig, role.RoleType)
+	}
+
+	if role.UserClaim == "" {
+		return fmt.Errorf("%w: missing user_claim", auth.ErrInvalidConfig)
+	}
+
+	if len(role.BoundAudiences) == 0 && role.BoundSubject == "" && len(role.BoundClaims) == 0 && len(role.TokenBoundCIDRs) == 0 {
+		return fmt.Errorf("%w: must have at least one bound constraint when creating a jwt role", auth.ErrInvalidConfig)
+	}
+
+	for name, value := range role.BoundClaims {
+		if !validBoundClaim(value) {
+			return fmt.Errorf("%w: bound claim %q must be a string or a list of strings", auth.ErrInvalidConfig, name)
+		}
+	}
+
+	if err := role.TokenParams.Validate(); err != nil {
+		return err
+	}
+
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	return b.write(ctx, b.rolePath(role.Name), role)
+}
+
+func (b *Backend) DeleteRole(ctx context.Context, name string) error {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	if _, err := b.GetRole(ctx, name); err != nil {
+		return err
+	}
+
+	return b.storage.Delete(ctx, b.rolePath(name))
+}
+
+// Login verifies the signature of rawJWT and checks its claims against the
+// role. The default role of the configuration is used when name is empty.
+func (b *Backend) Login(ctx context.Context, name string, rawJWT string) (Result, error) {
+	config, err := b.GetConfig(ctx)
+	if err != nil {
+		return Result{}, err
+	}
+
+	if name == "" {
+		name = config.DefaultRole
+	}
+	if name == "" {
+		return Result{}, fmt.Errorf("%w: missing role", auth.ErrInvalidCredentials)
+	}
+
+	role, err := b.GetRole(ctx, name)
+	if err != nil {
+		return Result{}, err
+	}
+
+	algs, err := signatureAlgs(config.JWTSupportedAlgs)
+	if err != nil {
+		return Result{}, err
+	}
+
+	signed, err := jose.ParseSignedCompact(rawJWT, algs)
+	if err != nil {
+		return Result{}, invalidToken(err.Error())
+	}
+
+	payload, err := b.verify(ctx, config, signed)
+	if err != nil {
+		return Result{}, err
+	}
+
+	var claims map[string]any
+	if err = json.Unmarshal(payload, &claims); err != nil {
+		return Result{}, invalidToken("claims are not a JSON object")
+	}
+
+	if err = validateClaims(claims, config, role, time.Now()); err != nil {
+		return Result{}, err
+	}
+
+	user, ok := stringClaim(lookupClaim(claims, role.UserClaim))
+	if !ok || user == "" {
+		return Result{}, invalidToken(fmt.Sprintf("claim %q not found in token", role.UserClaim))
+	}
+
+	var groups []string
+	if role.GroupsClaim != "" {
+		value := lookupClaim(claims, role.GroupsClaim)
+		if value == nil {
+			return Result{}, invalidToken(fmt.Sprintf("claim %q not found in token", role.GroupsClaim))
+		}
+		groups = stringsClaim(value)
+	}
+
+	metadata := make(map[string]string, len(role.ClaimMappings))
+	for claim, key := range role.ClaimMappings {
+		if value, ok := stringClaim(lookupClaim(claims, claim)); ok {
+			metadata[key] = value
+		}
+	}
+
+	return Result{
+		Role:     role,
+		User:     user,
+		Groups:   groups,
+		Metadata: metadata,
+	}, nil
+}
+
+// verify checks the signature against the configured keys and returns the
+// payload. With a JWKS the key ID of the token selects the keys to try.
+func (b *Backend) verify(ctx context.Context, config Config, signed *jose.JSONWebSignature) ([]byte, error) {
+	var keys []any
+	if config.JWKSURL != "" {
+		var kid string
+		if len(signed.Signatures) > 0 {
+			kid = signed.Signatures[0].Header.KeyID
+		}
+
+		webKeys, err := b.keySet(config).Keys(ctx, kid)
+		if err != nil {
+			return nil, err
+		}
+
+		for _, key := range webKeys {
+			keys = append(keys, key)
+		}
+	} else {
+		var err error
+		keys, err = parsePublicKeys(config.JWTValidationPubKeys)
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	for _, key := range keys {
+		if payload, err := signed.Verify(key); err == nil {
+			return payload, nil
+		}
+	}
+
+	return nil, invalidToken("failed to verify token signature")
+}
+
+// keySet returns the cached key set for the JWKS URL of config. The cache is
+// rebuilt when the configuration has changed, e.g. on another node.
+func (b *Backend) keySet(config Config) *keySet {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	if b.keys == nil || b.keys.url != config.JWKSURL || b.keys.caPEM != config.JWKSCAPEM {
+		b.keys = newKeySet(config.JWKSURL, config.JWKSCAPEM)
+	}
+
+	return b.keys
+}
+
+func signatureAlgs(names []string) ([]jose.SignatureAlgorithm, error) {
+	if len(names) == 0 {
+		return []jose.SignatureAlgorithm{jose.RS256}, nil
+	}
+
+	algs := make([]jose.SignatureAlgorithm, 0, len(names))
+	for _, name := range names {
+		alg := jose.SignatureAlgorithm(name)
+		if !slices.Contains(supportedAlgs, alg) {
+			return nil, fmt.Errorf("%w: unsupported signing algorithm %q", auth.ErrInvalidConfig, name)
+		}
+		algs = append(algs, alg)
+	}
+
+	return algs, nil
+}
+
+func validBoundClaim(value any) bool {
+	switch v := value.(type) {
+	case string:
+		return true
+	case []any:
+		for _, item := range v {
+			if _, ok := item.(string); !ok {
+				return false
+			}
+		}
+		return true
+	default:
+		return false
+	}
+}
+
+func (b *Backend) read(ctx context.Context, path string, v any) error {
+	data, err := b.storage.Get(ctx, path)
+	if err != nil {
+		return err
+	}
+
+	decryptedData, err := b.encryptor.Decrypt(data)
+	if err != nil {
+		return err
+	}
+
+	return json.Unmarshal(decryptedData, v)
+}
+
+func (b *Backend) write(ctx context.Context, path string, v any) error {
+	d, err := json.Marshal(v)
+	if err != nil {
+		return err
+	}
+
+	encryptedData, err := b.encryptor.Encrypt(d)
+	if err != nil {
+		return err
+	}
+
+	return b.storage.Put(ctx, path, encryptedData)
+}
+
+func (b *Backend) configPath() string {
+	return filepath.Join(b.path, "config")
+}
+
+func (b *Backend) rolePath(name string) string {
+	return filepath.Join(b.path, "role", name)
+}
@@ -0,0 +1,231 @@
+package jwt
+
+import (
+	"context"
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/rsa"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/Burzich/dvault/internal/dvault/auth"
+	fs "github.com/Burzich/dvault/internal/dvault/storage/disc"
+	"github.com/Burzich/dvault/internal/tools"
+	"github.com/go-jose/go-jose/v4"
+)
+
+// testIssuer serves a JWKS with an RSA and an EC key and signs tokens with
+// them.
+type testIssuer struct {
+	rsaKey *rsa.PrivateKey
+	ecKey  *ecdsa.PrivateKey
+	server *httptest.Server
+}
+
+func newTestIssuer(t *testing.T) *testIssuer {
+	t.Helper()
+
+	rsaKey, err := rsa.GenerateKey(rand.Reader, 2048)
+	if err != nil {
+		t.Fatal(err)
+	}
+	ecKey, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	jwks, err := json.Marshal(jose.JSONWebKeySet{Keys: []jose.JSONWebKey{
+		{Key: rsaKey.Public(), KeyID: "rsa", Algorithm: string(jose.RS256), Use: "sig"},
+		{Key: ecKey.Public(), KeyID: "ec", Algorithm: string(jose.ES256), Use: "sig"},
+	}})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "application/json")
+		w.Write(jwks)
+	}))
+	t.Cleanup(server.Close)
+
+	return &testIssuer{rsaKey: rsaKey, ecKey: ecKey, server: server}
+}
+
+func (i *testIssuer) sign(t *testing.T, alg jose.SignatureAlgorithm, claims map[string]any) string {
+	t.Helper()
+
+	key, kid := any(i.rsaKey), "rsa"
+	if alg == jose.ES256 {
+		key, kid = i.ecKey, "ec"
+	}
+
+	signer, err := jose.NewSigner(jose.SigningKey{Algorithm: alg, Key: key},
+		(&jose.SignerOptions{}).WithType("JWT").WithHeader("kid", kid))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	payload, err := json.Marshal(claims)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	signed, err := signer.Sign(payload)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	raw, err := signed.CompactSerialize()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	return raw
+}
+
+func newTestBackend(t *testing.T, issuer *testIssuer) *Backend {
+	t.Helper()
+	ctx := context.Background()
+
+	encryptor, err := tools.NewEncryptor("aes", make([]byte, 32))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	b := New("auth/jwt", fs.NewFSStorage(t.TempDir()), encryptor)
+
+	err = b.PutConfig(ctx, Config{
+		JWKSURL:          issuer.server.URL,
+		BoundIssuer:      "https://issuer.example",
+		JWTSupportedAlgs: []string{string(jose.RS256)},
+	})
+	if err != nil {
+		t.Fatalf("PutConfig: %v", err)
+	}
+
+	roles := []Role{
+		{Name: "audience", BoundAudiences: []string{"dvault"}, UserClaim: "sub"},
+		{Name: "subject", BoundSubject: "alice", UserClaim: "sub"},
+	}
+	for _, role := range roles {
+		if err = b.PutRole(ctx, role); err != nil {
+			t.Fatalf("PutRole %s: %v", role.Name, err)
+		}
+	}
+
+	return b
+}
+
+func TestLogin(t *testing.T) {
+	issuer := newTestIssuer(t)
+	b := newTestBackend(t, issuer)
+	now := time.Now()
+
+	claims := func(overrides map[string]any) map[string]any {
+		c := map[string]any{
+			"iss": "https://issuer.example",
+			"sub": "alice",
+			"aud": "dvault",
+			"iat": now.Unix(),
+			"exp": now.Add(time.Hour).Unix(),
+		}
+		for name, value := range overrides {
+			if value == nil {
+				delete(c, name)
+				continue
+			}
+			c[name] = value
+		}
+		return c
+	}
+
+	tests := []struct {
+		name   string
+		role   string
+		alg    jose.SignatureAlgorithm
+		claims map[string]any
+		reason string
+	}{
+		{
+			name:   "valid RS256",
+			role:   "audience",
+			alg:    jose.RS256,
+			claims: claims(nil),
+		},
+		{
+			name:   "audience in list",
+			role:   "audience",
+			alg:    jose.RS256,
+			claims: claims(map[string]any{"aud": []string{"other", "dvault"}}),
+		},
+		{
+			name:   "algorithm not supported",
+			role:   "audience",
+			alg:    jose.ES256,
+			claims: claims(nil),
+			reason: "unexpected signature algorithm",
+		},
+		{
+			name:   "audience mismatch",
+			role:   "audience",
+			alg:    jose.RS256,
+			claims: claims(map[string]any{"aud": "other"}),
+			reason: "invalid audience claim",
+		},
+		{
+			name:   "audience present but none bound",
+			role:   "subject",
+			alg:    jose.RS256,
+			claims: claims(nil),
+			reason: "no audiences bound to the role",
+		},
+		{
+			name:   "no audience and none bound",
+			role:   "subject",
+			alg:    jose.RS256,
+			claims: claims(map[string]any{"aud": nil}),
+		},
+		{
+			name:   "expired",
+			role:   "audience",
+			alg:    jose.RS256,
+			claims: claims(map[string]any{"iat": now.Add(-2 * time.Hour).Unix(), "exp": now.Add(-time.Hour).Unix()}),
+			reason: "token is expired",
+		},
+		{
+			name:   "expired within leeway",
+			role:   "audience",
+			alg:    jose.RS256,
+			claims: claims(map[string]any{"exp": now.Add(-time.Minute).Unix()}),
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			result, err := b.Login(context.Background(), tt.role, issuer.sign(t, tt.alg, tt.claims))
+
+			if tt.reason == "" {
+				if err != nil {
+					t.Fatalf("Login: %v", err)
+				}
+				if result.User != "alice" {
+					t.Fatalf("User = %q, want %q", result.User, "alice")
+				}
+				return
+			}
+
+			if !errors.Is(err, auth.ErrInvalidCredentials) {
+				t.Fatalf("Login error = %v, want %v", err, auth.ErrInvalidCredentials)
+			}
+			if !strings.Contains(err.Error(), tt.reason) {
+				t.Fatalf("Login error = %q, want it to mention %q", err, tt.reason)
+			}
+		})
+	}
+}
@@ -0,0 +1,171 @@
+package jwt
+
+import (
+	"context"
+	"crypto/tls"
+	"crypto/x509"
+	"encoding/json"
+	"encoding/pem"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"os"
+	"sync"
+	"time"
+
+	"github.com/Burzich/dvault/internal/dvault/auth"
+	"github.com/go-jose/go-jose/v4"
+)
+
+const (
+	jwksCacheTTL     = 5 * time.Minute
+	jwksMinRefresh   = 10 * time.Second
+	jwksFetchTimeout = 10 * time.Second
+	jwksMaxSize      = 1 << 20
+)
+
+// keySet caches the keys of a JWKS endpoint. It is refreshed when the cache
+// expires or a token names a key ID that is not known yet.
+type keySet struct {
+	url   string
+	caPEM string
+
+	mu        sync.Mutex
+	keys      jose.JSONWebKeySet
+	fetchedAt time.Time
+}
+
+func newKeySet(url string, caPEM string) *keySet {
+	return &keySet{url: url, caPEM: caPEM}
+}
+
+// Keys returns the keys matching kid, or all keys when kid is empty.
+func (k *keySet) Keys(ctx context.Context, kid string) ([]jose.JSONWebKey, error) {
+	k.mu.Lock()
+	defer k.mu.Unlock()
+
+	now := time.Now()
+	if now.Sub(k.fetchedAt) > jwksCacheTTL {
+		if err := k.fetch(ctx); err != nil {
+			return nil, err
+		}
+	}
+
+	keys := k.match(kid)
+	if len(keys) == 0 && now.Sub(k.fetchedAt) > jwksMinRefresh {
+		if err := k.fetch(ctx); err != nil {
+			return nil, err
+		}
+		keys = k.match(kid)
+	}
+
+	return keys, nil
+}
+
+func (k *keySet) match(kid string) []jose.JSONWebKey {
+	if kid == "" {
+		return k.keys.Keys
+	}
+
+	return k.keys.Key(kid)
+}
+
+func (k *keySet) fetch(ctx context.Context) error {
+	body, err := k.read(ctx)
+	if err != nil {
+		return fmt.Errorf("fetch JWKS: %w", err)
+	}
+
+	var keys jose.JSONWebKeySet
+	if err = json.Unmarshal(body, &keys); err != nil {
+		return fmt.Errorf("parse JWKS: %w", err)
+	}
+
+	k.keys = keys
+	k.fetchedAt = time.Now()
+
+	return nil
+}
+
+// read loads the JWKS document. Besides http and https URLs a file URL is
+// accepted, which is handy for keys distributed next to the server.
+func (k *keySet) read(ctx context.Context) ([]byte, error) {
+	u, err := url.Parse(k.url)
+	if err != nil {
+		return nil, err
+	}
+
+	if u.Scheme == "file" {
+		return os.ReadFile(u.Path)
+	}
+
+	client, err := httpClient(k.caPEM)
+	if err != nil {
+		return nil, err
+	}
+
+	ctx, cancel := context.WithTimeout(ctx, jwksFetchTimeout)
+	defer cancel()
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, k.url, nil)
+	if err != nil {
+		return nil, err
+	}
+
+	resp, err := client.Do(req)
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		return nil, fmt.Errorf("unexpected status %s", resp.Status)
+	}
+
+	return io.ReadAll(io.LimitReader(resp.Body, jwksMaxSize))
+}
+
+func httpClient(caPEM string) (*http.Client, error) {
+	if caPEM == "" {
+		return http.DefaultClient, nil
+	}
+
+	pool := x509.NewCertPool()
+	if !pool.AppendCertsFromPEM([]byte(caPEM)) {
+		return nil, fmt.Errorf("%w: could not parse jwks_ca_pem", auth.ErrInvalidConfig)
+	}
+
+	transport := http.DefaultTransport.(*http.Transport).Clone()
+	transport.TLSClientConfig = &tls.Config{RootCAs: pool, MinVersion: tls.VersionTLS12}
+
+	return &http.Client{Transport: transport}, nil
+}
+
+// parsePublicKeys parses PEM encoded public keys or certificates.
+func parsePublicKeys(pems []string) ([]any, error) {
+	keys := make([]any, 0, len(pems))
+	for _, p := range pems {
+		block, _ := pem.Decode([]byte(p))
+		if block == nil {
+			return nil, fmt.Errorf("%w: could not decode PEM public key", auth.ErrInvalidConfig)
+		}
+
+		if block.Type == "CERTIFICATE" {
+			cert, err := x509.ParseCertificate(block.Bytes)
+			if err != nil {
+				return nil, fmt.Errorf("%w: %s", auth.ErrInvalidConfig, err)
+			}
+			keys = append(keys, cert.PublicKey)
+			continue
+		}
+
+		key, err := x509.ParsePKIXPublicKey(block.Bytes)
+		if err != nil {
+			return nil, fmt.Errorf("%w: %s", auth.ErrInvalidConfig, err)
+		}
+		keys = append(keys, key)
+	}
+
+	return keys, nil
+}
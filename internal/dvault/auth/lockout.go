@@ -0,0 +1,61 @@
+package auth
+
+import "time"
+
+const (
+	DefaultLockoutThreshold    = 5
+	DefaultLockoutDuration     = 15 * time.Minute
+	DefaultLockoutCounterReset = 15 * time.Minute
+)
+
+// LockoutConfig mirrors Vault's user_lockout settings.
+type LockoutConfig struct {
+	Threshold    int
+	Duration     time.Duration
+	CounterReset time.Duration
+	Disable      bool
+}
+
+func (c LockoutConfig) WithDefaults() LockoutConfig {
+	if c.Threshold <= 0 {
+		c.Threshold = DefaultLockoutThreshold
+	}
+	if c.Duration <= 0 {
+		c.Duration = DefaultLockoutDuration
+	}
+	if c.CounterReset <= 0 {
+		c.CounterReset = DefaultLockoutCounterReset
+	}
+
+	return c
+}
+
+// Lockout tracks the failed logins of a single user.
+type Lockout struct {
+	FailedAttempts int       `json:"failed_attempts"`
+	LastFailure    time.Time `json:"last_failure"`
+	LockedUntil    time.Time `json:"locked_until"`
+}
+
+func (l Lockout) Locked(now time.Time) bool {
+	return now.Before(l.LockedUntil)
+}
+
+// Fail records a failed login and locks the user once the threshold is
+// reached. The counter starts over when the last failure is older than the
+// counter reset period.
+func (l Lockout) Fail(c LockoutConfig, now time.Time) Lockout {
+	if now.Sub(l.LastFailure) > c.CounterReset {
+		l.FailedAttempts = 0
+	}
+
+	l.FailedAttempts++
+	l.LastFailure = now
+
+	if l.FailedAttempts >= c.Threshold {
+		l.FailedAttempts = 0
+		l.LockedUntil = now.Add(c.Duration)
+	}
+
+	return l
+}
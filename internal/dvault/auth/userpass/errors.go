@@ -0,0 +1,5 @@
+package userpass
+
+import "errors"
+
+var ErrUserNotFound = errors.New("user not found")
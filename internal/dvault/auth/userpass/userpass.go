@@ -0,0 +1,257 @@
+package userpass
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"path/filepath"
+	"regexp"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/Burzich/dvault/internal/dvault/auth"
+	"github.com/Burzich/dvault/internal/dvault/storage"
+	"github.com/Burzich/dvault/internal/tools"
+)
+
+var validUsername = regexp.MustCompile(`^[a-z0-9_-][a-z0-9_.@-]*$`)
+
+type User struct {
+	Name         string `json:"name"`
+	PasswordHash string `json:"password_hash"`
+	auth.TokenParams
+}
+
+// Backend stores userpass users below path. Passwords are only stored as
+// argon2id hashes, and failed logins count towards the user lockout.
+type Backend struct {
+	path      string
+	storage   storage.Storage
+	encryptor tools.Encryptor
+	lockout   auth.LockoutConfig
+
+	mu sync.Mutex
+}
+
+func New(path string, s storage.Storage, encryptor tools.Encryptor, lockout auth.LockoutConfig) *Backend {
+	return &Backend{
+		path:      path,
+		storage:   s,
+		encryptor: encryptor,
+		lockout:   lockout.WithDefaults(),
+	}
+}
+
+func (b *Backend) ListUsers(ctx context.Context) ([]string, error) {
+	return b.storage.List(ctx, filepath.Join(b.path, "user"))
+}
+
+func (b *Backend) GetUser(ctx context.Context, name string) (User, error) {
+	name = strings.ToLower(name)
+	if !validUsername.MatchString(name) {
+		return User{}, ErrUserNotFound
+	}
+
+	var user User
+	err := b.read(ctx, b.userPath(name), &user)
+	if errors.Is(err, storage.ErrPathNotFound) {
+		return User{}, ErrUserNotFound
+	}
+	if err != nil {
+		return User{}, err
+	}
+
+	return user, nil
+}
+
+// PutUser creates or updates a user. The password is required for new users
+// and keeps its current value on update when empty.
+func (b *Backend) PutUser(ctx context.Context, user User, password string) error {
+	user.Name = strings.ToLower(user.Name)
+	if !validUsername.MatchString(user.Name) {
+		return fmt.Errorf("%w: invalid username %q", auth.ErrInvalidConfig, user.Name)
+	}
+
+	if err := user.TokenParams.Validate(); err != nil {
+		return err
+	}
+
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	old, err := b.GetUser(ctx, user.Name)
+	if err != nil && !errors.Is(err, ErrUserNotFound) {
+		return err
+	}
+
+	user.PasswordHash = old.PasswordHash
+	if password != "" {
+		user.PasswordHash, err = hashPassword(password)
+		if err != nil {
+			return err
+		}
+	}
+
+	if user.PasswordHash == "" {
+		return fmt.Errorf("%w: missing password", auth.ErrInvalidConfig)
+	}
+
+	return b.write(ctx, b.userPath(user.Name), user)
+}
+
+func (b *Backend) SetPassword(ctx context.Context, name string, password string) error {
+	if password == "" {
+		return fmt.Errorf("%w: missing password", auth.ErrInvalidConfig)
+	}
+
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	user, err := b.GetUser(ctx, name)
+	if err != nil {
+		return err
+	}
+
+	user.PasswordHash, err = hashPassword(password)
+	if err != nil {
+		return err
+	}
+
+	return b.write(ctx, b.userPath(user.Name), user)
+}
+
+func (b *Backend) SetPolicies(ctx context.Context, name string, policies []string) error {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	user, err := b.GetUser(ctx, name)
+	if err != nil {
+		return err
+	}
+
+	user.TokenPolicies = policies
+
+	return b.write(ctx, b.userPath(user.Name), user)
+}
+
+func (b *Backend) DeleteUser(ctx context.Context, name string) error {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	user, err := b.GetUser(ctx, name)
+	if err != nil {
+		return err
+	}
+
+	if err = b.delete(ctx, b.lockoutPath(user.Name)); err != nil {
+		return err
+	}
+
+	return b.delete(ctx, b.userPath(user.Name))
+}
+
+// Login verifies the password of a user. Too many failed attempts lock the
+// user out for the configured lockout duration.
+func (b *Backend) Login(ctx context.Context, name string, password string) (User, error) {
+	invalid := fmt.Errorf("%w: invalid username or password", auth.ErrInvalidCredentials)
+
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	user, err := b.GetUser(ctx, name)
+	if errors.Is(err, ErrUserNotFound) {
+		// Spend the time of a hash anyway, so response times do not tell
+		// which users exist.
+		_, _ = hashPassword(password)
+		return User{}, invalid
+	}
+	if err != nil {
+		return User{}, err
+	}
+
+	now := time.Now()
+
+	var lockout auth.Lockout
+	if !b.lockout.Disable {
+		err = b.read(ctx, b.lockoutPath(user.Name), &lockout)
+		if err != nil && !errors.Is(err, storage.ErrPathNotFound) {
+			return User{}, err
+		}
+
+		if lockout.Locked(now) {
+			return User{}, auth.ErrUserLocked
+		}
+	}
+
+	ok, err := verifyPassword(user.PasswordHash, password)
+	if err != nil {
+		return User{}, err
+	}
+
+	if b.lockout.Disable {
+		if !ok {
+			return User{}, invalid
+		}
+		return user, nil
+	}
+
+	if !ok {
+		if err = b.write(ctx, b.lockoutPath(user.Name), lockout.Fail(b.lockout, now)); err != nil {
+			return User{}, err
+		}
+		return User{}, invalid
+	}
+
+	if err = b.delete(ctx, b.lockoutPath(user.Name)); err != nil {
+		return User{}, err
+	}
+
+	return user, nil
+}
+
+func (b *Backend) read(ctx context.Context, path string, v any) error {
+	data, err := b.storage.Get(ctx, path)
+	if err != nil {
+		return err
+	}
+
+	decryptedData, err := b.encryptor.Decrypt(data)
+	if err != nil {
+		return err
+	}
+
+	return json.Unmarshal(decryptedData, v)
+}
+
+func (b *Backend) write(ctx context.Context, path string, v any) error {
+	d, err := json.Marshal(v)
+	if err != nil {
+		return err
+	}
+
+	encryptedData, err := b.encryptor.Encrypt(d)
+	if err != nil {
+		return err
+	}
+
+	return b.storage.Put(ctx, path, encryptedData)
+}
+
+func (b *Backend) delete(ctx context.Context, path string) error {
+	err := b.storage.Delete(ctx, path)
+	if errors.Is(err, storage.ErrPathNotFound) {
+		return nil
+	}
+
+	return err
+}
+
+func (b *Backend) userPath(name string) string {
+	return filepath.Join(b.path, "user", name)
+}
+
+func (b *Backend) lockoutPath(name string) string {
+	return filepath.Join(b.path, "lockout", name)
+}
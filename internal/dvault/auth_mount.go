@@ -0,0 +1,472 @@
+package dvault
+
+import (
+	"context"
+	"crypto/rand"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"maps"
+	"path/filepath"
+	"regexp"
+	"strings"
+	"time"
+
+	"github.com/Burzich/dvault/internal/dvault/auth/approle"
+	"github.com/Burzich/dvault/internal/dvault/auth/cert"
+	"github.com/Burzich/dvault/internal/dvault/auth/jwt"
+	"github.com/Burzich/dvault/internal/dvault/auth/userpass"
+	"github.com/Burzich/dvault/internal/dvault/storage"
+	"github.com/Burzich/dvault/internal/dvault/token"
+	"github.com/Burzich/dvault/internal/tools"
+	"github.com/google/uuid"
+)
+
+const (
+	authPath      = "auth"
+	authTablePath = "sys/auth"
+
+	tokenAuthType = "token"
+)
+
+var validAuthPath = regexp.MustCompile(`^[a-zA-Z0-9][a-zA-Z0-9_-]*$`)
+
+// authMount is an entry of the auth mount table. The table is stored
+// encrypted at authTablePath, the backends are created from it on unseal.
+// Each backend keeps its data below auth/<path>.
+type authMount struct {
+	Type        string            `json:"type"`
+	Path        string            `json:"path"`
+	Accessor    string            `json:"accessor"`
+	UUID        string            `json:"uuid"`
+	Description string            `json:"description"`
+	Config      authMountConfig   `json:"config"`
+	Options     map[string]string `json:"options"`
+	Local       bool              `json:"local"`
+	SealWrap    bool              `json:"seal_wrap"`
+
+	backend any
+}
+
+// authMountConfig holds the tunable settings of an auth mount. Zero values
+// fall back to the system defaults.
+type authMountConfig struct {
+	DefaultLeaseTTL time.Duration `json:"default_lease_ttl"`
+	MaxLeaseTTL     time.Duration `json:"max_lease_ttl"`
+}
+
+// AuthMethodType returns the type of the auth method mounted at path, e.g.
+// "approle" for auth/approle.
+func (d *DVault) AuthMethodType(_ context.Context, path string) (string, error) {
+	d.mu.RLock()
+	defer d.mu.RUnlock()
+
+	if d.isSealed {
+		return "", ErrSealed
+	}
+
+	mount, ok := d.auth[path]
+	if !ok {
+		return "", ErrAuthMethodNotFound
+	}
+
+	return mount.Type, nil
+}
+
+func (d *DVault) ListAuthMethods(_ context.Context) (AuthMounts, error) {
+	d.mu.RLock()
+	defer d.mu.RUnlock()
+
+	if d.isSealed {
+		return AuthMounts{}, ErrSealed
+	}
+
+	m := AuthMounts{}
+	m.Data = make(map[string]AuthMountData, len(d.auth))
+	m.RequestId = tools.GenerateXRequestID()
+
+	for path, mount := range d.auth {
+		m.Data[path] = authMountData(mount)
+	}
+
+	return m, nil
+}
+
+func (d *DVault) GetAuthMethod(_ context.Context, path string) (Response, error) {
+	d.mu.RLock()
+	defer d.mu.RUnlock()
+
+	if d.isSealed {
+		return Response{}, ErrSealed
+	}
+
+	mount, ok := d.auth[cleanAuthPath(path)]
+	if !ok {
+		return Response{}, ErrAuthMethodNotFound
+	}
+
+	var response Response
+	response.Data = authMountData(mount)
+	response.RequestId = tools.GenerateXRequestID()
+
+	return response, nil
+}
+
+// EnableAuthMethod mounts a new auth method of the given type at path, e.g.
+// a second jwt method at auth/ci-jwt.
+func (d *DVault) EnableAuthMethod(ctx context.Context, path string, enable EnableAuth) (Response, error) {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+
+	if d.isSealed {
+		return Response{}, ErrSealed
+	}
+
+	path = cleanAuthPath(path)
+	if !validAuthPath.MatchString(path) {
+		return Response{}, fmt.Errorf("%w: %q", ErrInvalidAuthPath, path)
+	}
+
+	if _, ok := d.auth[path]; ok {
+		return Response{}, fmt.Errorf("%w at %s", ErrAuthPathInUse, path)
+	}
+
+	if enable.Type == tokenAuthType {
+		return Response{}, fmt.Errorf("%w: token auth method can not be enabled twice", ErrUnknownAuthType)
+	}
+
+	if enable.DefaultLeaseTTL < 0 || enable.MaxLeaseTTL < 0 {
+		return Response{}, fmt.Errorf("%w: lease TTLs can not be negative", ErrInvalidAuthConfig)
+	}
+
+	if enable.MaxLeaseTTL > 0 && enable.DefaultLeaseTTL > enable.MaxLeaseTTL {
+		return Response{}, fmt.Errorf("%w: default_lease_ttl can not be greater than max_lease_ttl", ErrInvalidAuthConfig)
+	}
+
+	mount := authMount{
+		Type:        enable.Type,
+		Path:        path,
+		UUID:        uuid.NewString(),
+		Description: enable.Description,
+		Config: authMountConfig{
+			DefaultLeaseTTL: enable.DefaultLeaseTTL,
+			MaxLeaseTTL:     enable.MaxLeaseTTL,
+		},
+		Options:  enable.Options,
+		Local:    enable.Local,
+		SealWrap: enable.SealWrap,
+	}
+
+	var err error
+	mount.Accessor, err = authAccessor(mount.Type)
+	if err != nil {
+		return Response{}, err
+	}
+
+	mount.backend, err = d.newAuthBackend(mount, d.encryptor)
+	if err != nil {
+		return Response{}, err
+	}
+
+	table := maps.Clone(d.auth)
+	table[path] = mount
+
+	if err = d.saveAuthTable(ctx, table, d.encryptor); err != nil {
+		return Response{}, err
+	}
+	d.auth = table
+
+	var response Response
+	response.RequestId = tools.GenerateXRequestID()
+
+	return response, nil
+}
+
+// DisableAuthMethod unmounts the auth method at path. Tokens issued by it are
+// revoked and its data is deleted.
+func (d *DVault) DisableAuthMethod(ctx context.Context, path string) (Response, error) {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+
+	if d.isSealed {
+		return Response{}, ErrSealed
+	}
+
+	path = cleanAuthPath(path)
+
+	mount, ok := d.auth[path]
+	if !ok {
+		return Response{}, ErrAuthMethodNotFound
+	}
+
+	if mount.Type == tokenAuthType {
+		return Response{}, fmt.Errorf("%w: token auth method can not be disabled", ErrInvalidAuthPath)
+	}
+
+	if _, err := d.tokens.RevokePrefix(ctx, filepath.Join(authPath, path)); err != nil {
+		return Response{}, err
+	}
+
+	table := maps.Clone(d.auth)
+	delete(table, path)
+
+	if err := d.saveAuthTable(ctx, table, d.encryptor); err != nil {
+		return Response{}, err
+	}
+	d.auth = table
+
+	if err := storage.DeleteTree(ctx, d.Storage, filepath.Join(authPath, path)); err != nil {
+		return Response{}, err
+	}
+
+	if err := d.identity.DeleteMountAliases(ctx, mount.Accessor); err != nil {
+		return Response{}, err
+	}
+
+	var response Response
+	response.RequestId = tools.GenerateXRequestID()
+
+	return response, nil
+}
+
+func (d *DVault) GetAuthMethodTune(_ context.Context, path string) (Response, error) {
+	d.mu.RLock()
+	defer d.mu.RUnlock()
+
+	if d.isSealed {
+		return Response{}, ErrSealed
+	}
+
+	mount, ok := d.auth[cleanAuthPath(path)]
+	if !ok {
+		return Response{}, ErrAuthMethodNotFound
+	}
+
+	var response Response
+	response.Data = authTuneData(mount)
+	response.RequestId = tools.GenerateXRequestID()
+
+	return response, nil
+}
+
+// TuneAuthMethod changes the settings of a mounted auth method. Only the
+// settings that are given are changed.
+func (d *DVault) TuneAuthMethod(ctx context.Context, path string, tune TuneAuth) (Response, error) {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+
+	if d.isSealed {
+		return Response{}, ErrSealed
+	}
+
+	path = cleanAuthPath(path)
+
+	mount, ok := d.auth[path]
+	if !ok {
+		return Response{}, ErrAuthMethodNotFound
+	}
+
+	if tune.DefaultLeaseTTL != nil {
+		mount.Config.DefaultLeaseTTL = *tune.DefaultLeaseTTL
+	}
+	if tune.MaxLeaseTTL != nil {
+		mount.Config.MaxLeaseTTL = *tune.MaxLeaseTTL
+	}
+	if tune.Description != nil {
+		mount.Description = *tune.Description
+	}
+
+	if mount.Config.DefaultLeaseTTL < 0 || mount.Config.MaxLeaseTTL < 0 {
+		return Response{}, fmt.Errorf("%w: lease TTLs can not be negative", ErrInvalidAuthConfig)
+	}
+
+	if mount.Config.MaxLeaseTTL > 0 && mount.Config.DefaultLeaseTTL > mount.Config.MaxLeaseTTL {
+		return Response{}, fmt.Errorf("%w: default_lease_ttl can not be greater than max_lease_ttl", ErrInvalidAuthConfig)
+	}
+
+	table := maps.Clone(d.auth)
+	table[path] = mount
+
+	if err := d.saveAuthTable(ctx, table, d.encryptor); err != nil {
+		return Response{}, err
+	}
+	d.auth = table
+
+	var response Response
+	response.RequestId = tools.GenerateXRequestID()
+
+	return response, nil
+}
+
+// restoreAuth loads the auth mount table and creates the backends of all
+// mounts. A missing table is created holding only the token auth method.
+func (d *DVault) restoreAuth(ctx context.Context, encryptor tools.Encryptor) error {
+	table := make(map[string]authMount)
+
+	data, err := d.Storage.Get(ctx, authTablePath)
+	switch {
+	case errors.Is(err, storage.ErrPathNotFound):
+		accessor, err := authAccessor(tokenAuthType)
+		if err != nil {
+			return err
+		}
+
+		table[tokenAuthType] = authMount{
+			Type:        tokenAuthType,
+			Path:        tokenAuthType,
+			Accessor:    accessor,
+			UUID:        uuid.NewString(),
+			Description: "token based credentials",
+		}
+
+		if err = d.saveAuthTable(ctx, table, encryptor); err != nil {
+			return err
+		}
+	case err != nil:
+		return err
+	default:
+		decryptedData, err := encryptor.Decrypt(data)
+		if err != nil {
+			return err
+		}
+
+		if err = json.Unmarshal(decryptedData, &table); err != nil {
+			return err
+		}
+	}
+
+	for path, mount := range table {
+		mount.backend, err = d.newAuthBackend(mount, encryptor)
+		if err != nil {
+			return fmt.Errorf("restore auth method %s: %w", path, err)
+		}
+		table[path] = mount
+	}
+
+	d.auth = table
+
+	return nil
+}
+
+func (d *DVault) saveAuthTable(ctx context.Context, table map[string]authMount, encryptor tools.Encryptor) error {
+	data, err := json.Marshal(table)
+	if err != nil {
+		return err
+	}
+
+	encryptedData, err := encryptor.Encrypt(data)
+	if err != nil {
+		return err
+	}
+
+	return d.Storage.Put(ctx, authTablePath, encryptedData)
+}
+
+// newAuthBackend creates the backend of an auth mount. The token auth method
+// is served by the token store and has no backend of its own.
+func (d *DVault) newAuthBackend(mount authMount, encryptor tools.Encryptor) (any, error) {
+	path := filepath.Join(authPath, mount.Path)
+
+	switch mount.Type {
+	case tokenAuthType:
+		return nil, nil
+	case "approle":
+		return approle.New(path, d.Storage, encryptor), nil
+	case "userpass":
+		return userpass.New(path, d.Storage, encryptor, d.userLockout), nil
+	case "jwt":
+		return jwt.New(path, d.Storage, encryptor), nil
+	case "cert":
+		return cert.New(path, d.Storage, encryptor), nil
+	default:
+		return nil, fmt.Errorf("%w %q", ErrUnknownAuthType, mount.Type)
+	}
+}
+
+func (d *DVault) appRole(mount string) (*approle.Backend, error) {
+	backend, ok := d.auth[mount].backend.(*approle.Backend)
+	if !ok {
+		return nil, ErrAuthMethodNotFound
+	}
+
+	return backend, nil
+}
+
+func (d *DVault) userpass(mount string) (*userpass.Backend, error) {
+	backend, ok := d.auth[mount].backend.(*userpass.Backend)
+	if !ok {
+		return nil, ErrAuthMethodNotFound
+	}
+
+	return backend, nil
+}
+
+func (d *DVault) jwt(mount string) (*jwt.Backend, error) {
+	backend, ok := d.auth[mount].backend.(*jwt.Backend)
+	if !ok {
+		return nil, ErrAuthMethodNotFound
+	}
+
+	return backend, nil
+}
+
+func (d *DVault) cert(mount string) (*cert.Backend, error) {
+	backend, ok := d.auth[mount].backend.(*cert.Backend)
+	if !ok {
+		return nil, ErrAuthMethodNotFound
+	}
+
+	return backend, nil
+}
+
+func cleanAuthPath(path string) string {
+	return strings.Trim(path, "/")
+}
+
+// authAccessor returns a random accessor in Vault's format, e.g.
+// auth_approle_1a2b3c4d.
+func authAccessor(mountType string) (string, error) {
+	b := make([]byte, 4)
+	if _, err := rand.Read(b); err != nil {
+		return "", err
+	}
+
+	return fmt.Sprintf("auth_%s_%s", mountType, hex.EncodeToString(b)), nil
+}
+
+func authMountData(mount authMount) AuthMountData {
+	return AuthMountData{
+		Accessor: mount.Accessor,
+		Config: AuthMountConfig{
+			DefaultLeaseTtl: int(mount.Config.DefaultLeaseTTL.Seconds()),
+			MaxLeaseTtl:     int(mount.Config.MaxLeaseTTL.Seconds()),
+			TokenType:       "default-service",
+		},
+		Description: mount.Description,
+		Local:       mount.Local,
+		Options:     mount.Options,
+		SealWrap:    mount.SealWrap,
+		Type:        mount.Type,
+		Uuid:        mount.UUID,
+	}
+}
+
+func authTuneData(mount authMount) AuthTuneData {
+	defaultTTL := mount.Config.DefaultLeaseTTL
+	if defaultTTL == 0 {
+		defaultTTL = token.DefaultTTL
+	}
+
+	maxTTL := mount.Config.MaxLeaseTTL
+	if maxTTL == 0 {
+		maxTTL = token.MaxTTL
+	}
+
+	return AuthTuneData{
+		DefaultLeaseTtl: int(defaultTTL.Seconds()),
+		MaxLeaseTtl:     int(maxTTL.Seconds()),
+		Description:     mount.Description,
+		TokenType:       "default-service",
+	}
+}
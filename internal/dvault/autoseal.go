@@ -0,0 +1,202 @@
+package dvault
+
+import (
+	"context"
+	"crypto/rand"
+	"crypto/sha256"
+	"crypto/subtle"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log/slog"
+	"os"
+	"path/filepath"
+	"time"
+)
+
+const (
+	sealStateFile = "seal"
+
+	// autoUnsealRetry is how long to wait before trying again when the seal
+	// could not unwrap the root key on startup, e.g. because the KMS is down.
+	autoUnsealRetry = 10 * time.Second
+)
+
+// sealState is kept next to the key file by auto seals. The root key is
+// only stored wrapped by the seal; the recovery key is only stored hashed, so
+// recovery keys authorize operations but can not unseal.
+//
+// The recovery share configuration is authoritative over the one in the key
+// file, as a recovery rekey only rewrites this file.
+type sealState struct {
+	Type              string `json:"type"`
+	RootKey           []byte `json:"root_key"`
+	RecoveryKeyHash   []byte `json:"recovery_key_hash"`
+	RecoveryShares    int    `json:"recovery_shares"`
+	RecoveryThreshold int    `json:"recovery_threshold"`
+}
+
+func (d *DVault) sealType() string {
+	if d.seal == nil {
+		return "shamir"
+	}
+
+	return d.seal.Type()
+}
+
+// initSeal generates the root key, wraps it with the seal and stores it along
+// with the hash of the recovery key.
+func (d *DVault) initSeal(ctx context.Context, recoveryKey []byte, n uint, t uint) ([]byte, error) {
+	rootKey := make([]byte, 32)
+	if _, err := rand.Read(rootKey); err != nil {
+		return nil, err
+	}
+
+	wrapped, err := d.seal.Encrypt(ctx, rootKey)
+	if err != nil {
+		return nil, fmt.Errorf("wrap root key: %w", err)
+	}
+
+	recoveryKeyHash := sha256.Sum256(recoveryKey)
+	state := sealState{
+		Type:              d.seal.Type(),
+		RootKey:           wrapped,
+		RecoveryKeyHash:   recoveryKeyHash[:],
+		RecoveryShares:    int(n),
+		RecoveryThreshold: int(t),
+	}
+
+	if err = d.saveSealState(state); err != nil {
+		return nil, err
+	}
+
+	return rootKey, nil
+}
+
+func (d *DVault) saveSealState(state sealState) error {
+	data, err := json.Marshal(state)
+	if err != nil {
+		return err
+	}
+
+	if err = d.writeFile(sealStateFile, data); err != nil {
+		return err
+	}
+
+	d.sealState = &state
+
+	return nil
+}
+
+// loadSealState checks that an initialized vault is started with the seal it
+// was initialized with.
+func (d *DVault) loadSealState() error {
+	data, err := os.ReadFile(filepath.Join(d.mountPath, sealStateFile))
+	if errors.Is(err, os.ErrNotExist) {
+		if d.seal != nil {
+			return fmt.Errorf("vault was initialized with the shamir seal, migrating to the %s seal is not supported", d.seal.Type())
+		}
+		return nil
+	}
+	if err != nil {
+		return err
+	}
+
+	var state sealState
+	if err = json.Unmarshal(data, &state); err != nil {
+		return fmt.Errorf("seal state corrupted: %w", err)
+	}
+
+	if d.sealType() != state.Type {
+		return fmt.Errorf("vault was initialized with the %s seal, but the %s seal is configured", state.Type, d.sealType())
+	}
+
+	d.sealState = &state
+	d.N = state.RecoveryShares
+	d.T = state.RecoveryThreshold
+
+	return nil
+}
+
+// autoUnseal unwraps the root key with the seal without any key material, so
+// it is only used on startup. The seal is asked without holding the lock, as
+// it may have to reach a remote KMS.
+func (d *DVault) autoUnseal(ctx context.Context) (UnsealResponse, error) {
+	d.mu.RLock()
+	initialized, sealed, state := d.isInitialized, d.isSealed, d.sealState
+	d.mu.RUnlock()
+
+	if !initialized {
+		return UnsealResponse{}, ErrNotInitialized
+	}
+	if !sealed {
+		return UnsealResponse{}, ErrAlreadyUnsealed
+	}
+
+	rootKey, err := d.seal.Decrypt(ctx, state.RootKey)
+	if err != nil {
+		return UnsealResponse{}, fmt.Errorf("unwrap root key: %w", err)
+	}
+
+	d.mu.Lock()
+	defer d.mu.Unlock()
+
+	if d.isSealed {
+		if err = d.unsealWithRootKey(ctx, rootKey); err != nil {
+			return UnsealResponse{}, err
+		}
+	}
+
+	return d.unsealResponse(), nil
+}
+
+// unsealOnStart unseals with the seal, retrying until the seal is reachable.
+func (d *DVault) unsealOnStart() {
+	for {
+		_, err := d.autoUnseal(context.Background())
+		if err == nil || errors.Is(err, ErrAlreadyUnsealed) {
+			d.logger.Info("unsealed", slog.String("seal", d.seal.Type()))
+			return
+		}
+
+		d.logger.Error("auto unseal", slog.String("error", err.Error()))
+		time.Sleep(autoUnsealRetry)
+	}
+}
+
+// unwrapRootKey unwraps the root key with the seal once keys, a threshold of
+// the recovery keys, are verified.
+func (d *DVault) unwrapRootKey(ctx context.Context, keys []string) ([]byte, error) {
+	if err := d.checkShares(keys); err != nil {
+		return nil, err
+	}
+
+	rootKey, err := d.seal.Decrypt(ctx, d.sealState.RootKey)
+	if err != nil {
+		return nil, fmt.Errorf("unwrap root key: %w", err)
+	}
+
+	return rootKey, nil
+}
+
+// checkShares verifies that keys, a threshold of the current shares, recover
+// the root key, or with an auto seal the recovery key.
+func (d *DVault) checkShares(keys []string) error {
+	secret, err := combineShares(keys, d.T)
+	if err != nil {
+		return err
+	}
+
+	if d.seal == nil {
+		// A wrong set of shares yields a key that can not open the key file.
+		_, err = d.decryptKey(secret)
+		return err
+	}
+
+	hash := sha256.Sum256(secret)
+	if subtle.ConstantTimeCompare(hash[:], d.sealState.RecoveryKeyHash) != 1 {
+		return fmt.Errorf("%w: shares do not recover the recovery key", ErrInvalidShare)
+	}
+
+	return nil
+}
@@ -0,0 +1,165 @@
+package dvault
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"io"
+	"log/slog"
+	"net/http"
+	"net/http/httptest"
+	"strconv"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/Burzich/dvault/internal/config"
+	fs "github.com/Burzich/dvault/internal/dvault/storage/disc"
+)
+
+// newTestTransit serves the encrypt and decrypt endpoints of a transit key
+// named "dvault". Ciphertexts are references to the plaintexts it was given.
+func newTestTransit(t *testing.T) config.TransitSeal {
+	t.Helper()
+
+	var mu sync.Mutex
+	plaintexts := make(map[string]string)
+
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		mu.Lock()
+		defer mu.Unlock()
+
+		var body struct {
+			Plaintext  string `json:"plaintext"`
+			Ciphertext string `json:"ciphertext"`
+		}
+		if err := json.NewDecoder(r.Body).Decode(&body); err != nil {
+			w.WriteHeader(http.StatusBadRequest)
+			return
+		}
+
+		var data map[string]string
+		switch r.URL.Path {
+		case "/v1/transit/encrypt/dvault":
+			ciphertext := "vault:v1:" + strconv.Itoa(len(plaintexts))
+			plaintexts[ciphertext] = body.Plaintext
+			data = map[string]string{"ciphertext": ciphertext}
+		case "/v1/transit/decrypt/dvault":
+			plaintext, ok := plaintexts[body.Ciphertext]
+			if !ok {
+				w.WriteHeader(http.StatusBadRequest)
+				return
+			}
+			data = map[string]string{"plaintext": plaintext}
+		default:
+			w.WriteHeader(http.StatusNotFound)
+			return
+		}
+
+		_ = json.NewEncoder(w).Encode(map[string]any{"data": data})
+	}))
+	t.Cleanup(server.Close)
+
+	return config.TransitSeal{Address: server.URL, Token: "s.transit", KeyName: "dvault"}
+}
+
+func newTestDVault(t *testing.T, cfg config.Dvault) *DVault {
+	t.Helper()
+
+	d, err := NewDVault(slog.New(slog.NewTextHandler(io.Discard, nil)), cfg, fs.NewFSStorage(cfg.MountPath))
+	if err != nil {
+		t.Fatalf("NewDVault: %v", err)
+	}
+	t.Cleanup(func() { _, _ = d.Seal(context.Background()) })
+
+	return d
+}
+
+func isSealed(t *testing.T, d *DVault) bool {
+	t.Helper()
+
+	status, err := d.SealStatus(context.Background())
+	if err != nil {
+		t.Fatalf("SealStatus: %v", err)
+	}
+
+	return status.Sealed
+}
+
+func TestTransitAutoUnseal(t *testing.T) {
+	ctx := context.Background()
+	cfg := config.Dvault{
+		MountPath:        t.TempDir(),
+		EncryptionMethod: "aes",
+		Seal:             config.Seal{Type: "transit", Transit: newTestTransit(t)},
+	}
+
+	d := newTestDVault(t, cfg)
+	init, err := d.Init(ctx, Init{RecoveryShares: 3, RecoveryThreshold: 2})
+	if err != nil {
+		t.Fatalf("Init: %v", err)
+	}
+	if len(init.Keys) != 0 || len(init.RecoveryKeys) != 3 {
+		t.Fatalf("Init returned %d keys and %d recovery keys, want 0 and 3", len(init.Keys), len(init.RecoveryKeys))
+	}
+	if isSealed(t, d) {
+		t.Fatal("vault is sealed after init")
+	}
+
+	// Without the recovery keys anybody reaching the unseal endpoint could
+	// undo a seal.
+	if _, err = d.Seal(ctx); err != nil {
+		t.Fatalf("Seal: %v", err)
+	}
+	if _, err = d.Unseal(ctx, Unseal{}); err == nil {
+		t.Fatal("Unseal without a key succeeded")
+	}
+	if !isSealed(t, d) {
+		t.Fatal("vault is unsealed without recovery keys")
+	}
+
+	for _, key := range init.RecoveryKeys[:2] {
+		if _, err = d.Unseal(ctx, Unseal{Key: key}); err != nil {
+			t.Fatalf("Unseal: %v", err)
+		}
+	}
+	if isSealed(t, d) {
+		t.Fatal("vault is sealed after a threshold of recovery keys")
+	}
+
+	if _, err = d.Seal(ctx); err != nil {
+		t.Fatalf("Seal: %v", err)
+	}
+
+	// A restart unseals with the seal alone.
+	restarted := newTestDVault(t, cfg)
+	deadline := time.Now().Add(5 * time.Second)
+	for isSealed(t, restarted) {
+		if time.Now().After(deadline) {
+			t.Fatal("vault did not unseal on startup")
+		}
+		time.Sleep(10 * time.Millisecond)
+	}
+
+	if _, err = restarted.Unseal(ctx, Unseal{Key: init.RecoveryKeys[0]}); !errors.Is(err, ErrAlreadyUnsealed) {
+		t.Fatalf("Unseal error = %v, want %v", err, ErrAlreadyUnsealed)
+	}
+}
+
+func TestTransitSealMismatch(t *testing.T) {
+	cfg := config.Dvault{
+		MountPath:        t.TempDir(),
+		EncryptionMethod: "aes",
+		Seal:             config.Seal{Type: "transit", Transit: newTestTransit(t)},
+	}
+
+	d := newTestDVault(t, cfg)
+	if _, err := d.Init(context.Background(), Init{RecoveryShares: 1, RecoveryThreshold: 1}); err != nil {
+		t.Fatalf("Init: %v", err)
+	}
+
+	cfg.Seal = config.Seal{}
+	if _, err := NewDVault(slog.New(slog.NewTextHandler(io.Discard, nil)), cfg, fs.NewFSStorage(cfg.MountPath)); err == nil {
+		t.Fatal("NewDVault with the shamir seal succeeded on a vault initialized with the transit seal")
+	}
+}
@@ -0,0 +1,162 @@
+package dvault
+
+import (
+	"context"
+	"crypto/x509"
+	"path/filepath"
+
+	"github.com/Burzich/dvault/internal/dvault/auth/cert"
+	"github.com/Burzich/dvault/internal/tools"
+)
+
+func (d *DVault) ListCertRoles(ctx context.Context, mount string) (Response, error) {
+	d.mu.RLock()
+	defer d.mu.RUnlock()
+
+	if d.isSealed {
+		return Response{}, ErrSealed
+	}
+
+	backend, err := d.cert(mount)
+	if err != nil {
+		return Response{}, err
+	}
+
+	roles, err := backend.ListRoles(ctx)
+	if err != nil {
+		return Response{}, err
+	}
+
+	var response Response
+	response.Data = KeyList{Keys: roles}
+	response.MountType = "cert"
+	response.RequestId = tools.GenerateXRequestID()
+
+	return response, nil
+}
+
+func (d *DVault) GetCertRole(ctx context.Context, mount string, name string) (Response, error) {
+	d.mu.RLock()
+	defer d.mu.RUnlock()
+
+	if d.isSealed {
+		return Response{}, ErrSealed
+	}
+
+	backend, err := d.cert(mount)
+	if err != nil {
+		return Response{}, err
+	}
+
+	role, err := backend.GetRole(ctx, name)
+	if err != nil {
+		return Response{}, err
+	}
+
+	var response Response
+	response.Data = CertRoleData{
+		Certificate:                role.Certificate,
+		DisplayName:                role.DisplayName,
+		AllowedCommonNames:         role.AllowedCommonNames,
+		AllowedDnsSans:             role.AllowedDNSSANs,
+		AllowedOrganizationalUnits: role.AllowedOrganizationalUnits,
+		TokenParamsData:            tokenParamsData(role.TokenParams),
+	}
+	response.MountType = "cert"
+	response.RequestId = tools.GenerateXRequestID()
+
+	return response, nil
+}
+
+func (d *DVault) SaveCertRole(ctx context.Context, mount string, name string, role CertRole) (Response, error) {
+	d.mu.RLock()
+	defer d.mu.RUnlock()
+
+	if d.isSealed {
+		return Response{}, ErrSealed
+	}
+
+	backend, err := d.cert(mount)
+	if err != nil {
+		return Response{}, err
+	}
+
+	err = backend.PutRole(ctx, cert.Role{
+		Name:                       name,
+		Certificate:                role.Certificate,
+		DisplayName:                role.DisplayName,
+		AllowedCommonNames:         role.AllowedCommonNames,
+		AllowedDNSSANs:             role.AllowedDNSSANs,
+		AllowedOrganizationalUnits: role.AllowedOrganizationalUnits,
+		TokenParams:                role.TokenParams,
+	})
+	if err != nil {
+		return Response{}, err
+	}
+
+	var response Response
+	response.MountType = "cert"
+	response.RequestId = tools.GenerateXRequestID()
+
+	return response, nil
+}
+
+func (d *DVault) DeleteCertRole(ctx context.Context, mount string, name string) (Response, error) {
+	d.mu.RLock()
+	defer d.mu.RUnlock()
+
+	if d.isSealed {
+		return Response{}, ErrSealed
+	}
+
+	backend, err := d.cert(mount)
+	if err != nil {
+		return Response{}, err
+	}
+
+	if err = backend.DeleteRole(ctx, name); err != nil {
+		return Response{}, err
+	}
+
+	var response Response
+	response.MountType = "cert"
+	response.RequestId = tools.GenerateXRequestID()
+
+	return response, nil
+}
+
+// LoginCert issues a token for the client certificate chain presented on the
+// TLS connection. The chain is verified against the CA bundle of the role.
+func (d *DVault) LoginCert(ctx context.Context, mount string, name string, chain []*x509.Certificate) (Response, error) {
+	d.mu.RLock()
+	defer d.mu.RUnlock()
+
+	if d.isSealed {
+		return Response{}, ErrSealed
+	}
+
+	backend, err := d.cert(mount)
+	if err != nil {
+		return Response{}, err
+	}
+
+	role, err := backend.Login(ctx, name, chain)
+	if err != nil {
+		return Response{}, err
+	}
+
+	displayName := role.DisplayName
+	if displayName == "" {
+		displayName = role.Name
+	}
+
+	return d.issueLoginToken(ctx, login{
+		Mount:       mount,
+		MountType:   "cert",
+		Path:        filepath.Join(authPath, mount, "login"),
+		DisplayName: "cert-" + displayName,
+		Alias:       chain[0].Subject.CommonName,
+		Meta:        cert.Metadata(role, chain[0]),
+		Params:      role.TokenParams,
+	})
+}
@@ -0,0 +1,46 @@
+package dvault
+
+import (
+	"context"
+
+	"github.com/Burzich/dvault/internal/dvault/token"
+)
+
+type tokenKey struct{}
+
+type mfaKey struct{}
+
+type clientIPKey struct{}
+
+func withToken(ctx context.Context, entry token.Entry) context.Context {
+	return context.WithValue(ctx, tokenKey{}, entry)
+}
+
+func tokenFromContext(ctx context.Context) (token.Entry, bool) {
+	entry, ok := ctx.Value(tokenKey{}).(token.Entry)
+	return entry, ok
+}
+
+// WithMFACredentials attaches the X-Vault-MFA header values of a request. Each
+// value is a passcode prefixed with the ID or name of its method and a colon,
+// the prefix may be left out when a single method is required.
+func WithMFACredentials(ctx context.Context, credentials []string) context.Context {
+	return context.WithValue(ctx, mfaKey{}, credentials)
+}
+
+func mfaCredentials(ctx context.Context) []string {
+	credentials, _ := ctx.Value(mfaKey{}).([]string)
+	return credentials
+}
+
+// WithClientIP attaches the address of the client a request came from, after
+// trusted proxies are accounted for.
+func WithClientIP(ctx context.Context, ip string) context.Context {
+	return context.WithValue(ctx, clientIPKey{}, ip)
+}
+
+// ClientIP returns the client address attached with WithClientIP.
+func ClientIP(ctx context.Context) string {
+	ip, _ := ctx.Value(clientIPKey{}).(string)
+	return ip
+}
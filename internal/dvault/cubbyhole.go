@@ -0,0 +1,141 @@
+package dvault
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/Burzich/dvault/internal/dvault/cubbyhole"
+	"github.com/Burzich/dvault/internal/dvault/token"
+	"github.com/Burzich/dvault/internal/tools"
+)
+
+// cubbyholeMount is the path of the cubbyhole secrets engine. It is mounted
+// on unseal and can neither be mounted elsewhere nor removed.
+const cubbyholeMount = "cubbyhole"
+
+func (d *DVault) GetCubbyholeSecret(ctx context.Context, secretPath string) (Response, error) {
+	d.mu.RLock()
+	defer d.mu.RUnlock()
+
+	if d.isSealed {
+		return Response{}, ErrSealed
+	}
+
+	accessor, err := cubbyholeAccessor(ctx)
+	if err != nil {
+		return Response{}, err
+	}
+
+	data, err := d.cubbyhole.Get(ctx, accessor, secretPath)
+	if err != nil {
+		return Response{}, err
+	}
+
+	var response Response
+	response.Data = data
+	response.MountType = cubbyholeMount
+	response.RequestId = tools.GenerateXRequestID()
+
+	return response, nil
+}
+
+func (d *DVault) ListCubbyholeSecrets(ctx context.Context, secretPath string) (Response, error) {
+	d.mu.RLock()
+	defer d.mu.RUnlock()
+
+	if d.isSealed {
+		return Response{}, ErrSealed
+	}
+
+	accessor, err := cubbyholeAccessor(ctx)
+	if err != nil {
+		return Response{}, err
+	}
+
+	keys, err := d.cubbyhole.List(ctx, accessor, secretPath)
+	if err != nil {
+		return Response{}, err
+	}
+
+	var response Response
+	response.Data = KeyList{Keys: keys}
+	response.MountType = cubbyholeMount
+	response.RequestId = tools.GenerateXRequestID()
+
+	return response, nil
+}
+
+func (d *DVault) SaveCubbyholeSecret(ctx context.Context, secretPath string, data map[string]interface{}) (Response, error) {
+	d.mu.RLock()
+	defer d.mu.RUnlock()
+
+	if d.isSealed {
+		return Response{}, ErrSealed
+	}
+
+	accessor, err := cubbyholeAccessor(ctx)
+	if err != nil {
+		return Response{}, err
+	}
+
+	if err = d.cubbyhole.Put(ctx, accessor, secretPath, data); err != nil {
+		return Response{}, err
+	}
+
+	var response Response
+	response.MountType = cubbyholeMount
+	response.RequestId = tools.GenerateXRequestID()
+
+	return response, nil
+}
+
+func (d *DVault) DeleteCubbyholeSecret(ctx context.Context, secretPath string) (Response, error) {
+	d.mu.RLock()
+	defer d.mu.RUnlock()
+
+	if d.isSealed {
+		return Response{}, ErrSealed
+	}
+
+	accessor, err := cubbyholeAccessor(ctx)
+	if err != nil {
+		return Response{}, err
+	}
+
+	if err = d.cubbyhole.Delete(ctx, accessor, secretPath); err != nil {
+		return Response{}, err
+	}
+
+	var response Response
+	response.MountType = cubbyholeMount
+	response.RequestId = tools.GenerateXRequestID()
+
+	return response, nil
+}
+
+// cubbyholeAccessor returns the accessor of the calling token, which scopes
+// its cubbyhole. Batch tokens have no accessor and therefore no cubbyhole.
+func cubbyholeAccessor(ctx context.Context) (string, error) {
+	entry, ok := tokenFromContext(ctx)
+	if !ok {
+		return "", ErrPermissionDenied
+	}
+
+	if entry.Type == token.TypeBatch || entry.Accessor == "" {
+		return "", fmt.Errorf("%w: batch tokens have no cubbyhole", token.ErrBatchToken)
+	}
+
+	return entry.Accessor, nil
+}
+
+// destroyCubbyhole returns the revoke hook of the token store, which deletes
+// the cubbyhole of every revoked or expired token.
+func destroyCubbyhole(cubbyholes *cubbyhole.Store) func(ctx context.Context, entry token.Entry) error {
+	return func(ctx context.Context, entry token.Entry) error {
+		if entry.Accessor == "" {
+			return nil
+		}
+
+		return cubbyholes.Destroy(ctx, entry.Accessor)
+	}
+}
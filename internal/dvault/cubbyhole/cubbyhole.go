@@ -0,0 +1,141 @@
+package cubbyhole
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"path/filepath"
+	"strings"
+
+	"github.com/Burzich/dvault/internal/dvault/storage"
+	"github.com/Burzich/dvault/internal/tools"
+)
+
+// Store keeps a private namespace of secrets for every token below path. The
+// namespaces are keyed by the token accessor, so the token itself never ends
+// up in a storage path.
+type Store struct {
+	path      string
+	storage   storage.Storage
+	encryptor tools.Encryptor
+}
+
+func NewStore(path string, s storage.Storage, encryptor tools.Encryptor) *Store {
+	return &Store{
+		path:      path,
+		storage:   s,
+		encryptor: encryptor,
+	}
+}
+
+func (s *Store) Get(ctx context.Context, accessor string, secretPath string) (map[string]interface{}, error) {
+	p, err := s.secretPath(accessor, secretPath)
+	if err != nil {
+		return nil, err
+	}
+
+	b, err := s.storage.Get(ctx, p)
+	if errors.Is(err, storage.ErrPathNotFound) {
+		return nil, ErrPathNotFound
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	decryptedData, err := s.encryptor.Decrypt(b)
+	if err != nil {
+		return nil, err
+	}
+
+	var data map[string]interface{}
+	if err = json.Unmarshal(decryptedData, &data); err != nil {
+		return nil, err
+	}
+
+	return data, nil
+}
+
+func (s *Store) Put(ctx context.Context, accessor string, secretPath string, data map[string]interface{}) error {
+	p, err := s.secretPath(accessor, secretPath)
+	if err != nil {
+		return err
+	}
+
+	d, err := json.Marshal(data)
+	if err != nil {
+		return err
+	}
+
+	encryptedData, err := s.encryptor.Encrypt(d)
+	if err != nil {
+		return err
+	}
+
+	return s.storage.Put(ctx, p, encryptedData)
+}
+
+func (s *Store) Delete(ctx context.Context, accessor string, secretPath string) error {
+	p, err := s.secretPath(accessor, secretPath)
+	if err != nil {
+		return err
+	}
+
+	err = s.storage.Delete(ctx, p)
+	if errors.Is(err, storage.ErrPathNotFound) {
+		return ErrPathNotFound
+	}
+
+	return err
+}
+
+// List returns the keys below secretPath, an empty path lists the root of
+// the namespace. Keys with further keys below them end with "/".
+func (s *Store) List(ctx context.Context, accessor string, secretPath string) ([]string, error) {
+	p := filepath.Join(s.path, accessor)
+	if strings.Trim(secretPath, "/") != "" {
+		var err error
+		if p, err = s.secretPath(accessor, secretPath); err != nil {
+			return nil, err
+		}
+	} else if !validAccessor(accessor) {
+		return nil, ErrInvalidPath
+	}
+
+	keys, err := s.storage.List(ctx, p)
+	if err != nil {
+		return nil, err
+	}
+	if len(keys) == 0 {
+		return nil, ErrPathNotFound
+	}
+
+	return keys, nil
+}
+
+// Destroy deletes the namespace of a token, e.g. when it is revoked.
+func (s *Store) Destroy(ctx context.Context, accessor string) error {
+	if !validAccessor(accessor) {
+		return ErrInvalidPath
+	}
+
+	return storage.DeleteTree(ctx, s.storage, filepath.Join(s.path, accessor))
+}
+
+func (s *Store) secretPath(accessor string, secretPath string) (string, error) {
+	secretPath = strings.Trim(secretPath, "/")
+	if !validAccessor(accessor) || secretPath == "" {
+		return "", ErrInvalidPath
+	}
+
+	for _, part := range strings.Split(secretPath, "/") {
+		if part == "" || part == "." || part == ".." {
+			return "", ErrInvalidPath
+		}
+	}
+
+	return filepath.Join(s.path, accessor, secretPath), nil
+}
+
+func validAccessor(accessor string) bool {
+	return accessor != "" && !strings.ContainsAny(accessor, `/\.`)
+}
@@ -0,0 +1,6 @@
+package cubbyhole
+
+import "errors"
+
+var ErrPathNotFound = errors.New("path not found")
+var ErrInvalidPath = errors.New("invalid cubbyhole path")
@@ -16,19 +16,49 @@ import (
 	"time"
 
 	"github.com/Burzich/dvault/internal/config"
+	"github.com/Burzich/dvault/internal/dvault/auth"
+	"github.com/Burzich/dvault/internal/dvault/cubbyhole"
+	"github.com/Burzich/dvault/internal/dvault/identity"
 	kv2 "github.com/Burzich/dvault/internal/dvault/kv"
 	"github.com/Burzich/dvault/internal/dvault/kv/standart"
+	"github.com/Burzich/dvault/internal/dvault/mfa"
+	"github.com/Burzich/dvault/internal/dvault/policy"
+	"github.com/Burzich/dvault/internal/dvault/recipient"
+	"github.com/Burzich/dvault/internal/dvault/seal"
 	"github.com/Burzich/dvault/internal/dvault/storage"
+	"github.com/Burzich/dvault/internal/dvault/token"
 	"github.com/Burzich/dvault/internal/tools"
 	"github.com/cloudflare/circl/group"
-	"github.com/cloudflare/circl/secretsharing"
 )
 
+const (
+	tokenPath     = "sys/token"
+	policyPath    = "sys/policy"
+	identityPath  = "sys/identity"
+	cubbyholePath = "sys/cubbyhole"
+	mfaPath       = "sys/mfa"
+)
+
+// defaultUnsealTimeout is how long an unseal attempt may take before its
+// shares are discarded.
+const defaultUnsealTimeout = 10 * time.Minute
+
+// unsealAttempt holds the shares submitted for an unseal. The nonce lets key
+// holders check that they contribute to the same attempt.
+type unsealAttempt struct {
+	nonce   string
+	started time.Time
+	shares  shareSet
+}
+
 type DVault struct {
 	logger           *slog.Logger
 	mountPath        string
 	encryptionMethod string
 
+	expirationInterval time.Duration
+	userLockout        auth.LockoutConfig
+
 	buildDate     time.Time
 	isSealed      bool
 	isInitialized bool
@@ -36,12 +66,33 @@ type DVault struct {
 	mu sync.RWMutex
 
 	encryptor tools.Encryptor
-	Storage   storage.Storage
+	keyring   *tools.Keyring
+	rootKey   []byte
+
+	// seal is nil for the Shamir seal.
+	seal      seal.Seal
+	sealState *sealState
+
+	Storage storage.Storage
 
 	kv        map[string]kv2.KV
-	shareKeys []string
-	N         int
-	T         int
+	auth      map[string]authMount
+	tokens    *token.Store
+	policies  *policy.Store
+	identity  *identity.Store
+	cubbyhole *cubbyhole.Store
+	mfa       *mfa.Store
+
+	unseal        *unsealAttempt
+	unsealTimeout time.Duration
+
+	generateRoot *generateRootAttempt
+	rekey        *rekeyAttempt
+	expiration   *expirationManager
+	rewrap       *rewrapper
+
+	N int
+	T int
 }
 
 func NewDVault(logger *slog.Logger, dvault config.Dvault, storage storage.Storage) (*DVault, error) {
@@ -54,69 +105,180 @@ func NewDVault(logger *slog.Logger, dvault config.Dvault, storage storage.Storag
 		isInitialized:    false,
 		mu:               sync.RWMutex{},
 		kv:               make(map[string]kv2.KV),
-		shareKeys:        nil,
 		N:                0,
 		T:                0,
 		Storage:          storage,
 	}
 
-	err := d.tryInitVault()
+	d.expirationInterval = dvault.ExpirationInterval
+	if d.expirationInterval <= 0 {
+		d.expirationInterval = defaultExpirationInterval
+	}
+
+	d.unsealTimeout = dvault.UnsealTimeout
+	if d.unsealTimeout <= 0 {
+		d.unsealTimeout = defaultUnsealTimeout
+	}
+
+	d.userLockout = auth.LockoutConfig{
+		Threshold:    dvault.UserLockout.Threshold,
+		Duration:     dvault.UserLockout.Duration,
+		CounterReset: dvault.UserLockout.CounterReset,
+		Disable:      dvault.UserLockout.Disable,
+	}
+
+	var err error
+	if d.seal, err = seal.New(dvault.Seal); err != nil {
+		return nil, err
+	}
+
+	err = d.tryInitVault()
 	if err != nil {
 		return nil, err
 	}
 
+	if d.seal != nil && d.isInitialized {
+		go d.unsealOnStart()
+	}
+
 	return &d, nil
 }
 
+// Unseal adds a key share to the running unseal attempt, which is started by
+// the first share. Once the threshold is reached the root key is recovered
+// and the vault unsealed. An attempt that does not complete within the unseal
+// timeout is discarded together with its shares.
+//
+// An auto seal only unseals on its own on startup. After a seal through the
+// API the recovery keys are required, and the root key is unwrapped by the
+// seal once they are verified.
 func (d *DVault) Unseal(ctx context.Context, unseal Unseal) (UnsealResponse, error) {
 	d.mu.Lock()
 	defer d.mu.Unlock()
 
 	if !d.isSealed {
-		return UnsealResponse{}, errors.New("already unsealed")
+		return UnsealResponse{}, ErrAlreadyUnsealed
 	}
 
 	if unseal.Reset {
-		d.shareKeys = make([]string, 0)
+		d.unseal = nil
+		if unseal.Key == "" {
+			return d.unsealResponse(), nil
+		}
 	}
 
-	d.shareKeys = append(d.shareKeys, unseal.Key)
-
-	if len(d.shareKeys) == d.T {
-		encryptor, err := d.tryUnseal(d.shareKeys)
-		d.shareKeys = nil
-		if err != nil {
-			return UnsealResponse{}, err
+	attempt := d.currentUnseal()
+	if unseal.Nonce != "" && (attempt == nil || unseal.Nonce != attempt.nonce) {
+		return UnsealResponse{}, ErrInvalidNonce
+	}
+	if attempt == nil {
+		attempt = &unsealAttempt{
+			nonce:   tools.GenerateXRequestID(),
+			started: time.Now(),
 		}
+	}
 
-		err = d.restoreKV(encryptor)
-		if err != nil {
-			return UnsealResponse{}, err
-		}
+	if err := attempt.shares.add(unseal.Key); err != nil {
+		return UnsealResponse{}, err
+	}
+	d.unseal = attempt
 
-		d.isSealed = false
-		d.encryptor = encryptor
-
-		return UnsealResponse{
-			BuildDate:         d.buildDate.String(),
-			ClusterId:         "dvault",
-			ClusterName:       "dvault",
-			HcpLinkResourceID: "",
-			HcpLinkStatus:     "",
-			Initialized:       d.isInitialized,
-			Migration:         false,
-			N:                 d.N,
-			T:                 d.T,
-			Progress:          0,
-			Nonce:             "",
-			RecoverySeal:      false,
-			Sealed:            d.isSealed,
-			StorageType:       "file",
-			Type:              "shamir",
-			Version:           "1.0.0",
-		}, nil
+	if attempt.shares.len() < d.T {
+		return d.unsealResponse(), nil
+	}
+	d.unseal = nil
+
+	var rootKey []byte
+	var err error
+	if d.seal != nil {
+		rootKey, err = d.unwrapRootKey(ctx, attempt.shares.list())
+	} else {
+		rootKey, err = d.recoverRootKey(attempt.shares.list())
+	}
+	if err != nil {
+		return UnsealResponse{}, err
+	}
+
+	if err = d.unsealWithRootKey(ctx, rootKey); err != nil {
+		return UnsealResponse{}, err
 	}
 
+	return d.unsealResponse(), nil
+}
+
+// unsealWithRootKey opens the keyring with the root key and restores the
+// mounts and stores.
+func (d *DVault) unsealWithRootKey(ctx context.Context, rootKey []byte) error {
+	keyring, err := d.restoreKey(rootKey)
+	if err != nil {
+		return err
+	}
+	encryptor := tools.Encryptor(keyring)
+
+	err = d.restoreKV(encryptor)
+	if err != nil {
+		return err
+	}
+
+	policies := policy.NewStore(policyPath, d.Storage, encryptor)
+	err = policies.SetupDefault(ctx)
+	if err != nil {
+		return err
+	}
+
+	err = d.restoreAuth(ctx, encryptor)
+	if err != nil {
+		return err
+	}
+
+	cubbyholes := cubbyhole.NewStore(cubbyholePath, d.Storage, encryptor)
+
+	d.tokens = token.NewStore(tokenPath, d.Storage, encryptor)
+	d.tokens.OnRevoke(destroyCubbyhole(cubbyholes))
+	d.policies = policies
+	d.identity = identity.NewStore(identityPath, d.Storage, encryptor)
+	d.cubbyhole = cubbyholes
+	d.mfa = mfa.NewStore(mfaPath, d.Storage, encryptor)
+	d.isSealed = false
+	d.encryptor = encryptor
+	d.keyring = keyring
+	d.rootKey = rootKey
+
+	d.expiration = newExpirationManager(d.logger, d.tokens)
+	d.expiration.start(d.expirationInterval)
+
+	// A rewrap interrupted by sealing or a restart picks up where it left off.
+	if keyring.Len() > 1 {
+		d.startRewrap()
+	}
+
+	return nil
+}
+
+// currentUnseal returns the running unseal attempt, or nil when there is
+// none or it timed out.
+func (d *DVault) currentUnseal() *unsealAttempt {
+	if d.unseal != nil && time.Since(d.unseal.started) > d.unsealTimeout {
+		d.unseal = nil
+	}
+
+	return d.unseal
+}
+
+// unsealProgress returns the nonce and the number of shares of the running
+// unseal attempt.
+func (d *DVault) unsealProgress() (string, int) {
+	attempt := d.currentUnseal()
+	if attempt == nil {
+		return "", 0
+	}
+
+	return attempt.nonce, attempt.shares.len()
+}
+
+func (d *DVault) unsealResponse() UnsealResponse {
+	nonce, progress := d.unsealProgress()
+
 	return UnsealResponse{
 		BuildDate:         d.buildDate.String(),
 		ClusterId:         "dvault",
@@ -127,14 +289,14 @@ func (d *DVault) Unseal(ctx context.Context, unseal Unseal) (UnsealResponse, err
 		Migration:         false,
 		N:                 d.N,
 		T:                 d.T,
-		Progress:          len(d.shareKeys),
-		Nonce:             "",
-		RecoverySeal:      false,
+		Progress:          progress,
+		Nonce:             nonce,
+		RecoverySeal:      d.seal != nil,
 		Sealed:            d.isSealed,
 		StorageType:       "file",
-		Type:              "shamir",
+		Type:              d.sealType(),
 		Version:           "1.0.0",
-	}, nil
+	}
 }
 
 func (d *DVault) Seal(ctx context.Context) (Response, error) {
@@ -144,52 +306,77 @@ func (d *DVault) Seal(ctx context.Context) (Response, error) {
 	var response Response
 	response.RequestId = tools.GenerateXRequestID()
 
+	if d.expiration != nil {
+		d.expiration.stop()
+		d.expiration = nil
+	}
+	d.stopRewrap()
+
 	d.isSealed = true
+	d.rootKey = nil
 
 	return response, nil
 }
 
-func (d *DVault) Init(_ context.Context, init Init) (InitResponse, error) {
+func (d *DVault) Init(ctx context.Context, init Init) (InitResponse, error) {
 	d.mu.Lock()
 	defer d.mu.Unlock()
 
 	if d.isInitialized {
-		return InitResponse{}, errors.New("already initialized")
+		return InitResponse{}, ErrAlreadyInitialized
 	}
 
 	g := group.P256
 	t := uint(2)
 	n := uint(5)
 
-	if init.SecretShares != 0 {
-		n = uint(init.SecretShares)
+	// With an auto seal the shares are recovery keys, configured by the
+	// recovery_ fields.
+	shares, threshold, pgpKeys, prefix := init.SecretShares, init.SecretThreshold, init.PgpKeys, ""
+	if d.seal != nil {
+		if len(init.PgpKeys) != 0 {
+			return InitResponse{}, fmt.Errorf("%w: pgp_keys are not used with an auto seal, use recovery_pgp_keys", ErrInvalidInit)
+		}
+		shares, threshold, pgpKeys, prefix = init.RecoveryShares, init.RecoveryThreshold, init.RecoveryPgpKeys, "recovery_"
+	} else if len(init.RecoveryPgpKeys) != 0 {
+		return InitResponse{}, fmt.Errorf("%w: recovery_pgp_keys require a seal with recovery keys", ErrInvalidInit)
+	}
+
+	if shares != 0 {
+		n = uint(shares)
 	}
-	if init.SecretThreshold != 0 {
-		t = uint(init.SecretThreshold)
+	if threshold != 0 {
+		t = uint(threshold)
 	}
 
-	secret := g.RandomScalar(rand.Reader)
-	ss := secretsharing.New(rand.Reader, t-1, secret)
-	shares := make([]secretsharing.Share, n)
-	for i := range shares {
-		shares[i] = ss.ShareWithID(g.RandomScalar(rand.Reader))
+	// The keys are parsed before anything is stored, so a typo in one of
+	// them does not leave behind an initialized vault nobody can unseal.
+	if len(pgpKeys) != 0 && len(pgpKeys) != int(n) {
+		return InitResponse{}, fmt.Errorf("%w: %d %spgp_keys given for %d %sshares", ErrInvalidInit, len(pgpKeys), prefix, n, prefix)
 	}
 
-	var sharesValuesBase64 []string
+	shareRecipients, err := recipient.ParseAll(pgpKeys)
+	if err != nil {
+		return InitResponse{}, fmt.Errorf("%spgp_keys: %w", prefix, err)
+	}
 
-	for _, share := range shares {
-		shareValueBytes, err := share.Value.MarshalBinary()
-		if err != nil {
-			return InitResponse{}, err
+	var rootTokenRecipient recipient.Recipient
+	if init.RootTokenPgpKey != "" {
+		if rootTokenRecipient, err = recipient.Parse(init.RootTokenPgpKey); err != nil {
+			return InitResponse{}, fmt.Errorf("root_token_pgp_key: %w", err)
 		}
-		shareIdBytes, err := share.ID.MarshalBinary()
-		if err != nil {
+	}
+
+	secret := g.RandomScalar(rand.Reader)
+	sharesValuesBase64, err := splitSecret(secret, n, t)
+	if err != nil {
+		return InitResponse{}, err
+	}
+
+	if len(shareRecipients) != 0 {
+		if sharesValuesBase64, err = encryptShares(shareRecipients, sharesValuesBase64); err != nil {
 			return InitResponse{}, err
 		}
-
-		shareValueBase64 := base64.StdEncoding.EncodeToString(shareValueBytes)
-		shareIdBase64 := base64.StdEncoding.EncodeToString(shareIdBytes)
-		sharesValuesBase64 = append(sharesValuesBase64, shareValueBase64+"#"+shareIdBase64)
 	}
 
 	secretBytes, err := secret.MarshalBinary()
@@ -197,19 +384,53 @@ func (d *DVault) Init(_ context.Context, init Init) (InitResponse, error) {
 		return InitResponse{}, err
 	}
 
-	_, err = d.generateAndSaveEncryptKey(secretBytes, n, t)
+	rootKey := secretBytes
+	if d.seal != nil {
+		if rootKey, err = d.initSeal(ctx, secretBytes, n, t); err != nil {
+			return InitResponse{}, err
+		}
+	}
+
+	encryptor, err := d.generateAndSaveEncryptKey(rootKey, n, t)
+	if err != nil {
+		return InitResponse{}, err
+	}
+
+	rootToken, err := token.NewStore(tokenPath, d.Storage, encryptor).Create(ctx, rootTokenEntry())
 	if err != nil {
 		return InitResponse{}, err
 	}
 
+	rootTokenID := rootToken.ID
+	if rootTokenRecipient != nil {
+		if rootTokenID, err = rootTokenRecipient.Encrypt([]byte(rootToken.ID)); err != nil {
+			return InitResponse{}, err
+		}
+	}
+
 	d.N = int(n)
 	d.T = int(t)
 	d.isInitialized = true
 
+	if d.seal == nil {
+		return InitResponse{
+			Keys:       sharesValuesBase64,
+			KeysBase64: sharesValuesBase64,
+			RootToken:  rootTokenID,
+		}, nil
+	}
+
+	// There is nobody to wait for, so an auto seal unseals right away.
+	if err = d.unsealWithRootKey(ctx, rootKey); err != nil {
+		return InitResponse{}, err
+	}
+
 	return InitResponse{
-		Keys:       sharesValuesBase64,
-		KeysBase64: sharesValuesBase64,
-		RootToken:  base64.StdEncoding.EncodeToString(secretBytes),
+		Keys:               []string{},
+		KeysBase64:         []string{},
+		RecoveryKeys:       sharesValuesBase64,
+		RecoveryKeysBase64: sharesValuesBase64,
+		RootToken:          rootTokenID,
 	}, nil
 }
 
@@ -217,20 +438,22 @@ func (d *DVault) SealStatus(ctx context.Context) (SealStatus, error) {
 	d.mu.Lock()
 	defer d.mu.Unlock()
 
+	nonce, progress := d.unsealProgress()
+
 	return SealStatus{
-		Type:         "shamir",
+		Type:         d.sealType(),
 		Initialized:  d.isInitialized,
 		Sealed:       d.isSealed,
 		T:            d.T,
 		N:            d.N,
-		Progress:     len(d.shareKeys),
-		Nonce:        "",
+		Progress:     progress,
+		Nonce:        nonce,
 		Version:      "1.0.0",
 		BuildDate:    d.buildDate,
 		Migration:    false,
 		ClusterName:  "dvault",
 		ClusterId:    "dvault",
-		RecoverySeal: false,
+		RecoverySeal: d.seal != nil,
 		StorageType:  "file",
 	}, nil
 }
@@ -244,7 +467,7 @@ func (d *DVault) Mounts(_ context.Context) (Mounts, error) {
 	m.RequestId = tools.GenerateXRequestID()
 
 	if d.isSealed {
-		return Mounts{}, errors.New("vault is sealed")
+		return Mounts{}, ErrSealed
 	}
 
 	for k := range d.kv {
@@ -272,6 +495,12 @@ func (d *DVault) Mounts(_ context.Context) (Mounts, error) {
 		}
 	}
 
+	m.Data[cubbyholeMount] = MountData{
+		Description: "per-token private secret storage",
+		Local:       true,
+		Type:        cubbyholeMount,
+	}
+
 	return m, nil
 }
 
@@ -280,7 +509,7 @@ func (d *DVault) GetKVSecret(ctx context.Context, mount string, secretPath strin
 	defer d.mu.RUnlock()
 
 	if d.isSealed {
-		return Response{}, errors.New("vault is sealed")
+		return Response{}, ErrSealed
 	}
 
 	if _, ok := d.kv[mount]; !ok {
@@ -305,7 +534,7 @@ func (d *DVault) GetKVSecretByVersion(ctx context.Context, mount string, secretP
 	defer d.mu.RUnlock()
 
 	if d.isSealed {
-		return Response{}, errors.New("vault is sealed")
+		return Response{}, ErrSealed
 	}
 
 	if _, ok := d.kv[mount]; !ok {
@@ -330,7 +559,7 @@ func (d *DVault) SaveKVSecret(ctx context.Context, mount string, secretPath stri
 	defer d.mu.RUnlock()
 
 	if d.isSealed {
-		return Response{}, errors.New("vault is sealed")
+		return Response{}, ErrSealed
 	}
 
 	if _, ok := d.kv[mount]; !ok {
@@ -355,7 +584,7 @@ func (d *DVault) DeleteKVSecret(ctx context.Context, mount string, secretPath st
 	defer d.mu.RUnlock()
 
 	if d.isSealed {
-		return Response{}, errors.New("vault is sealed")
+		return Response{}, ErrSealed
 	}
 
 	if _, ok := d.kv[mount]; !ok {
@@ -379,7 +608,7 @@ func (d *DVault) UndeleteKVSecret(ctx context.Context, mount string, secretPath
 	defer d.mu.RUnlock()
 
 	if d.isSealed {
-		return Response{}, errors.New("vault is sealed")
+		return Response{}, ErrSealed
 	}
 
 	if _, ok := d.kv[mount]; !ok {
@@ -403,7 +632,7 @@ func (d *DVault) DeleteKVSecretByVersion(ctx context.Context, mount string, secr
 	defer d.mu.RUnlock()
 
 	if d.isSealed {
-		return Response{}, errors.New("vault is sealed")
+		return Response{}, ErrSealed
 	}
 
 	if _, ok := d.kv[mount]; !ok {
@@ -427,7 +656,7 @@ func (d *DVault) UndeleteKVSecretByVersion(ctx context.Context, mount string, se
 	defer d.mu.RUnlock()
 
 	if d.isSealed {
-		return Response{}, errors.New("vault is sealed")
+		return Response{}, ErrSealed
 	}
 
 	if _, ok := d.kv[mount]; !ok {
@@ -451,7 +680,7 @@ func (d *DVault) DestroyKVSecret(ctx context.Context, mount string, secretPath s
 	defer d.mu.RUnlock()
 
 	if d.isSealed {
-		return Response{}, errors.New("vault is sealed")
+		return Response{}, ErrSealed
 	}
 
 	if _, ok := d.kv[mount]; !ok {
@@ -475,7 +704,7 @@ func (d *DVault) UpdateKVConfig(ctx context.Context, mount string, config kv2.Co
 	defer d.mu.RUnlock()
 
 	if d.isSealed {
-		return Response{}, errors.New("vault is sealed")
+		return Response{}, ErrSealed
 	}
 
 	if _, ok := d.kv[mount]; !ok {
@@ -499,7 +728,7 @@ func (d *DVault) GetKVConfig(ctx context.Context, mount string) (Response, error
 	defer d.mu.RUnlock()
 
 	if d.isSealed {
-		return Response{}, errors.New("vault is sealed")
+		return Response{}, ErrSealed
 	}
 
 	if _, ok := d.kv[mount]; !ok {
@@ -524,7 +753,7 @@ func (d *DVault) GetKVMeta(ctx context.Context, mount string, secretPath string)
 	defer d.mu.RUnlock()
 
 	if d.isSealed {
-		return Response{}, errors.New("vault is sealed")
+		return Response{}, ErrSealed
 	}
 
 	if _, ok := d.kv[mount]; !ok {
@@ -549,7 +778,7 @@ func (d *DVault) UpdateKVMeta(ctx context.Context, mount string, secretPath stri
 	defer d.mu.RUnlock()
 
 	if d.isSealed {
-		return Response{}, errors.New("vault is sealed")
+		return Response{}, ErrSealed
 	}
 
 	if _, ok := d.kv[mount]; !ok {
@@ -573,7 +802,7 @@ func (d *DVault) DeleteKVMeta(ctx context.Context, mount string, secretPath stri
 	defer d.mu.RUnlock()
 
 	if d.isSealed {
-		return Response{}, errors.New("vault is sealed")
+		return Response{}, ErrSealed
 	}
 
 	if _, ok := d.kv[mount]; !ok {
@@ -597,7 +826,7 @@ func (d *DVault) CreateMount(_ context.Context, path string, mount CreateMount)
 	defer d.mu.Unlock()
 
 	if d.isSealed {
-		return Response{}, errors.New("vault is sealed")
+		return Response{}, ErrSealed
 	}
 
 	var response Response
@@ -608,11 +837,13 @@ func (d *DVault) CreateMount(_ context.Context, path string, mount CreateMount)
 	}
 
 	path = filepath.Clean(path)
-	if _, ok := d.kv[path]; ok {
+	if _, ok := d.kv[path]; ok || path == cubbyholeMount {
 		return response, errors.New("mount already exist")
 	}
 
 	switch mount.Type {
+	case cubbyholeMount:
+		return response, fmt.Errorf("%w: cubbyhole is mounted automatically at %s/", ErrMountNotAllowed, cubbyholeMount)
 	case "kv":
 		cfg, err := kv2.CreateConfigFromMap(mount.Config)
 		if err != nil {
@@ -633,111 +864,111 @@ func (d *DVault) CreateMount(_ context.Context, path string, mount CreateMount)
 	return response, nil
 }
 
-func (d *DVault) generateAndSaveEncryptKey(secret []byte, shares uint, threshold uint) (tools.Encryptor, error) {
-	encryptKey := make([]byte, 32)
-	_, err := rand.Read(encryptKey)
-	if err != nil {
-		return nil, err
+// DeleteMount unmounts the secrets engine at path and deletes its data. The
+// cubbyhole mount can not be removed.
+func (d *DVault) DeleteMount(ctx context.Context, path string) (Response, error) {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+
+	if d.isSealed {
+		return Response{}, ErrSealed
+	}
+
+	path = filepath.Clean(path)
+	if path == cubbyholeMount {
+		return Response{}, fmt.Errorf("%w: cubbyhole can not be unmounted", ErrMountNotAllowed)
+	}
+
+	if _, ok := d.kv[path]; !ok {
+		return Response{}, ErrMountNotFound
+	}
+
+	for _, p := range []string{filepath.Join(d.mountPath, path), filepath.Join(d.mountPath, "data", path)} {
+		if err := storage.DeleteTree(ctx, d.Storage, p); err != nil {
+			return Response{}, err
+		}
 	}
+	delete(d.kv, path)
+
+	var response Response
+	response.RequestId = tools.GenerateXRequestID()
 
-	encryptor, err := tools.NewEncryptor(d.encryptionMethod, secret)
+	return response, nil
+}
+
+func (d *DVault) generateAndSaveEncryptKey(secret []byte, shares uint, threshold uint) (*tools.Keyring, error) {
+	keyring, err := tools.NewKeyring(d.encryptionMethod)
 	if err != nil {
 		return nil, err
 	}
 
-	encryptedEncryptedKey, err := encryptor.Encrypt(encryptKey)
+	keyringData, err := keyring.MarshalBinary()
 	if err != nil {
 		return nil, err
 	}
 
-	encryptedEncryptedKeyBase64 := base64.StdEncoding.EncodeToString(encryptedEncryptedKey)
-	keyPath := filepath.Join(d.mountPath, "key")
-
-	err = os.WriteFile(keyPath, []byte(fmt.Sprintf("%s#%d#%d", encryptedEncryptedKeyBase64, shares, threshold)), 0600)
+	err = d.saveKey(secret, keyringData, shares, threshold)
 	if err != nil {
 		return nil, err
 	}
 
-	return encryptor, nil
+	return keyring, nil
 }
 
-func (d *DVault) tryUnseal(keysBase64Encoded []string) (tools.Encryptor, error) {
-	valueKeys := make([][]byte, len(keysBase64Encoded))
-	idKeys := make([][]byte, len(keysBase64Encoded))
-	for i := range valueKeys {
-		valueBase64, idBase64, ok := strings.Cut(keysBase64Encoded[i], "#")
-		if !ok {
-			return nil, errors.New("invalid share")
-		}
-
-		{
-			base64Text := make([]byte, base64.StdEncoding.DecodedLen(len(valueBase64)))
-
-			n, err := base64.StdEncoding.Decode(base64Text, []byte(valueBase64))
-			if err != nil {
-				return nil, err
-			}
+// saveKey writes the keyring, encrypted with the root key, and the share
+// configuration to the key file. The file is replaced atomically, so a crash
+// leaves either the old or the new key behind.
+func (d *DVault) saveKey(rootKey []byte, keyringData []byte, shares uint, threshold uint) error {
+	encryptor, err := tools.NewEncryptor(d.encryptionMethod, rootKey)
+	if err != nil {
+		return err
+	}
 
-			valueKeys[i] = base64Text[:n]
-		}
-		{
-			base64Text := make([]byte, base64.StdEncoding.DecodedLen(len(idBase64)))
+	encryptedEncryptedKey, err := encryptor.Encrypt(keyringData)
+	if err != nil {
+		return err
+	}
 
-			n, err := base64.StdEncoding.Decode(base64Text, []byte(idBase64))
-			if err != nil {
-				return nil, err
-			}
+	encryptedEncryptedKeyBase64 := base64.StdEncoding.EncodeToString(encryptedEncryptedKey)
 
-			idKeys[i] = base64Text[:n]
-		}
-	}
+	return d.writeFile("key", []byte(fmt.Sprintf("%s#%d#%d", encryptedEncryptedKeyBase64, shares, threshold)))
+}
 
-	var values []group.Scalar
-	for i := range valueKeys {
-		g := group.P256
-		scalar := g.NewScalar()
-		err := scalar.UnmarshalBinary(valueKeys[i])
-		if err != nil {
-			return nil, err
-		}
-		values = append(values, scalar)
+// writeFile replaces the file name in the mount path atomically.
+func (d *DVault) writeFile(name string, data []byte) error {
+	f, err := os.CreateTemp(d.mountPath, name+"-*.tmp")
+	if err != nil {
+		return err
 	}
+	defer os.Remove(f.Name())
 
-	var ids []group.Scalar
-	for i := range idKeys {
-		g := group.P256
-		scalar := g.NewScalar()
-		err := scalar.UnmarshalBinary(idKeys[i])
-		if err != nil {
-			return nil, err
-		}
-		ids = append(ids, scalar)
+	_, err = f.Write(data)
+	if err == nil {
+		err = f.Sync()
 	}
-
-	var shares []secretsharing.Share
-	for i := range valueKeys {
-		shares = append(shares, secretsharing.Share{
-			ID:    ids[i],
-			Value: values[i],
-		})
+	if closeErr := f.Close(); err == nil {
+		err = closeErr
 	}
-
-	secret, err := secretsharing.Recover(uint(d.T)-1, shares)
 	if err != nil {
-		return nil, err
+		return err
 	}
 
-	rootKey, err := secret.MarshalBinary()
-	if err != nil {
-		return nil, err
+	if err = os.Rename(f.Name(), filepath.Join(d.mountPath, name)); err != nil {
+		return err
 	}
 
-	encryptor, err := d.restoreKey(rootKey)
+	// The rename is only durable once the directory is synced.
+	dir, err := os.Open(d.mountPath)
 	if err != nil {
-		return nil, err
+		return err
 	}
+	defer dir.Close()
 
-	return encryptor, nil
+	return dir.Sync()
+}
+
+func (d *DVault) recoverRootKey(keys []string) ([]byte, error) {
+	return combineShares(keys, d.T)
 }
 
 func (d *DVault) tryInitVault() error {
@@ -769,10 +1000,21 @@ func (d *DVault) tryInitVault() error {
 	d.T = t
 	d.isInitialized = true
 
-	return nil
+	return d.loadSealState()
 }
 
-func (d *DVault) restoreKey(rootKey []byte) (tools.Encryptor, error) {
+func (d *DVault) restoreKey(rootKey []byte) (*tools.Keyring, error) {
+	keyringData, err := d.decryptKey(rootKey)
+	if err != nil {
+		return nil, err
+	}
+
+	return tools.ParseKeyring(d.encryptionMethod, keyringData)
+}
+
+// decryptKey reads the keyring from the key file. It fails when
+// rootKey is not the key it was encrypted with.
+func (d *DVault) decryptKey(rootKey []byte) ([]byte, error) {
 	keyPath := filepath.Join(d.mountPath, "key")
 	encryptionKeyBytes, err := os.ReadFile(keyPath)
 	if err != nil {
@@ -781,7 +1023,7 @@ func (d *DVault) restoreKey(rootKey []byte) (tools.Encryptor, error) {
 
 	base64Secret, _, ok := bytes.Cut(encryptionKeyBytes, []byte("#"))
 	if !ok {
-		return nil, err
+		return nil, errors.New("encryption key corrupted, try deleting key file and try again")
 	}
 
 	secret := make([]byte, base64.StdEncoding.DecodedLen(len(base64Secret)))
@@ -795,12 +1037,7 @@ func (d *DVault) restoreKey(rootKey []byte) (tools.Encryptor, error) {
 		return nil, err
 	}
 
-	encryptionKey, err := encryptor.Decrypt(secret[:n])
-	if err != nil {
-		return nil, err
-	}
-
-	return tools.NewEncryptor(d.encryptionMethod, encryptionKey)
+	return encryptor.Decrypt(secret[:n])
 }
 
 func (d *DVault) restoreKV(encryptor tools.Encryptor) error {
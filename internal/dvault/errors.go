@@ -0,0 +1,21 @@
+package dvault
+
+import "errors"
+
+var ErrSealed = errors.New("vault is sealed")
+var ErrPermissionDenied = errors.New("permission denied")
+var ErrAuthMethodNotFound = errors.New("no auth method mounted at path")
+var ErrInvalidAuthPath = errors.New("invalid auth method path")
+var ErrAuthPathInUse = errors.New("path is already in use")
+var ErrUnknownAuthType = errors.New("unknown auth method type")
+var ErrInvalidAuthConfig = errors.New("invalid auth method configuration")
+var ErrMountNotFound = errors.New("no secrets engine mounted at path")
+var ErrMountNotAllowed = errors.New("mount operation not allowed")
+var ErrInvalidWrapTTL = errors.New("invalid wrap TTL")
+var ErrInvalidWrappingToken = errors.New("wrapping token is not valid or does not exist")
+var ErrAlreadyUnsealed = errors.New("already unsealed")
+var ErrAlreadyInitialized = errors.New("already initialized")
+var ErrNotInitialized = errors.New("vault is not initialized")
+var ErrInvalidInit = errors.New("invalid init request")
+var ErrInvalidShare = errors.New("invalid unseal key share")
+var ErrMFARequired = errors.New("multi-factor authentication required")
@@ -0,0 +1,135 @@
+package dvault
+
+import (
+	"context"
+	"log/slog"
+	"sync"
+	"time"
+
+	"github.com/Burzich/dvault/internal/dvault/token"
+	"github.com/Burzich/dvault/internal/tools"
+)
+
+const defaultExpirationInterval = time.Minute
+
+// expirationManager periodically revokes expired tokens while the vault is
+// unsealed. It also owns on-demand tidy runs, so sealing stops both.
+type expirationManager struct {
+	logger *slog.Logger
+	tokens *token.Store
+
+	ctx    context.Context
+	cancel context.CancelFunc
+	wg     sync.WaitGroup
+
+	mu   sync.Mutex
+	tidy TidyStatus
+}
+
+func newExpirationManager(logger *slog.Logger, tokens *token.Store) *expirationManager {
+	ctx, cancel := context.WithCancel(context.Background())
+
+	return &expirationManager{
+		logger: logger,
+		tokens: tokens,
+		ctx:    ctx,
+		cancel: cancel,
+		tidy:   TidyStatus{State: "idle"},
+	}
+}
+
+func (m *expirationManager) start(interval time.Duration) {
+	m.wg.Add(1)
+	go func() {
+		defer m.wg.Done()
+
+		ticker := time.NewTicker(interval)
+		defer ticker.Stop()
+
+		for {
+			select {
+			case <-m.ctx.Done():
+				return
+			case <-ticker.C:
+				revoked, err := m.tokens.RevokeExpired(m.ctx)
+				if err != nil && m.ctx.Err() == nil {
+					m.logger.Error("revoke expired tokens", slog.String("error", err.Error()))
+				}
+				if revoked > 0 {
+					m.logger.Info("revoked expired tokens", slog.Int("count", revoked))
+				}
+			}
+		}
+	}()
+}
+
+func (m *expirationManager) stop() {
+	m.cancel()
+	m.wg.Wait()
+}
+
+// startTidy launches a tidy pass unless one is already running and reports
+// whether it did.
+func (m *expirationManager) startTidy() (TidyStatus, bool) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	if m.tidy.State == "running" {
+		return m.tidy, false
+	}
+
+	m.tidy = TidyStatus{State: "running", StartTime: time.Now()}
+
+	m.wg.Add(1)
+	go func() {
+		defer m.wg.Done()
+
+		result, err := m.tokens.Tidy(m.ctx)
+
+		m.mu.Lock()
+		defer m.mu.Unlock()
+
+		m.tidy.State = "idle"
+		m.tidy.EndTime = time.Now()
+		m.tidy.ExpiredTokens = result.Expired
+		m.tidy.DanglingAccessors = result.DanglingAccessors
+		m.tidy.InvalidParents = result.InvalidParents
+		if err != nil {
+			m.tidy.LastError = err.Error()
+			m.logger.Error("tidy tokens", slog.String("error", err.Error()))
+			return
+		}
+
+		m.logger.Info("tidy tokens finished",
+			slog.Int("expired", result.Expired),
+			slog.Int("dangling_accessors", result.DanglingAccessors),
+			slog.Int("invalid_parents", result.InvalidParents))
+	}()
+
+	return m.tidy, true
+}
+
+// TidyToken starts a tidy pass over the token store in the background. The
+// response carries the state of the current or last run.
+func (d *DVault) TidyToken(_ context.Context) (Response, error) {
+	d.mu.RLock()
+	defer d.mu.RUnlock()
+
+	if d.isSealed {
+		return Response{}, ErrSealed
+	}
+
+	status, started := d.expiration.startTidy()
+
+	var response Response
+	response.Data = status
+	if started {
+		response.Warnings = []string{"Tidy operation successfully started. Any information from the operation will be printed to dvault's server logs."}
+	} else {
+		response.Warnings = []string{"Tidy operation already in progress."}
+	}
+	response.MountType = "token"
+	response.RequestId = tools.GenerateXRequestID()
+
+	return response, nil
+}
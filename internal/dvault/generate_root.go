@@ -0,0 +1,135 @@
+package dvault
+
+import (
+	"context"
+	"encoding/base64"
+	"errors"
+
+	"github.com/Burzich/dvault/internal/dvault/token"
+	"github.com/Burzich/dvault/internal/tools"
+)
+
+var ErrGenerateRootNotStarted = errors.New("no root generation in progress")
+var ErrGenerateRootInProgress = errors.New("root generation already in progress")
+var ErrInvalidNonce = errors.New("invalid nonce")
+
+type generateRootAttempt struct {
+	nonce  string
+	otp    string
+	shares shareSet
+}
+
+func (d *DVault) GenerateRootStatus(_ context.Context) (GenerateRootStatus, error) {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+
+	return d.generateRootStatus(), nil
+}
+
+func (d *DVault) StartGenerateRoot(_ context.Context) (GenerateRootStatus, error) {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+
+	if d.isSealed {
+		return GenerateRootStatus{}, ErrSealed
+	}
+
+	if d.generateRoot != nil {
+		return GenerateRootStatus{}, ErrGenerateRootInProgress
+	}
+
+	otp, err := token.GenerateOTP()
+	if err != nil {
+		return GenerateRootStatus{}, err
+	}
+
+	d.generateRoot = &generateRootAttempt{
+		nonce: tools.GenerateXRequestID(),
+		otp:   otp,
+	}
+
+	status := d.generateRootStatus()
+	status.Otp = otp
+
+	return status, nil
+}
+
+func (d *DVault) CancelGenerateRoot(_ context.Context) error {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+
+	d.generateRoot = nil
+
+	return nil
+}
+
+func (d *DVault) UpdateGenerateRoot(ctx context.Context, update GenerateRootUpdate) (GenerateRootStatus, error) {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+
+	if d.isSealed {
+		return GenerateRootStatus{}, ErrSealed
+	}
+
+	if d.generateRoot == nil {
+		return GenerateRootStatus{}, ErrGenerateRootNotStarted
+	}
+
+	if update.Nonce != d.generateRoot.nonce {
+		return GenerateRootStatus{}, ErrInvalidNonce
+	}
+
+	if err := d.generateRoot.shares.add(update.Key); err != nil {
+		return GenerateRootStatus{}, err
+	}
+
+	if d.generateRoot.shares.len() < d.T {
+		return d.generateRootStatus(), nil
+	}
+
+	attempt := d.generateRoot
+	d.generateRoot = nil
+
+	if err := d.checkShares(attempt.shares.list()); err != nil {
+		return GenerateRootStatus{}, err
+	}
+
+	entry, err := d.tokens.Create(ctx, rootTokenEntry())
+	if err != nil {
+		return GenerateRootStatus{}, err
+	}
+
+	encoded := make([]byte, len(entry.ID))
+	for i := range encoded {
+		encoded[i] = entry.ID[i] ^ attempt.otp[i]
+	}
+	encodedToken := base64.RawStdEncoding.EncodeToString(encoded)
+
+	return GenerateRootStatus{
+		Started:          true,
+		Nonce:            attempt.nonce,
+		Progress:         attempt.shares.len(),
+		Required:         d.T,
+		Complete:         true,
+		EncodedToken:     encodedToken,
+		EncodedRootToken: encodedToken,
+		OtpLength:        len(attempt.otp),
+	}, nil
+}
+
+func (d *DVault) generateRootStatus() GenerateRootStatus {
+	if d.generateRoot == nil {
+		return GenerateRootStatus{
+			Required:  d.T,
+			OtpLength: len(token.ServicePrefix) + token.IDLength,
+		}
+	}
+
+	return GenerateRootStatus{
+		Started:   true,
+		Nonce:     d.generateRoot.nonce,
+		Progress:  d.generateRoot.shares.len(),
+		Required:  d.T,
+		OtpLength: len(d.generateRoot.otp),
+	}
+}
@@ -0,0 +1,310 @@
+package handler
+
+import (
+	"encoding/json"
+	"errors"
+	"io"
+	"net/http"
+	"time"
+
+	"github.com/Burzich/dvault/internal/dvault"
+	"github.com/go-chi/chi/v5"
+)
+
+func (h Handler) ListAppRoles(w http.ResponseWriter, r *http.Request) {
+	mount := chi.URLParam(r, "auth_mount")
+
+	response, err := h.dVault.ListAppRoles(r.Context(), mount)
+	if err != nil {
+		h.handleError(w, r, err)
+		return
+	}
+
+	if err := json.NewEncoder(w).Encode(response); err != nil {
+		w.WriteHeader(http.StatusInternalServerError)
+		return
+	}
+}
+
+func (h Handler) GetAppRole(w http.ResponseWriter, r *http.Request) {
+	mount := chi.URLParam(r, "auth_mount")
+	roleName := chi.URLParam(r, "role_name")
+
+	response, err := h.dVault.GetAppRole(r.Context(), mount, roleName)
+	if err != nil {
+		h.handleError(w, r, err)
+		return
+	}
+
+	if err := json.NewEncoder(w).Encode(response); err != nil {
+		w.WriteHeader(http.StatusInternalServerError)
+		return
+	}
+}
+
+func (h Handler) SaveAppRole(w http.ResponseWriter, r *http.Request) {
+	mount := chi.URLParam(r, "auth_mount")
+	roleName := chi.URLParam(r, "role_name")
+
+	var appRole AppRoleRequest
+	if err := json.NewDecoder(r.Body).Decode(&appRole); err != nil && !errors.Is(err, io.EOF) {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	response, err := h.dVault.SaveAppRole(r.Context(), mount, roleName, dvault.AppRole{
+		BindSecretID:       appRole.BindSecretID,
+		SecretIDBoundCIDRs: appRole.SecretIDBoundCIDRs,
+		SecretIDNumUses:    appRole.SecretIDNumUses,
+		SecretIDTTL:        time.Duration(appRole.SecretIDTTL),
+		TokenParams:        appRole.tokenParams(),
+	})
+	if err != nil {
+		h.handleError(w, r, err)
+		return
+	}
+
+	if err := json.NewEncoder(w).Encode(response); err != nil {
+		w.WriteHeader(http.StatusInternalServerError)
+		return
+	}
+}
+
+func (h Handler) DeleteAppRole(w http.ResponseWriter, r *http.Request) {
+	mount := chi.URLParam(r, "auth_mount")
+	roleName := chi.URLParam(r, "role_name")
+
+	response, err := h.dVault.DeleteAppRole(r.Context(), mount, roleName)
+	if err != nil {
+		h.handleError(w, r, err)
+		return
+	}
+
+	if err := json.NewEncoder(w).Encode(response); err != nil {
+		w.WriteHeader(http.StatusInternalServerError)
+		return
+	}
+}
+
+func (h Handler) GetAppRoleRoleID(w http.ResponseWriter, r *http.Request) {
+	mount := chi.URLParam(r, "auth_mount")
+	roleName := chi.URLParam(r, "role_name")
+
+	response, err := h.dVault.GetAppRoleRoleID(r.Context(), mount, roleName)
+	if err != nil {
+		h.handleError(w, r, err)
+		return
+	}
+
+	if err := json.NewEncoder(w).Encode(response); err != nil {
+		w.WriteHeader(http.StatusInternalServerError)
+		return
+	}
+}
+
+func (h Handler) SaveAppRoleRoleID(w http.ResponseWriter, r *http.Request) {
+	mount := chi.URLParam(r, "auth_mount")
+	roleName := chi.URLParam(r, "role_name")
+
+	var roleID AppRoleRoleIDRequest
+	if err := json.NewDecoder(r.Body).Decode(&roleID); err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	response, err := h.dVault.SaveAppRoleRoleID(r.Context(), mount, roleName, roleID.RoleID)
+	if err != nil {
+		h.handleError(w, r, err)
+		return
+	}
+
+	if err := json.NewEncoder(w).Encode(response); err != nil {
+		w.WriteHeader(http.StatusInternalServerError)
+		return
+	}
+}
+
+func (h Handler) GenerateAppRoleSecretID(w http.ResponseWriter, r *http.Request) {
+	h.createAppRoleSecretID(w, r, false)
+}
+
+func (h Handler) CreateAppRoleCustomSecretID(w http.ResponseWriter, r *http.Request) {
+	h.createAppRoleSecretID(w, r, true)
+}
+
+func (h Handler) createAppRoleSecretID(w http.ResponseWriter, r *http.Request, custom bool) {
+	mount := chi.URLParam(r, "auth_mount")
+	roleName := chi.URLParam(r, "role_name")
+
+	var secretID AppRoleSecretIDRequest
+	if err := json.NewDecoder(r.Body).Decode(&secretID); err != nil && !errors.Is(err, io.EOF) {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	if !custom {
+		secretID.SecretID = ""
+	} else if secretID.SecretID == "" {
+		http.Error(w, "missing secret_id", http.StatusBadRequest)
+		return
+	}
+
+	response, err := h.dVault.GenerateAppRoleSecretID(r.Context(), mount, roleName, dvault.AppRoleSecretID{
+		SecretID:        secretID.SecretID,
+		Metadata:        secretID.Metadata,
+		CIDRList:        secretID.CIDRList,
+		TokenBoundCIDRs: secretID.TokenBoundCIDRs,
+		NumUses:         secretID.NumUses,
+		TTL:             time.Duration(secretID.TTL),
+	})
+	if err != nil {
+		h.handleError(w, r, err)
+		return
+	}
+
+	if err := json.NewEncoder(w).Encode(response); err != nil {
+		w.WriteHeader(http.StatusInternalServerError)
+		return
+	}
+}
+
+func (h Handler) ListAppRoleSecretIDAccessors(w http.ResponseWriter, r *http.Request) {
+	mount := chi.URLParam(r, "auth_mount")
+	roleName := chi.URLParam(r, "role_name")
+
+	response, err := h.dVault.ListAppRoleSecretIDAccessors(r.Context(), mount, roleName)
+	if err != nil {
+		h.handleError(w, r, err)
+		return
+	}
+
+	if err := json.NewEncoder(w).Encode(response); err != nil {
+		w.WriteHeader(http.StatusInternalServerError)
+		return
+	}
+}
+
+func (h Handler) LookupAppRoleSecretID(w http.ResponseWriter, r *http.Request) {
+	mount := chi.URLParam(r, "auth_mount")
+	roleName := chi.URLParam(r, "role_name")
+
+	var secretID AppRoleSecretIDRequest
+	if err := json.NewDecoder(r.Body).Decode(&secretID); err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	response, err := h.dVault.LookupAppRoleSecretID(r.Context(), mount, roleName, secretID.SecretID)
+	if err != nil {
+		h.handleError(w, r, err)
+		return
+	}
+
+	if err := json.NewEncoder(w).Encode(response); err != nil {
+		w.WriteHeader(http.StatusInternalServerError)
+		return
+	}
+}
+
+func (h Handler) DestroyAppRoleSecretID(w http.ResponseWriter, r *http.Request) {
+	mount := chi.URLParam(r, "auth_mount")
+	roleName := chi.URLParam(r, "role_name")
+
+	var secretID AppRoleSecretIDRequest
+	if err := json.NewDecoder(r.Body).Decode(&secretID); err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	response, err := h.dVault.DestroyAppRoleSecretID(r.Context(), mount, roleName, secretID.SecretID)
+	if err != nil {
+		h.handleError(w, r, err)
+		return
+	}
+
+	if err := json.NewEncoder(w).Encode(response); err != nil {
+		w.WriteHeader(http.StatusInternalServerError)
+		return
+	}
+}
+
+func (h Handler) LookupAppRoleSecretIDAccessor(w http.ResponseWriter, r *http.Request) {
+	mount := chi.URLParam(r, "auth_mount")
+	roleName := chi.URLParam(r, "role_name")
+
+	var accessor AppRoleSecretIDAccessorRequest
+	if err := json.NewDecoder(r.Body).Decode(&accessor); err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	response, err := h.dVault.LookupAppRoleSecretIDAccessor(r.Context(), mount, roleName, accessor.SecretIDAccessor)
+	if err != nil {
+		h.handleError(w, r, err)
+		return
+	}
+
+	if err := json.NewEncoder(w).Encode(response); err != nil {
+		w.WriteHeader(http.StatusInternalServerError)
+		return
+	}
+}
+
+func (h Handler) DestroyAppRoleSecretIDAccessor(w http.ResponseWriter, r *http.Request) {
+	mount := chi.URLParam(r, "auth_mount")
+	roleName := chi.URLParam(r, "role_name")
+
+	var accessor AppRoleSecretIDAccessorRequest
+	if err := json.NewDecoder(r.Body).Decode(&accessor); err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	response, err := h.dVault.DestroyAppRoleSecretIDAccessor(r.Context(), mount, roleName, accessor.SecretIDAccessor)
+	if err != nil {
+		h.handleError(w, r, err)
+		return
+	}
+
+	if err := json.NewEncoder(w).Encode(response); err != nil {
+		w.WriteHeader(http.StatusInternalServerError)
+		return
+	}
+}
+
+func (h Handler) TidyAppRoleSecretIDs(w http.ResponseWriter, r *http.Request) {
+	mount := chi.URLParam(r, "auth_mount")
+
+	response, err := h.dVault.TidyAppRoleSecretIDs(r.Context(), mount)
+	if err != nil {
+		h.handleError(w, r, err)
+		return
+	}
+
+	w.WriteHeader(http.StatusAccepted)
+	if err := json.NewEncoder(w).Encode(response); err != nil {
+		w.WriteHeader(http.StatusInternalServerError)
+		return
+	}
+}
+
+func (h Handler) LoginAppRole(w http.ResponseWriter, r *http.Request) {
+	mount := chi.URLParam(r, "auth_mount")
+
+	var login AppRoleLoginRequest
+	if err := json.NewDecoder(r.Body).Decode(&login); err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	response, err := h.dVault.LoginAppRole(r.Context(), mount, login.RoleID, login.SecretID, dvault.ClientIP(r.Context()))
+	if err != nil {
+		h.handleError(w, r, err)
+		return
+	}
+
+	if err := json.NewEncoder(w).Encode(response); err != nil {
+		w.WriteHeader(http.StatusInternalServerError)
+		return
+	}
+}
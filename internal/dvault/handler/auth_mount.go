@@ -0,0 +1,132 @@
+package handler
+
+import (
+	"encoding/json"
+	"net/http"
+	"time"
+
+	"github.com/Burzich/dvault/internal/dvault"
+	"github.com/go-chi/chi/v5"
+)
+
+func (h Handler) ListAuthMethods(w http.ResponseWriter, r *http.Request) {
+	response, err := h.dVault.ListAuthMethods(r.Context())
+	if err != nil {
+		h.handleError(w, r, err)
+		return
+	}
+
+	if err := json.NewEncoder(w).Encode(response); err != nil {
+		w.WriteHeader(http.StatusInternalServerError)
+		return
+	}
+}
+
+func (h Handler) GetAuthMethod(w http.ResponseWriter, r *http.Request) {
+	path := chi.URLParam(r, "path")
+
+	response, err := h.dVault.GetAuthMethod(r.Context(), path)
+	if err != nil {
+		h.handleError(w, r, err)
+		return
+	}
+
+	if err := json.NewEncoder(w).Encode(response); err != nil {
+		w.WriteHeader(http.StatusInternalServerError)
+		return
+	}
+}
+
+func (h Handler) EnableAuthMethod(w http.ResponseWriter, r *http.Request) {
+	path := chi.URLParam(r, "path")
+
+	var enable EnableAuthRequest
+	if err := json.NewDecoder(r.Body).Decode(&enable); err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	response, err := h.dVault.EnableAuthMethod(r.Context(), path, dvault.EnableAuth{
+		Type:            enable.Type,
+		Description:     enable.Description,
+		DefaultLeaseTTL: time.Duration(enable.Config.DefaultLeaseTTL),
+		MaxLeaseTTL:     time.Duration(enable.Config.MaxLeaseTTL),
+		Options:         enable.Options,
+		Local:           enable.Local,
+		SealWrap:        enable.SealWrap,
+	})
+	if err != nil {
+		h.handleError(w, r, err)
+		return
+	}
+
+	if err := json.NewEncoder(w).Encode(response); err != nil {
+		w.WriteHeader(http.StatusInternalServerError)
+		return
+	}
+}
+
+func (h Handler) DisableAuthMethod(w http.ResponseWriter, r *http.Request) {
+	path := chi.URLParam(r, "path")
+
+	response, err := h.dVault.DisableAuthMethod(r.Context(), path)
+	if err != nil {
+		h.handleError(w, r, err)
+		return
+	}
+
+	if err := json.NewEncoder(w).Encode(response); err != nil {
+		w.WriteHeader(http.StatusInternalServerError)
+		return
+	}
+}
+
+func (h Handler) GetAuthMethodTune(w http.ResponseWriter, r *http.Request) {
+	path := chi.URLParam(r, "path")
+
+	response, err := h.dVault.GetAuthMethodTune(r.Context(), path)
+	if err != nil {
+		h.handleError(w, r, err)
+		return
+	}
+
+	if err := json.NewEncoder(w).Encode(response); err != nil {
+		w.WriteHeader(http.StatusInternalServerError)
+		return
+	}
+}
+
+func (h Handler) TuneAuthMethod(w http.ResponseWriter, r *http.Request) {
+	path := chi.URLParam(r, "path")
+
+	var tune TuneAuthRequest
+	if err := json.NewDecoder(r.Body).Decode(&tune); err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	var defaultLeaseTTL, maxLeaseTTL *time.Duration
+	if tune.DefaultLeaseTTL != nil {
+		ttl := time.Duration(*tune.DefaultLeaseTTL)
+		defaultLeaseTTL = &ttl
+	}
+	if tune.MaxLeaseTTL != nil {
+		ttl := time.Duration(*tune.MaxLeaseTTL)
+		maxLeaseTTL = &ttl
+	}
+
+	response, err := h.dVault.TuneAuthMethod(r.Context(), path, dvault.TuneAuth{
+		DefaultLeaseTTL: defaultLeaseTTL,
+		MaxLeaseTTL:     maxLeaseTTL,
+		Description:     tune.Description,
+	})
+	if err != nil {
+		h.handleError(w, r, err)
+		return
+	}
+
+	if err := json.NewEncoder(w).Encode(response); err != nil {
+		w.WriteHeader(http.StatusInternalServerError)
+		return
+	}
+}
@@ -0,0 +1,116 @@
+package handler
+
+import (
+	"crypto/x509"
+	"encoding/json"
+	"errors"
+	"io"
+	"net/http"
+
+	"github.com/Burzich/dvault/internal/dvault"
+	"github.com/go-chi/chi/v5"
+)
+
+func (h Handler) ListCertRoles(w http.ResponseWriter, r *http.Request) {
+	mount := chi.URLParam(r, "auth_mount")
+
+	response, err := h.dVault.ListCertRoles(r.Context(), mount)
+	if err != nil {
+		h.handleError(w, r, err)
+		return
+	}
+
+	if err := json.NewEncoder(w).Encode(response); err != nil {
+		w.WriteHeader(http.StatusInternalServerError)
+		return
+	}
+}
+
+func (h Handler) GetCertRole(w http.ResponseWriter, r *http.Request) {
+	mount := chi.URLParam(r, "auth_mount")
+	name := chi.URLParam(r, "name")
+
+	response, err := h.dVault.GetCertRole(r.Context(), mount, name)
+	if err != nil {
+		h.handleError(w, r, err)
+		return
+	}
+
+	if err := json.NewEncoder(w).Encode(response); err != nil {
+		w.WriteHeader(http.StatusInternalServerError)
+		return
+	}
+}
+
+func (h Handler) SaveCertRole(w http.ResponseWriter, r *http.Request) {
+	mount := chi.URLParam(r, "auth_mount")
+	name := chi.URLParam(r, "name")
+
+	var role CertRoleRequest
+	if err := json.NewDecoder(r.Body).Decode(&role); err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	response, err := h.dVault.SaveCertRole(r.Context(), mount, name, dvault.CertRole{
+		Certificate:                role.Certificate,
+		DisplayName:                role.DisplayName,
+		AllowedCommonNames:         role.AllowedCommonNames,
+		AllowedDNSSANs:             role.AllowedDNSSANs,
+		AllowedOrganizationalUnits: role.AllowedOrganizationalUnits,
+		TokenParams:                role.tokenParams(),
+	})
+	if err != nil {
+		h.handleError(w, r, err)
+		return
+	}
+
+	if err := json.NewEncoder(w).Encode(response); err != nil {
+		w.WriteHeader(http.StatusInternalServerError)
+		return
+	}
+}
+
+func (h Handler) DeleteCertRole(w http.ResponseWriter, r *http.Request) {
+	mount := chi.URLParam(r, "auth_mount")
+	name := chi.URLParam(r, "name")
+
+	response, err := h.dVault.DeleteCertRole(r.Context(), mount, name)
+	if err != nil {
+		h.handleError(w, r, err)
+		return
+	}
+
+	if err := json.NewEncoder(w).Encode(response); err != nil {
+		w.WriteHeader(http.StatusInternalServerError)
+		return
+	}
+}
+
+// LoginCert authenticates with the client certificate of the TLS connection.
+// The body is optional and may name the role to check against.
+func (h Handler) LoginCert(w http.ResponseWriter, r *http.Request) {
+	mount := chi.URLParam(r, "auth_mount")
+
+	var login CertLoginRequest
+	if err := json.NewDecoder(r.Body).Decode(&login); err != nil && !errors.Is(err, io.EOF) {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	var chain []*x509.Certificate
+	if r.TLS != nil {
+		chain = r.TLS.PeerCertificates
+	}
+
+	response, err := h.dVault.LoginCert(r.Context(), mount, login.Name, chain)
+	if err != nil {
+		h.handleError(w, r, err)
+		return
+	}
+
+	if err := json.NewEncoder(w).Encode(response); err != nil {
+		w.WriteHeader(http.StatusInternalServerError)
+		return
+	}
+}
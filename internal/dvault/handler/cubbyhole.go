@@ -0,0 +1,66 @@
+package handler
+
+import (
+	"encoding/json"
+	"net/http"
+
+	"github.com/go-chi/chi/v5"
+)
+
+// GetCubbyholeSecret reads a secret of the cubbyhole of the calling token, or
+// lists the keys below the path for list=true.
+func (h Handler) GetCubbyholeSecret(w http.ResponseWriter, r *http.Request) {
+	secretPath := chi.URLParam(r, "*")
+
+	read := h.dVault.GetCubbyholeSecret
+	if r.URL.Query().Get("list") == "true" {
+		read = h.dVault.ListCubbyholeSecrets
+	}
+
+	response, err := read(r.Context(), secretPath)
+	if err != nil {
+		h.handleError(w, r, err)
+		return
+	}
+
+	if err := json.NewEncoder(w).Encode(response); err != nil {
+		w.WriteHeader(http.StatusInternalServerError)
+		return
+	}
+}
+
+func (h Handler) SaveCubbyholeSecret(w http.ResponseWriter, r *http.Request) {
+	secretPath := chi.URLParam(r, "*")
+
+	var data map[string]interface{}
+	if err := json.NewDecoder(r.Body).Decode(&data); err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	response, err := h.dVault.SaveCubbyholeSecret(r.Context(), secretPath, data)
+	if err != nil {
+		h.handleError(w, r, err)
+		return
+	}
+
+	if err := json.NewEncoder(w).Encode(response); err != nil {
+		w.WriteHeader(http.StatusInternalServerError)
+		return
+	}
+}
+
+func (h Handler) DeleteCubbyholeSecret(w http.ResponseWriter, r *http.Request) {
+	secretPath := chi.URLParam(r, "*")
+
+	response, err := h.dVault.DeleteCubbyholeSecret(r.Context(), secretPath)
+	if err != nil {
+		h.handleError(w, r, err)
+		return
+	}
+
+	if err := json.NewEncoder(w).Encode(response); err != nil {
+		w.WriteHeader(http.StatusInternalServerError)
+		return
+	}
+}
@@ -6,9 +6,20 @@ import (
 	"io"
 	"net/http"
 	"strconv"
+	"time"
 
 	"github.com/Burzich/dvault/internal/dvault"
+	"github.com/Burzich/dvault/internal/dvault/auth"
+	"github.com/Burzich/dvault/internal/dvault/auth/approle"
+	"github.com/Burzich/dvault/internal/dvault/auth/jwt"
+	"github.com/Burzich/dvault/internal/dvault/auth/userpass"
+	"github.com/Burzich/dvault/internal/dvault/cubbyhole"
+	"github.com/Burzich/dvault/internal/dvault/identity"
 	"github.com/Burzich/dvault/internal/dvault/kv"
+	"github.com/Burzich/dvault/internal/dvault/mfa"
+	"github.com/Burzich/dvault/internal/dvault/policy"
+	"github.com/Burzich/dvault/internal/dvault/recipient"
+	"github.com/Burzich/dvault/internal/dvault/token"
 	"github.com/go-chi/chi/v5"
 )
 
@@ -243,75 +254,370 @@ func (h Handler) CreateKVSubkeys(w http.ResponseWriter, r *http.Request) {
 }
 
 func (h Handler) GetTokenAccessors(w http.ResponseWriter, r *http.Request) {
-	http.Error(w, http.StatusText(http.StatusNotImplemented), http.StatusNotImplemented)
+	response, err := h.dVault.ListTokenAccessors(r.Context())
+	if err != nil {
+		h.handleError(w, r, err)
+		return
+	}
+
+	if err := json.NewEncoder(w).Encode(response); err != nil {
+		w.WriteHeader(http.StatusInternalServerError)
+		return
+	}
 }
 
 func (h Handler) CreateToken(w http.ResponseWriter, r *http.Request) {
-	http.Error(w, http.StatusText(http.StatusNotImplemented), http.StatusNotImplemented)
+	var createToken CreateTokenRequest
+	if err := json.NewDecoder(r.Body).Decode(&createToken); err != nil && !errors.Is(err, io.EOF) {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	response, err := h.dVault.CreateToken(r.Context(), dvault.CreateToken{
+		Policies:        createToken.Policies,
+		Meta:            createToken.Meta,
+		NoDefaultPolicy: createToken.NoDefaultPolicy,
+		NoParent:        createToken.NoParent,
+		Renewable:       createToken.Renewable,
+		TTL:             time.Duration(createToken.TTL),
+		ExplicitMaxTTL:  time.Duration(createToken.ExplicitMaxTTL),
+		DisplayName:     createToken.DisplayName,
+		NumUses:         createToken.NumUses,
+		Type:            createToken.Type,
+	})
+	if err != nil {
+		h.handleError(w, r, err)
+		return
+	}
+
+	if err := json.NewEncoder(w).Encode(response); err != nil {
+		w.WriteHeader(http.StatusInternalServerError)
+		return
+	}
 }
 
 func (h Handler) CreateOrphanToken(w http.ResponseWriter, r *http.Request) {
-	http.Error(w, http.StatusText(http.StatusNotImplemented), http.StatusNotImplemented)
+	var createToken CreateTokenRequest
+	if err := json.NewDecoder(r.Body).Decode(&createToken); err != nil && !errors.Is(err, io.EOF) {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	response, err := h.dVault.CreateOrphanToken(r.Context(), dvault.CreateToken{
+		Policies:        createToken.Policies,
+		Meta:            createToken.Meta,
+		NoDefaultPolicy: createToken.NoDefaultPolicy,
+		Renewable:       createToken.Renewable,
+		TTL:             time.Duration(createToken.TTL),
+		ExplicitMaxTTL:  time.Duration(createToken.ExplicitMaxTTL),
+		DisplayName:     createToken.DisplayName,
+		NumUses:         createToken.NumUses,
+		Type:            createToken.Type,
+	})
+	if err != nil {
+		h.handleError(w, r, err)
+		return
+	}
+
+	if err := json.NewEncoder(w).Encode(response); err != nil {
+		w.WriteHeader(http.StatusInternalServerError)
+		return
+	}
 }
 
 func (h Handler) CreateRoleToken(w http.ResponseWriter, r *http.Request) {
-	http.Error(w, http.StatusText(http.StatusNotImplemented), http.StatusNotImplemented)
+	roleName := chi.URLParam(r, "role_name")
+
+	var createToken CreateTokenRequest
+	if err := json.NewDecoder(r.Body).Decode(&createToken); err != nil && !errors.Is(err, io.EOF) {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	response, err := h.dVault.CreateRoleToken(r.Context(), roleName, dvault.CreateToken{
+		Policies:        createToken.Policies,
+		Meta:            createToken.Meta,
+		NoDefaultPolicy: createToken.NoDefaultPolicy,
+		Renewable:       createToken.Renewable,
+		TTL:             time.Duration(createToken.TTL),
+		ExplicitMaxTTL:  time.Duration(createToken.ExplicitMaxTTL),
+		DisplayName:     createToken.DisplayName,
+		NumUses:         createToken.NumUses,
+	})
+	if err != nil {
+		h.handleError(w, r, err)
+		return
+	}
+
+	if err := json.NewEncoder(w).Encode(response); err != nil {
+		w.WriteHeader(http.StatusInternalServerError)
+		return
+	}
 }
 
 func (h Handler) LookupToken(w http.ResponseWriter, r *http.Request) {
-	http.Error(w, http.StatusText(http.StatusNotImplemented), http.StatusNotImplemented)
+	var lookupToken TokenRequest
+	if err := json.NewDecoder(r.Body).Decode(&lookupToken); err != nil && !errors.Is(err, io.EOF) {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	response, err := h.dVault.LookupToken(r.Context(), lookupToken.Token)
+	if err != nil {
+		h.handleError(w, r, err)
+		return
+	}
+
+	if err := json.NewEncoder(w).Encode(response); err != nil {
+		w.WriteHeader(http.StatusInternalServerError)
+		return
+	}
 }
 
 func (h Handler) LookupSelfToken(w http.ResponseWriter, r *http.Request) {
-	http.Error(w, http.StatusText(http.StatusNotImplemented), http.StatusNotImplemented)
+	response, err := h.dVault.LookupSelfToken(r.Context())
+	if err != nil {
+		h.handleError(w, r, err)
+		return
+	}
+
+	if err := json.NewEncoder(w).Encode(response); err != nil {
+		w.WriteHeader(http.StatusInternalServerError)
+		return
+	}
+}
+
+func (h Handler) LookupAccessorToken(w http.ResponseWriter, r *http.Request) {
+	var lookupAccessor AccessorRequest
+	if err := json.NewDecoder(r.Body).Decode(&lookupAccessor); err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	response, err := h.dVault.LookupAccessorToken(r.Context(), lookupAccessor.Accessor)
+	if err != nil {
+		h.handleError(w, r, err)
+		return
+	}
+
+	if err := json.NewEncoder(w).Encode(response); err != nil {
+		w.WriteHeader(http.StatusInternalServerError)
+		return
+	}
 }
 
 func (h Handler) RenewToken(w http.ResponseWriter, r *http.Request) {
-	http.Error(w, http.StatusText(http.StatusNotImplemented), http.StatusNotImplemented)
+	var renewToken RenewTokenRequest
+	if err := json.NewDecoder(r.Body).Decode(&renewToken); err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	response, err := h.dVault.RenewToken(r.Context(), renewToken.Token, time.Duration(renewToken.Increment))
+	if err != nil {
+		h.handleError(w, r, err)
+		return
+	}
+
+	if err := json.NewEncoder(w).Encode(response); err != nil {
+		w.WriteHeader(http.StatusInternalServerError)
+		return
+	}
 }
 
 func (h Handler) RenewAccessorToken(w http.ResponseWriter, r *http.Request) {
-	http.Error(w, http.StatusText(http.StatusNotImplemented), http.StatusNotImplemented)
+	var renewAccessor RenewAccessorRequest
+	if err := json.NewDecoder(r.Body).Decode(&renewAccessor); err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	response, err := h.dVault.RenewAccessorToken(r.Context(), renewAccessor.Accessor, time.Duration(renewAccessor.Increment))
+	if err != nil {
+		h.handleError(w, r, err)
+		return
+	}
+
+	if err := json.NewEncoder(w).Encode(response); err != nil {
+		w.WriteHeader(http.StatusInternalServerError)
+		return
+	}
 }
 
 func (h Handler) RenewSelfToken(w http.ResponseWriter, r *http.Request) {
-	http.Error(w, http.StatusText(http.StatusNotImplemented), http.StatusNotImplemented)
+	var renewToken RenewTokenRequest
+	if err := json.NewDecoder(r.Body).Decode(&renewToken); err != nil && !errors.Is(err, io.EOF) {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	response, err := h.dVault.RenewSelfToken(r.Context(), time.Duration(renewToken.Increment))
+	if err != nil {
+		h.handleError(w, r, err)
+		return
+	}
+
+	if err := json.NewEncoder(w).Encode(response); err != nil {
+		w.WriteHeader(http.StatusInternalServerError)
+		return
+	}
 }
 
 func (h Handler) RevokeToken(w http.ResponseWriter, r *http.Request) {
-	http.Error(w, http.StatusText(http.StatusNotImplemented), http.StatusNotImplemented)
+	var revokeToken TokenRequest
+	if err := json.NewDecoder(r.Body).Decode(&revokeToken); err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	response, err := h.dVault.RevokeToken(r.Context(), revokeToken.Token)
+	if err != nil {
+		h.handleError(w, r, err)
+		return
+	}
+
+	if err := json.NewEncoder(w).Encode(response); err != nil {
+		w.WriteHeader(http.StatusInternalServerError)
+		return
+	}
 }
 
 func (h Handler) RevokeAccessorToken(w http.ResponseWriter, r *http.Request) {
-	http.Error(w, http.StatusText(http.StatusNotImplemented), http.StatusNotImplemented)
+	var revokeAccessor AccessorRequest
+	if err := json.NewDecoder(r.Body).Decode(&revokeAccessor); err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	response, err := h.dVault.RevokeAccessorToken(r.Context(), revokeAccessor.Accessor)
+	if err != nil {
+		h.handleError(w, r, err)
+		return
+	}
+
+	if err := json.NewEncoder(w).Encode(response); err != nil {
+		w.WriteHeader(http.StatusInternalServerError)
+		return
+	}
 }
 
 func (h Handler) RevokeOrphanToken(w http.ResponseWriter, r *http.Request) {
-	http.Error(w, http.StatusText(http.StatusNotImplemented), http.StatusNotImplemented)
+	var revokeToken TokenRequest
+	if err := json.NewDecoder(r.Body).Decode(&revokeToken); err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	response, err := h.dVault.RevokeOrphanToken(r.Context(), revokeToken.Token)
+	if err != nil {
+		h.handleError(w, r, err)
+		return
+	}
+
+	if err := json.NewEncoder(w).Encode(response); err != nil {
+		w.WriteHeader(http.StatusInternalServerError)
+		return
+	}
 }
 
 func (h Handler) RevokeSelfToken(w http.ResponseWriter, r *http.Request) {
-	http.Error(w, http.StatusText(http.StatusNotImplemented), http.StatusNotImplemented)
+	response, err := h.dVault.RevokeSelfToken(r.Context())
+	if err != nil {
+		h.handleError(w, r, err)
+		return
+	}
+
+	if err := json.NewEncoder(w).Encode(response); err != nil {
+		w.WriteHeader(http.StatusInternalServerError)
+		return
+	}
 }
 
 func (h Handler) GetRolesToken(w http.ResponseWriter, r *http.Request) {
-	http.Error(w, http.StatusText(http.StatusNotImplemented), http.StatusNotImplemented)
+	response, err := h.dVault.ListTokenRoles(r.Context())
+	if err != nil {
+		h.handleError(w, r, err)
+		return
+	}
+
+	if err := json.NewEncoder(w).Encode(response); err != nil {
+		w.WriteHeader(http.StatusInternalServerError)
+		return
+	}
 }
 
 func (h Handler) GetRoleByNameToken(w http.ResponseWriter, r *http.Request) {
-	http.Error(w, http.StatusText(http.StatusNotImplemented), http.StatusNotImplemented)
+	roleName := chi.URLParam(r, "role_name")
+
+	response, err := h.dVault.GetTokenRole(r.Context(), roleName)
+	if err != nil {
+		h.handleError(w, r, err)
+		return
+	}
+
+	if err := json.NewEncoder(w).Encode(response); err != nil {
+		w.WriteHeader(http.StatusInternalServerError)
+		return
+	}
 }
 
 func (h Handler) CreateRoleByNameToken(w http.ResponseWriter, r *http.Request) {
-	http.Error(w, http.StatusText(http.StatusNotImplemented), http.StatusNotImplemented)
+	roleName := chi.URLParam(r, "role_name")
+
+	var tokenRole TokenRoleRequest
+	if err := json.NewDecoder(r.Body).Decode(&tokenRole); err != nil && !errors.Is(err, io.EOF) {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	response, err := h.dVault.SaveTokenRole(r.Context(), roleName, dvault.TokenRole{
+		AllowedPolicies:     tokenRole.AllowedPolicies,
+		DisallowedPolicies:  tokenRole.DisallowedPolicies,
+		Orphan:              tokenRole.Orphan,
+		Renewable:           tokenRole.Renewable,
+		TokenPeriod:         time.Duration(tokenRole.TokenPeriod),
+		TokenExplicitMaxTTL: time.Duration(tokenRole.TokenExplicitMaxTTL),
+		TokenBoundCIDRs:     tokenRole.TokenBoundCIDRs,
+		PathSuffix:          tokenRole.PathSuffix,
+	})
+	if err != nil {
+		h.handleError(w, r, err)
+		return
+	}
+
+	if err := json.NewEncoder(w).Encode(response); err != nil {
+		w.WriteHeader(http.StatusInternalServerError)
+		return
+	}
 }
 
 func (h Handler) DeleteRoleByNameToken(w http.ResponseWriter, r *http.Request) {
-	http.Error(w, http.StatusText(http.StatusNotImplemented), http.StatusNotImplemented)
+	roleName := chi.URLParam(r, "role_name")
+
+	response, err := h.dVault.DeleteTokenRole(r.Context(), roleName)
+	if err != nil {
+		h.handleError(w, r, err)
+		return
+	}
+
+	if err := json.NewEncoder(w).Encode(response); err != nil {
+		w.WriteHeader(http.StatusInternalServerError)
+		return
+	}
 }
 
 func (h Handler) TidyToken(w http.ResponseWriter, r *http.Request) {
-	http.Error(w, http.StatusText(http.StatusNotImplemented), http.StatusNotImplemented)
+	response, err := h.dVault.TidyToken(r.Context())
+	if err != nil {
+		h.handleError(w, r, err)
+		return
+	}
+
+	w.WriteHeader(http.StatusAccepted)
+	if err := json.NewEncoder(w).Encode(response); err != nil {
+		w.WriteHeader(http.StatusInternalServerError)
+		return
+	}
 }
 
 func (h Handler) Unseal(w http.ResponseWriter, r *http.Request) {
@@ -325,6 +631,7 @@ func (h Handler) Unseal(w http.ResponseWriter, r *http.Request) {
 		Key:     unsealRequest.Key,
 		Migrate: unsealRequest.Migrate,
 		Reset:   unsealRequest.Reset,
+		Nonce:   unsealRequest.Nonce,
 	})
 	if err != nil {
 		h.handleError(w, r, err)
@@ -350,6 +657,32 @@ func (h Handler) Seal(w http.ResponseWriter, r *http.Request) {
 	}
 }
 
+func (h Handler) Rotate(w http.ResponseWriter, r *http.Request) {
+	response, err := h.dVault.Rotate(r.Context())
+	if err != nil {
+		h.handleError(w, r, err)
+		return
+	}
+
+	if err := json.NewEncoder(w).Encode(response); err != nil {
+		w.WriteHeader(http.StatusInternalServerError)
+		return
+	}
+}
+
+func (h Handler) KeyStatus(w http.ResponseWriter, r *http.Request) {
+	response, err := h.dVault.KeyStatus(r.Context())
+	if err != nil {
+		h.handleError(w, r, err)
+		return
+	}
+
+	if err := json.NewEncoder(w).Encode(response); err != nil {
+		w.WriteHeader(http.StatusInternalServerError)
+		return
+	}
+}
+
 func (h Handler) SealStatus(w http.ResponseWriter, r *http.Request) {
 	sealStatus, err := h.dVault.SealStatus(r.Context())
 	if err != nil {
@@ -391,6 +724,243 @@ func (h Handler) Init(w http.ResponseWriter, r *http.Request) {
 	}
 }
 
+func (h Handler) GetGenerateRootAttempt(w http.ResponseWriter, r *http.Request) {
+	response, err := h.dVault.GenerateRootStatus(r.Context())
+	if err != nil {
+		h.handleError(w, r, err)
+		return
+	}
+
+	if err := json.NewEncoder(w).Encode(response); err != nil {
+		w.WriteHeader(http.StatusInternalServerError)
+		return
+	}
+}
+
+func (h Handler) StartGenerateRootAttempt(w http.ResponseWriter, r *http.Request) {
+	response, err := h.dVault.StartGenerateRoot(r.Context())
+	if err != nil {
+		h.handleError(w, r, err)
+		return
+	}
+
+	if err := json.NewEncoder(w).Encode(response); err != nil {
+		w.WriteHeader(http.StatusInternalServerError)
+		return
+	}
+}
+
+func (h Handler) CancelGenerateRootAttempt(w http.ResponseWriter, r *http.Request) {
+	if err := h.dVault.CancelGenerateRoot(r.Context()); err != nil {
+		h.handleError(w, r, err)
+		return
+	}
+
+	w.WriteHeader(http.StatusNoContent)
+}
+
+func (h Handler) UpdateGenerateRoot(w http.ResponseWriter, r *http.Request) {
+	var updateRequest GenerateRootUpdateRequest
+	if err := json.NewDecoder(r.Body).Decode(&updateRequest); err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	response, err := h.dVault.UpdateGenerateRoot(r.Context(), dvault.GenerateRootUpdate{
+		Key:   updateRequest.Key,
+		Nonce: updateRequest.Nonce,
+	})
+	if err != nil {
+		h.handleError(w, r, err)
+		return
+	}
+
+	if err := json.NewEncoder(w).Encode(response); err != nil {
+		w.WriteHeader(http.StatusInternalServerError)
+		return
+	}
+}
+
+func (h Handler) GetRekeyAttempt(w http.ResponseWriter, r *http.Request) {
+	response, err := h.dVault.RekeyStatus(r.Context())
+	if err != nil {
+		h.handleError(w, r, err)
+		return
+	}
+
+	if err := json.NewEncoder(w).Encode(response); err != nil {
+		w.WriteHeader(http.StatusInternalServerError)
+		return
+	}
+}
+
+func (h Handler) StartRekeyAttempt(w http.ResponseWriter, r *http.Request) {
+	var initRequest RekeyInitRequest
+	if err := json.NewDecoder(r.Body).Decode(&initRequest); err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	response, err := h.dVault.StartRekey(r.Context(), dvault.RekeyInit{
+		SecretShares:        initRequest.SecretShares,
+		SecretThreshold:     initRequest.SecretThreshold,
+		PgpKeys:             initRequest.PgpKeys,
+		RequireVerification: initRequest.RequireVerification,
+	})
+	if err != nil {
+		h.handleError(w, r, err)
+		return
+	}
+
+	if err := json.NewEncoder(w).Encode(response); err != nil {
+		w.WriteHeader(http.StatusInternalServerError)
+		return
+	}
+}
+
+func (h Handler) CancelRekeyAttempt(w http.ResponseWriter, r *http.Request) {
+	if err := h.dVault.CancelRekey(r.Context()); err != nil {
+		h.handleError(w, r, err)
+		return
+	}
+
+	w.WriteHeader(http.StatusNoContent)
+}
+
+func (h Handler) UpdateRekey(w http.ResponseWriter, r *http.Request) {
+	var updateRequest RekeyUpdateRequest
+	if err := json.NewDecoder(r.Body).Decode(&updateRequest); err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	response, err := h.dVault.UpdateRekey(r.Context(), dvault.RekeyUpdate{
+		Key:   updateRequest.Key,
+		Nonce: updateRequest.Nonce,
+	})
+	if err != nil {
+		h.handleError(w, r, err)
+		return
+	}
+
+	if err := json.NewEncoder(w).Encode(response); err != nil {
+		w.WriteHeader(http.StatusInternalServerError)
+		return
+	}
+}
+
+func (h Handler) GetRekeyVerification(w http.ResponseWriter, r *http.Request) {
+	response, err := h.dVault.RekeyVerificationStatus(r.Context())
+	if err != nil {
+		h.handleError(w, r, err)
+		return
+	}
+
+	if err := json.NewEncoder(w).Encode(response); err != nil {
+		w.WriteHeader(http.StatusInternalServerError)
+		return
+	}
+}
+
+func (h Handler) CancelRekeyVerification(w http.ResponseWriter, r *http.Request) {
+	response, err := h.dVault.CancelRekeyVerification(r.Context())
+	if err != nil {
+		h.handleError(w, r, err)
+		return
+	}
+
+	if err := json.NewEncoder(w).Encode(response); err != nil {
+		w.WriteHeader(http.StatusInternalServerError)
+		return
+	}
+}
+
+func (h Handler) VerifyRekey(w http.ResponseWriter, r *http.Request) {
+	var updateRequest RekeyUpdateRequest
+	if err := json.NewDecoder(r.Body).Decode(&updateRequest); err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	response, err := h.dVault.VerifyRekey(r.Context(), dvault.RekeyUpdate{
+		Key:   updateRequest.Key,
+		Nonce: updateRequest.Nonce,
+	})
+	if err != nil {
+		h.handleError(w, r, err)
+		return
+	}
+
+	if err := json.NewEncoder(w).Encode(response); err != nil {
+		w.WriteHeader(http.StatusInternalServerError)
+		return
+	}
+}
+
+func (h Handler) ListPolicies(w http.ResponseWriter, r *http.Request) {
+	response, err := h.dVault.ListPolicies(r.Context())
+	if err != nil {
+		h.handleError(w, r, err)
+		return
+	}
+
+	if err := json.NewEncoder(w).Encode(response); err != nil {
+		w.WriteHeader(http.StatusInternalServerError)
+		return
+	}
+}
+
+func (h Handler) GetPolicy(w http.ResponseWriter, r *http.Request) {
+	name := chi.URLParam(r, "name")
+
+	response, err := h.dVault.GetPolicy(r.Context(), name)
+	if err != nil {
+		h.handleError(w, r, err)
+		return
+	}
+
+	if err := json.NewEncoder(w).Encode(response); err != nil {
+		w.WriteHeader(http.StatusInternalServerError)
+		return
+	}
+}
+
+func (h Handler) SavePolicy(w http.ResponseWriter, r *http.Request) {
+	name := chi.URLParam(r, "name")
+
+	var savePolicy SavePolicyRequest
+	if err := json.NewDecoder(r.Body).Decode(&savePolicy); err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	response, err := h.dVault.SavePolicy(r.Context(), name, savePolicy.Policy)
+	if err != nil {
+		h.handleError(w, r, err)
+		return
+	}
+
+	if err := json.NewEncoder(w).Encode(response); err != nil {
+		w.WriteHeader(http.StatusInternalServerError)
+		return
+	}
+}
+
+func (h Handler) DeletePolicy(w http.ResponseWriter, r *http.Request) {
+	name := chi.URLParam(r, "name")
+
+	response, err := h.dVault.DeletePolicy(r.Context(), name)
+	if err != nil {
+		h.handleError(w, r, err)
+		return
+	}
+
+	if err := json.NewEncoder(w).Encode(response); err != nil {
+		w.WriteHeader(http.StatusInternalServerError)
+		return
+	}
+}
+
 func (h Handler) GetMounts(w http.ResponseWriter, r *http.Request) {
 	response, err := h.dVault.Mounts(r.Context())
 	if err != nil {
@@ -440,7 +1010,18 @@ func (h Handler) CreateMount(w http.ResponseWriter, r *http.Request) {
 }
 
 func (h Handler) DeleteMount(w http.ResponseWriter, r *http.Request) {
-	http.Error(w, http.StatusText(http.StatusNotImplemented), http.StatusNotImplemented)
+	secretPath := chi.URLParam(r, "path")
+
+	response, err := h.dVault.DeleteMount(r.Context(), secretPath)
+	if err != nil {
+		h.handleError(w, r, err)
+		return
+	}
+
+	if err := json.NewEncoder(w).Encode(response); err != nil {
+		w.WriteHeader(http.StatusInternalServerError)
+		return
+	}
 }
 
 func (h Handler) Health(w http.ResponseWriter, r *http.Request) {
@@ -453,6 +1034,65 @@ func (h Handler) handleError(rw http.ResponseWriter, r *http.Request, err error)
 	switch {
 	case errors.As(err, &b):
 		rw.WriteHeader(http.StatusBadRequest)
+	case errors.Is(err, token.ErrTokenNotFound),
+		errors.Is(err, dvault.ErrPermissionDenied),
+		errors.Is(err, auth.ErrUserLocked),
+		errors.Is(err, identity.ErrEntityDisabled):
+		rw.WriteHeader(http.StatusForbidden)
+	case errors.Is(err, dvault.ErrSealed):
+		rw.WriteHeader(http.StatusServiceUnavailable)
+	case errors.Is(err, token.ErrNotRenewable),
+		errors.Is(err, token.ErrBatchToken),
+		errors.Is(err, token.ErrInvalidBatch),
+		errors.Is(err, token.ErrInvalidType),
+		errors.Is(err, token.ErrInvalidNumUses),
+		errors.Is(err, dvault.ErrGenerateRootNotStarted),
+		errors.Is(err, dvault.ErrGenerateRootInProgress),
+		errors.Is(err, dvault.ErrInvalidNonce),
+		errors.Is(err, dvault.ErrInvalidShare),
+		errors.Is(err, dvault.ErrInvalidInit),
+		errors.Is(err, dvault.ErrAlreadyInitialized),
+		errors.Is(err, dvault.ErrNotInitialized),
+		errors.Is(err, dvault.ErrAlreadyUnsealed),
+		errors.Is(err, dvault.ErrRekeyNotStarted),
+		errors.Is(err, dvault.ErrRekeyInProgress),
+		errors.Is(err, dvault.ErrRekeyVerificationNotStarted),
+		errors.Is(err, dvault.ErrInvalidRekey),
+		errors.Is(err, recipient.ErrInvalidKey),
+		errors.Is(err, policy.ErrInvalidPolicy),
+		errors.Is(err, policy.ErrImmutablePolicy),
+		errors.Is(err, token.ErrInvalidRole),
+		errors.Is(err, auth.ErrInvalidCredentials),
+		errors.Is(err, auth.ErrInvalidConfig),
+		errors.Is(err, jwt.ErrNotConfigured),
+		errors.Is(err, dvault.ErrInvalidAuthPath),
+		errors.Is(err, dvault.ErrAuthPathInUse),
+		errors.Is(err, dvault.ErrUnknownAuthType),
+		errors.Is(err, dvault.ErrInvalidAuthConfig),
+		errors.Is(err, identity.ErrInvalidIdentity),
+		errors.Is(err, dvault.ErrInvalidWrapTTL),
+		errors.Is(err, dvault.ErrInvalidWrappingToken),
+		errors.Is(err, dvault.ErrMountNotAllowed),
+		errors.Is(err, cubbyhole.ErrInvalidPath),
+		errors.Is(err, mfa.ErrInvalidConfig),
+		errors.Is(err, mfa.ErrMethodInUse),
+		errors.Is(err, mfa.ErrSecretExists):
+		rw.WriteHeader(http.StatusBadRequest)
+	case errors.Is(err, policy.ErrPolicyNotFound),
+		errors.Is(err, token.ErrRoleNotFound),
+		errors.Is(err, auth.ErrRoleNotFound),
+		errors.Is(err, approle.ErrSecretIDNotFound),
+		errors.Is(err, userpass.ErrUserNotFound),
+		errors.Is(err, dvault.ErrAuthMethodNotFound),
+		errors.Is(err, identity.ErrEntityNotFound),
+		errors.Is(err, identity.ErrAliasNotFound),
+		errors.Is(err, identity.ErrGroupNotFound),
+		errors.Is(err, dvault.ErrMountNotFound),
+		errors.Is(err, cubbyhole.ErrPathNotFound),
+		errors.Is(err, mfa.ErrMethodNotFound),
+		errors.Is(err, mfa.ErrEnforcementNotFound),
+		errors.Is(err, mfa.ErrSecretNotFound):
+		rw.WriteHeader(http.StatusNotFound)
 	default:
 		rw.WriteHeader(http.StatusInternalServerError)
 	}
@@ -0,0 +1,502 @@
+package handler
+
+import (
+	"encoding/json"
+	"net/http"
+
+	"github.com/Burzich/dvault/internal/dvault"
+	"github.com/go-chi/chi/v5"
+)
+
+// CreateEntity creates a entity, or updates it when the body carries an id.
+func (h Handler) CreateEntity(w http.ResponseWriter, r *http.Request) {
+	var request EntityRequest
+	if err := json.NewDecoder(r.Body).Decode(&request); err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	response, err := h.dVault.SaveEntity(r.Context(), request.Id, request.entity())
+	if err != nil {
+		h.handleError(w, r, err)
+		return
+	}
+
+	if err := json.NewEncoder(w).Encode(response); err != nil {
+		w.WriteHeader(http.StatusInternalServerError)
+		return
+	}
+}
+
+func (h Handler) ListEntities(w http.ResponseWriter, r *http.Request) {
+	response, err := h.dVault.ListEntities(r.Context())
+	if err != nil {
+		h.handleError(w, r, err)
+		return
+	}
+
+	if err := json.NewEncoder(w).Encode(response); err != nil {
+		w.WriteHeader(http.StatusInternalServerError)
+		return
+	}
+}
+
+func (h Handler) GetEntity(w http.ResponseWriter, r *http.Request) {
+	id := chi.URLParam(r, "id")
+
+	response, err := h.dVault.GetEntity(r.Context(), id)
+	if err != nil {
+		h.handleError(w, r, err)
+		return
+	}
+
+	if err := json.NewEncoder(w).Encode(response); err != nil {
+		w.WriteHeader(http.StatusInternalServerError)
+		return
+	}
+}
+
+func (h Handler) UpdateEntity(w http.ResponseWriter, r *http.Request) {
+	id := chi.URLParam(r, "id")
+
+	var request EntityRequest
+	if err := json.NewDecoder(r.Body).Decode(&request); err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	response, err := h.dVault.SaveEntity(r.Context(), id, request.entity())
+	if err != nil {
+		h.handleError(w, r, err)
+		return
+	}
+
+	if err := json.NewEncoder(w).Encode(response); err != nil {
+		w.WriteHeader(http.StatusInternalServerError)
+		return
+	}
+}
+
+func (h Handler) DeleteEntity(w http.ResponseWriter, r *http.Request) {
+	id := chi.URLParam(r, "id")
+
+	response, err := h.dVault.DeleteEntity(r.Context(), id)
+	if err != nil {
+		h.handleError(w, r, err)
+		return
+	}
+
+	if err := json.NewEncoder(w).Encode(response); err != nil {
+		w.WriteHeader(http.StatusInternalServerError)
+		return
+	}
+}
+
+func (h Handler) ListEntityNames(w http.ResponseWriter, r *http.Request) {
+	response, err := h.dVault.ListEntityNames(r.Context())
+	if err != nil {
+		h.handleError(w, r, err)
+		return
+	}
+
+	if err := json.NewEncoder(w).Encode(response); err != nil {
+		w.WriteHeader(http.StatusInternalServerError)
+		return
+	}
+}
+
+func (h Handler) GetEntityByName(w http.ResponseWriter, r *http.Request) {
+	name := chi.URLParam(r, "name")
+
+	response, err := h.dVault.GetEntityByName(r.Context(), name)
+	if err != nil {
+		h.handleError(w, r, err)
+		return
+	}
+
+	if err := json.NewEncoder(w).Encode(response); err != nil {
+		w.WriteHeader(http.StatusInternalServerError)
+		return
+	}
+}
+
+func (h Handler) SaveEntityByName(w http.ResponseWriter, r *http.Request) {
+	name := chi.URLParam(r, "name")
+
+	var request EntityRequest
+	if err := json.NewDecoder(r.Body).Decode(&request); err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	response, err := h.dVault.SaveEntityByName(r.Context(), name, request.entity())
+	if err != nil {
+		h.handleError(w, r, err)
+		return
+	}
+
+	if err := json.NewEncoder(w).Encode(response); err != nil {
+		w.WriteHeader(http.StatusInternalServerError)
+		return
+	}
+}
+
+func (h Handler) DeleteEntityByName(w http.ResponseWriter, r *http.Request) {
+	name := chi.URLParam(r, "name")
+
+	response, err := h.dVault.DeleteEntityByName(r.Context(), name)
+	if err != nil {
+		h.handleError(w, r, err)
+		return
+	}
+
+	if err := json.NewEncoder(w).Encode(response); err != nil {
+		w.WriteHeader(http.StatusInternalServerError)
+		return
+	}
+}
+
+// CreateEntityAlias creates a entity alias, or updates it when the body carries an id.
+func (h Handler) CreateEntityAlias(w http.ResponseWriter, r *http.Request) {
+	var request IdentityAliasRequest
+	if err := json.NewDecoder(r.Body).Decode(&request); err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	response, err := h.dVault.SaveEntityAlias(r.Context(), request.Id, request.alias())
+	if err != nil {
+		h.handleError(w, r, err)
+		return
+	}
+
+	if err := json.NewEncoder(w).Encode(response); err != nil {
+		w.WriteHeader(http.StatusInternalServerError)
+		return
+	}
+}
+
+func (h Handler) ListEntityAliases(w http.ResponseWriter, r *http.Request) {
+	response, err := h.dVault.ListEntityAliases(r.Context())
+	if err != nil {
+		h.handleError(w, r, err)
+		return
+	}
+
+	if err := json.NewEncoder(w).Encode(response); err != nil {
+		w.WriteHeader(http.StatusInternalServerError)
+		return
+	}
+}
+
+func (h Handler) GetEntityAlias(w http.ResponseWriter, r *http.Request) {
+	id := chi.URLParam(r, "id")
+
+	response, err := h.dVault.GetEntityAlias(r.Context(), id)
+	if err != nil {
+		h.handleError(w, r, err)
+		return
+	}
+
+	if err := json.NewEncoder(w).Encode(response); err != nil {
+		w.WriteHeader(http.StatusInternalServerError)
+		return
+	}
+}
+
+func (h Handler) UpdateEntityAlias(w http.ResponseWriter, r *http.Request) {
+	id := chi.URLParam(r, "id")
+
+	var request IdentityAliasRequest
+	if err := json.NewDecoder(r.Body).Decode(&request); err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	response, err := h.dVault.SaveEntityAlias(r.Context(), id, request.alias())
+	if err != nil {
+		h.handleError(w, r, err)
+		return
+	}
+
+	if err := json.NewEncoder(w).Encode(response); err != nil {
+		w.WriteHeader(http.StatusInternalServerError)
+		return
+	}
+}
+
+func (h Handler) DeleteEntityAlias(w http.ResponseWriter, r *http.Request) {
+	id := chi.URLParam(r, "id")
+
+	response, err := h.dVault.DeleteEntityAlias(r.Context(), id)
+	if err != nil {
+		h.handleError(w, r, err)
+		return
+	}
+
+	if err := json.NewEncoder(w).Encode(response); err != nil {
+		w.WriteHeader(http.StatusInternalServerError)
+		return
+	}
+}
+
+// CreateGroup creates a group, or updates it when the body carries an id.
+func (h Handler) CreateGroup(w http.ResponseWriter, r *http.Request) {
+	var request GroupRequest
+	if err := json.NewDecoder(r.Body).Decode(&request); err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	response, err := h.dVault.SaveGroup(r.Context(), request.Id, request.group())
+	if err != nil {
+		h.handleError(w, r, err)
+		return
+	}
+
+	if err := json.NewEncoder(w).Encode(response); err != nil {
+		w.WriteHeader(http.StatusInternalServerError)
+		return
+	}
+}
+
+func (h Handler) ListGroups(w http.ResponseWriter, r *http.Request) {
+	response, err := h.dVault.ListGroups(r.Context())
+	if err != nil {
+		h.handleError(w, r, err)
+		return
+	}
+
+	if err := json.NewEncoder(w).Encode(response); err != nil {
+		w.WriteHeader(http.StatusInternalServerError)
+		return
+	}
+}
+
+func (h Handler) GetGroup(w http.ResponseWriter, r *http.Request) {
+	id := chi.URLParam(r, "id")
+
+	response, err := h.dVault.GetGroup(r.Context(), id)
+	if err != nil {
+		h.handleError(w, r, err)
+		return
+	}
+
+	if err := json.NewEncoder(w).Encode(response); err != nil {
+		w.WriteHeader(http.StatusInternalServerError)
+		return
+	}
+}
+
+func (h Handler) UpdateGroup(w http.ResponseWriter, r *http.Request) {
+	id := chi.URLParam(r, "id")
+
+	var request GroupRequest
+	if err := json.NewDecoder(r.Body).Decode(&request); err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	response, err := h.dVault.SaveGroup(r.Context(), id, request.group())
+	if err != nil {
+		h.handleError(w, r, err)
+		return
+	}
+
+	if err := json.NewEncoder(w).Encode(response); err != nil {
+		w.WriteHeader(http.StatusInternalServerError)
+		return
+	}
+}
+
+func (h Handler) DeleteGroup(w http.ResponseWriter, r *http.Request) {
+	id := chi.URLParam(r, "id")
+
+	response, err := h.dVault.DeleteGroup(r.Context(), id)
+	if err != nil {
+		h.handleError(w, r, err)
+		return
+	}
+
+	if err := json.NewEncoder(w).Encode(response); err != nil {
+		w.WriteHeader(http.StatusInternalServerError)
+		return
+	}
+}
+
+func (h Handler) ListGroupNames(w http.ResponseWriter, r *http.Request) {
+	response, err := h.dVault.ListGroupNames(r.Context())
+	if err != nil {
+		h.handleError(w, r, err)
+		return
+	}
+
+	if err := json.NewEncoder(w).Encode(response); err != nil {
+		w.WriteHeader(http.StatusInternalServerError)
+		return
+	}
+}
+
+func (h Handler) GetGroupByName(w http.ResponseWriter, r *http.Request) {
+	name := chi.URLParam(r, "name")
+
+	response, err := h.dVault.GetGroupByName(r.Context(), name)
+	if err != nil {
+		h.handleError(w, r, err)
+		return
+	}
+
+	if err := json.NewEncoder(w).Encode(response); err != nil {
+		w.WriteHeader(http.StatusInternalServerError)
+		return
+	}
+}
+
+func (h Handler) SaveGroupByName(w http.ResponseWriter, r *http.Request) {
+	name := chi.URLParam(r, "name")
+
+	var request GroupRequest
+	if err := json.NewDecoder(r.Body).Decode(&request); err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	response, err := h.dVault.SaveGroupByName(r.Context(), name, request.group())
+	if err != nil {
+		h.handleError(w, r, err)
+		return
+	}
+
+	if err := json.NewEncoder(w).Encode(response); err != nil {
+		w.WriteHeader(http.StatusInternalServerError)
+		return
+	}
+}
+
+func (h Handler) DeleteGroupByName(w http.ResponseWriter, r *http.Request) {
+	name := chi.URLParam(r, "name")
+
+	response, err := h.dVault.DeleteGroupByName(r.Context(), name)
+	if err != nil {
+		h.handleError(w, r, err)
+		return
+	}
+
+	if err := json.NewEncoder(w).Encode(response); err != nil {
+		w.WriteHeader(http.StatusInternalServerError)
+		return
+	}
+}
+
+// CreateGroupAlias creates a group alias, or updates it when the body carries an id.
+func (h Handler) CreateGroupAlias(w http.ResponseWriter, r *http.Request) {
+	var request IdentityAliasRequest
+	if err := json.NewDecoder(r.Body).Decode(&request); err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	response, err := h.dVault.SaveGroupAlias(r.Context(), request.Id, request.alias())
+	if err != nil {
+		h.handleError(w, r, err)
+		return
+	}
+
+	if err := json.NewEncoder(w).Encode(response); err != nil {
+		w.WriteHeader(http.StatusInternalServerError)
+		return
+	}
+}
+
+func (h Handler) ListGroupAliases(w http.ResponseWriter, r *http.Request) {
+	response, err := h.dVault.ListGroupAliases(r.Context())
+	if err != nil {
+		h.handleError(w, r, err)
+		return
+	}
+
+	if err := json.NewEncoder(w).Encode(response); err != nil {
+		w.WriteHeader(http.StatusInternalServerError)
+		return
+	}
+}
+
+func (h Handler) GetGroupAlias(w http.ResponseWriter, r *http.Request) {
+	id := chi.URLParam(r, "id")
+
+	response, err := h.dVault.GetGroupAlias(r.Context(), id)
+	if err != nil {
+		h.handleError(w, r, err)
+		return
+	}
+
+	if err := json.NewEncoder(w).Encode(response); err != nil {
+		w.WriteHeader(http.StatusInternalServerError)
+		return
+	}
+}
+
+func (h Handler) UpdateGroupAlias(w http.ResponseWriter, r *http.Request) {
+	id := chi.URLParam(r, "id")
+
+	var request IdentityAliasRequest
+	if err := json.NewDecoder(r.Body).Decode(&request); err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	response, err := h.dVault.SaveGroupAlias(r.Context(), id, request.alias())
+	if err != nil {
+		h.handleError(w, r, err)
+		return
+	}
+
+	if err := json.NewEncoder(w).Encode(response); err != nil {
+		w.WriteHeader(http.StatusInternalServerError)
+		return
+	}
+}
+
+func (h Handler) DeleteGroupAlias(w http.ResponseWriter, r *http.Request) {
+	id := chi.URLParam(r, "id")
+
+	response, err := h.dVault.DeleteGroupAlias(r.Context(), id)
+	if err != nil {
+		h.handleError(w, r, err)
+		return
+	}
+
+	if err := json.NewEncoder(w).Encode(response); err != nil {
+		w.WriteHeader(http.StatusInternalServerError)
+		return
+	}
+}
+
+func (r EntityRequest) entity() dvault.Entity {
+	return dvault.Entity{
+		Name:     r.Name,
+		Policies: r.Policies,
+		Metadata: r.Metadata,
+		Disabled: r.Disabled,
+	}
+}
+
+func (r GroupRequest) group() dvault.Group {
+	return dvault.Group{
+		Name:            r.Name,
+		Type:            r.Type,
+		Policies:        r.Policies,
+		MemberEntityIds: r.MemberEntityIds,
+		MemberGroupIds:  r.MemberGroupIds,
+		Metadata:        r.Metadata,
+	}
+}
+
+func (r IdentityAliasRequest) alias() dvault.IdentityAlias {
+	return dvault.IdentityAlias{
+		Name:          r.Name,
+		MountAccessor: r.MountAccessor,
+		CanonicalId:   r.CanonicalId,
+		Metadata:      r.Metadata,
+	}
+}
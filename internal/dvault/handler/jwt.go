@@ -0,0 +1,157 @@
+package handler
+
+import (
+	"encoding/json"
+	"errors"
+	"io"
+	"net/http"
+	"time"
+
+	"github.com/Burzich/dvault/internal/dvault"
+	"github.com/go-chi/chi/v5"
+)
+
+func (h Handler) GetJWTConfig(w http.ResponseWriter, r *http.Request) {
+	mount := chi.URLParam(r, "auth_mount")
+
+	response, err := h.dVault.GetJWTConfig(r.Context(), mount)
+	if err != nil {
+		h.handleError(w, r, err)
+		return
+	}
+
+	if err := json.NewEncoder(w).Encode(response); err != nil {
+		w.WriteHeader(http.StatusInternalServerError)
+		return
+	}
+}
+
+func (h Handler) SaveJWTConfig(w http.ResponseWriter, r *http.Request) {
+	mount := chi.URLParam(r, "auth_mount")
+
+	var config JWTConfigRequest
+	if err := json.NewDecoder(r.Body).Decode(&config); err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	response, err := h.dVault.SaveJWTConfig(r.Context(), mount, dvault.JWTConfig{
+		JwksUrl:              config.JWKSURL,
+		JwksCaPem:            config.JWKSCAPEM,
+		JwtValidationPubkeys: config.JWTValidationPubKeys,
+		BoundIssuer:          config.BoundIssuer,
+		JwtSupportedAlgs:     config.JWTSupportedAlgs,
+		DefaultRole:          config.DefaultRole,
+	})
+	if err != nil {
+		h.handleError(w, r, err)
+		return
+	}
+
+	if err := json.NewEncoder(w).Encode(response); err != nil {
+		w.WriteHeader(http.StatusInternalServerError)
+		return
+	}
+}
+
+func (h Handler) ListJWTRoles(w http.ResponseWriter, r *http.Request) {
+	mount := chi.URLParam(r, "auth_mount")
+
+	response, err := h.dVault.ListJWTRoles(r.Context(), mount)
+	if err != nil {
+		h.handleError(w, r, err)
+		return
+	}
+
+	if err := json.NewEncoder(w).Encode(response); err != nil {
+		w.WriteHeader(http.StatusInternalServerError)
+		return
+	}
+}
+
+func (h Handler) GetJWTRole(w http.ResponseWriter, r *http.Request) {
+	mount := chi.URLParam(r, "auth_mount")
+	roleName := chi.URLParam(r, "role_name")
+
+	response, err := h.dVault.GetJWTRole(r.Context(), mount, roleName)
+	if err != nil {
+		h.handleError(w, r, err)
+		return
+	}
+
+	if err := json.NewEncoder(w).Encode(response); err != nil {
+		w.WriteHeader(http.StatusInternalServerError)
+		return
+	}
+}
+
+func (h Handler) SaveJWTRole(w http.ResponseWriter, r *http.Request) {
+	mount := chi.URLParam(r, "auth_mount")
+	roleName := chi.URLParam(r, "role_name")
+
+	var role JWTRoleRequest
+	if err := json.NewDecoder(r.Body).Decode(&role); err != nil && !errors.Is(err, io.EOF) {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	response, err := h.dVault.SaveJWTRole(r.Context(), mount, roleName, dvault.JWTRole{
+		RoleType:         role.RoleType,
+		BoundAudiences:   role.BoundAudiences,
+		BoundSubject:     role.BoundSubject,
+		BoundClaims:      role.BoundClaims,
+		ClaimMappings:    role.ClaimMappings,
+		UserClaim:        role.UserClaim,
+		GroupsClaim:      role.GroupsClaim,
+		ClockSkewLeeway:  time.Duration(role.ClockSkewLeeway),
+		ExpirationLeeway: time.Duration(role.ExpirationLeeway),
+		NotBeforeLeeway:  time.Duration(role.NotBeforeLeeway),
+		TokenParams:      role.tokenParams(),
+	})
+	if err != nil {
+		h.handleError(w, r, err)
+		return
+	}
+
+	if err := json.NewEncoder(w).Encode(response); err != nil {
+		w.WriteHeader(http.StatusInternalServerError)
+		return
+	}
+}
+
+func (h Handler) DeleteJWTRole(w http.ResponseWriter, r *http.Request) {
+	mount := chi.URLParam(r, "auth_mount")
+	roleName := chi.URLParam(r, "role_name")
+
+	response, err := h.dVault.DeleteJWTRole(r.Context(), mount, roleName)
+	if err != nil {
+		h.handleError(w, r, err)
+		return
+	}
+
+	if err := json.NewEncoder(w).Encode(response); err != nil {
+		w.WriteHeader(http.StatusInternalServerError)
+		return
+	}
+}
+
+func (h Handler) LoginJWT(w http.ResponseWriter, r *http.Request) {
+	mount := chi.URLParam(r, "auth_mount")
+
+	var login JWTLoginRequest
+	if err := json.NewDecoder(r.Body).Decode(&login); err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	response, err := h.dVault.LoginJWT(r.Context(), mount, login.Role, login.JWT)
+	if err != nil {
+		h.handleError(w, r, err)
+		return
+	}
+
+	if err := json.NewEncoder(w).Encode(response); err != nil {
+		w.WriteHeader(http.StatusInternalServerError)
+		return
+	}
+}
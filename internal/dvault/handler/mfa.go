@@ -0,0 +1,234 @@
+package handler
+
+import (
+	"encoding/json"
+	"net/http"
+	"time"
+
+	"github.com/Burzich/dvault/internal/dvault"
+	"github.com/go-chi/chi/v5"
+)
+
+func (h Handler) ListTOTPMethods(w http.ResponseWriter, r *http.Request) {
+	response, err := h.dVault.ListTOTPMethods(r.Context())
+	if err != nil {
+		h.handleError(w, r, err)
+		return
+	}
+
+	if err := json.NewEncoder(w).Encode(response); err != nil {
+		w.WriteHeader(http.StatusInternalServerError)
+		return
+	}
+}
+
+func (h Handler) CreateTOTPMethod(w http.ResponseWriter, r *http.Request) {
+	var request TOTPMethodRequest
+	if err := json.NewDecoder(r.Body).Decode(&request); err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	response, err := h.dVault.SaveTOTPMethod(r.Context(), "", request.totpMethod())
+	if err != nil {
+		h.handleError(w, r, err)
+		return
+	}
+
+	if err := json.NewEncoder(w).Encode(response); err != nil {
+		w.WriteHeader(http.StatusInternalServerError)
+		return
+	}
+}
+
+func (h Handler) GetTOTPMethod(w http.ResponseWriter, r *http.Request) {
+	id := chi.URLParam(r, "method_id")
+
+	response, err := h.dVault.GetTOTPMethod(r.Context(), id)
+	if err != nil {
+		h.handleError(w, r, err)
+		return
+	}
+
+	if err := json.NewEncoder(w).Encode(response); err != nil {
+		w.WriteHeader(http.StatusInternalServerError)
+		return
+	}
+}
+
+func (h Handler) UpdateTOTPMethod(w http.ResponseWriter, r *http.Request) {
+	id := chi.URLParam(r, "method_id")
+
+	var request TOTPMethodRequest
+	if err := json.NewDecoder(r.Body).Decode(&request); err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	response, err := h.dVault.SaveTOTPMethod(r.Context(), id, request.totpMethod())
+	if err != nil {
+		h.handleError(w, r, err)
+		return
+	}
+
+	if err := json.NewEncoder(w).Encode(response); err != nil {
+		w.WriteHeader(http.StatusInternalServerError)
+		return
+	}
+}
+
+func (h Handler) DeleteTOTPMethod(w http.ResponseWriter, r *http.Request) {
+	id := chi.URLParam(r, "method_id")
+
+	response, err := h.dVault.DeleteTOTPMethod(r.Context(), id)
+	if err != nil {
+		h.handleError(w, r, err)
+		return
+	}
+
+	if err := json.NewEncoder(w).Encode(response); err != nil {
+		w.WriteHeader(http.StatusInternalServerError)
+		return
+	}
+}
+
+// GenerateTOTPSecret enrolls the entity of the calling token.
+func (h Handler) GenerateTOTPSecret(w http.ResponseWriter, r *http.Request) {
+	var request TOTPSecretRequest
+	if err := json.NewDecoder(r.Body).Decode(&request); err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	response, err := h.dVault.GenerateTOTPSecret(r.Context(), request.MethodId)
+	if err != nil {
+		h.handleError(w, r, err)
+		return
+	}
+
+	if err := json.NewEncoder(w).Encode(response); err != nil {
+		w.WriteHeader(http.StatusInternalServerError)
+		return
+	}
+}
+
+func (h Handler) AdminGenerateTOTPSecret(w http.ResponseWriter, r *http.Request) {
+	var request TOTPSecretRequest
+	if err := json.NewDecoder(r.Body).Decode(&request); err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	response, err := h.dVault.AdminGenerateTOTPSecret(r.Context(), request.MethodId, request.EntityId)
+	if err != nil {
+		h.handleError(w, r, err)
+		return
+	}
+
+	if err := json.NewEncoder(w).Encode(response); err != nil {
+		w.WriteHeader(http.StatusInternalServerError)
+		return
+	}
+}
+
+func (h Handler) AdminDestroyTOTPSecret(w http.ResponseWriter, r *http.Request) {
+	var request TOTPSecretRequest
+	if err := json.NewDecoder(r.Body).Decode(&request); err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	response, err := h.dVault.AdminDestroyTOTPSecret(r.Context(), request.MethodId, request.EntityId)
+	if err != nil {
+		h.handleError(w, r, err)
+		return
+	}
+
+	if err := json.NewEncoder(w).Encode(response); err != nil {
+		w.WriteHeader(http.StatusInternalServerError)
+		return
+	}
+}
+
+func (h Handler) ListLoginEnforcements(w http.ResponseWriter, r *http.Request) {
+	response, err := h.dVault.ListLoginEnforcements(r.Context())
+	if err != nil {
+		h.handleError(w, r, err)
+		return
+	}
+
+	if err := json.NewEncoder(w).Encode(response); err != nil {
+		w.WriteHeader(http.StatusInternalServerError)
+		return
+	}
+}
+
+func (h Handler) GetLoginEnforcement(w http.ResponseWriter, r *http.Request) {
+	name := chi.URLParam(r, "name")
+
+	response, err := h.dVault.GetLoginEnforcement(r.Context(), name)
+	if err != nil {
+		h.handleError(w, r, err)
+		return
+	}
+
+	if err := json.NewEncoder(w).Encode(response); err != nil {
+		w.WriteHeader(http.StatusInternalServerError)
+		return
+	}
+}
+
+func (h Handler) SaveLoginEnforcement(w http.ResponseWriter, r *http.Request) {
+	name := chi.URLParam(r, "name")
+
+	var request LoginEnforcementRequest
+	if err := json.NewDecoder(r.Body).Decode(&request); err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	response, err := h.dVault.SaveLoginEnforcement(r.Context(), name, dvault.LoginEnforcement{
+		MfaMethodIds:        request.MfaMethodIds,
+		AuthMethodAccessors: request.AuthMethodAccessors,
+		AuthMethodTypes:     request.AuthMethodTypes,
+		IdentityGroupIds:    request.IdentityGroupIds,
+		IdentityEntityIds:   request.IdentityEntityIds,
+	})
+	if err != nil {
+		h.handleError(w, r, err)
+		return
+	}
+
+	if err := json.NewEncoder(w).Encode(response); err != nil {
+		w.WriteHeader(http.StatusInternalServerError)
+		return
+	}
+}
+
+func (h Handler) DeleteLoginEnforcement(w http.ResponseWriter, r *http.Request) {
+	name := chi.URLParam(r, "name")
+
+	response, err := h.dVault.DeleteLoginEnforcement(r.Context(), name)
+	if err != nil {
+		h.handleError(w, r, err)
+		return
+	}
+
+	if err := json.NewEncoder(w).Encode(response); err != nil {
+		w.WriteHeader(http.StatusInternalServerError)
+		return
+	}
+}
+
+func (r TOTPMethodRequest) totpMethod() dvault.TOTPMethod {
+	return dvault.TOTPMethod{
+		MethodName:            r.MethodName,
+		Issuer:                r.Issuer,
+		Period:                time.Duration(r.Period),
+		KeySize:               r.KeySize,
+		Algorithm:             r.Algorithm,
+		Digits:                r.Digits,
+		Skew:                  r.Skew,
+		MaxValidationAttempts: r.MaxValidationAttempts,
+	}
+}
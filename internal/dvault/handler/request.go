@@ -1,9 +1,20 @@
 package handler
 
+import (
+	"encoding/json"
+	"errors"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/Burzich/dvault/internal/dvault/auth"
+)
+
 type UnsealRequest struct {
 	Key     string `json:"key"`
 	Migrate bool   `json:"migrate"`
 	Reset   bool   `json:"reset"`
+	Nonce   string `json:"nonce"`
 }
 
 type InitRequest struct {
@@ -56,3 +67,354 @@ type CreateMount struct {
 	SealWrap              bool                   `json:"seal_wrap"`
 	Type                  string                 `json:"type"`
 }
+
+type CreateTokenRequest struct {
+	Policies        StringList        `json:"policies"`
+	Meta            map[string]string `json:"meta"`
+	NoDefaultPolicy bool              `json:"no_default_policy"`
+	NoParent        bool              `json:"no_parent"`
+	Renewable       *bool             `json:"renewable"`
+	TTL             Duration          `json:"ttl"`
+	ExplicitMaxTTL  Duration          `json:"explicit_max_ttl"`
+	DisplayName     string            `json:"display_name"`
+	NumUses         int               `json:"num_uses"`
+	Type            string            `json:"type"`
+}
+
+type TokenRequest struct {
+	Token string `json:"token"`
+}
+
+type RenewTokenRequest struct {
+	Token     string   `json:"token"`
+	Increment Duration `json:"increment"`
+}
+
+type TokenRoleRequest struct {
+	AllowedPolicies     StringList `json:"allowed_policies"`
+	DisallowedPolicies  StringList `json:"disallowed_policies"`
+	Orphan              bool       `json:"orphan"`
+	Renewable           *bool      `json:"renewable"`
+	TokenPeriod         Duration   `json:"token_period"`
+	TokenExplicitMaxTTL Duration   `json:"token_explicit_max_ttl"`
+	TokenBoundCIDRs     StringList `json:"token_bound_cidrs"`
+	PathSuffix          string     `json:"path_suffix"`
+}
+
+// StringList accepts both a JSON array and a comma separated string, which is
+// what the Vault CLI sends for key=a,b arguments.
+type StringList []string
+
+func (l *StringList) UnmarshalJSON(b []byte) error {
+	var v interface{}
+	if err := json.Unmarshal(b, &v); err != nil {
+		return err
+	}
+
+	switch value := v.(type) {
+	case nil:
+		*l = nil
+	case string:
+		*l = nil
+		for _, item := range strings.Split(value, ",") {
+			if item = strings.TrimSpace(item); item != "" {
+				*l = append(*l, item)
+			}
+		}
+	case []interface{}:
+		*l = make(StringList, 0, len(value))
+		for _, item := range value {
+			str, ok := item.(string)
+			if !ok {
+				return errors.New("invalid list item")
+			}
+			*l = append(*l, str)
+		}
+	default:
+		return errors.New("invalid list")
+	}
+
+	return nil
+}
+
+// Duration accepts both Vault duration strings ("1h", "90s") and plain
+// numbers of seconds.
+type Duration time.Duration
+
+func (d *Duration) UnmarshalJSON(b []byte) error {
+	var v interface{}
+	if err := json.Unmarshal(b, &v); err != nil {
+		return err
+	}
+
+	switch value := v.(type) {
+	case nil:
+		*d = 0
+	case float64:
+		*d = Duration(time.Duration(value) * time.Second)
+	case string:
+		if value == "" {
+			*d = 0
+			return nil
+		}
+
+		if seconds, err := strconv.Atoi(value); err == nil {
+			*d = Duration(time.Duration(seconds) * time.Second)
+			return nil
+		}
+
+		parsed, err := time.ParseDuration(value)
+		if err != nil {
+			return err
+		}
+		*d = Duration(parsed)
+	default:
+		return errors.New("invalid duration")
+	}
+
+	return nil
+}
+
+type GenerateRootUpdateRequest struct {
+	Key   string `json:"key"`
+	Nonce string `json:"nonce"`
+}
+
+type RekeyInitRequest struct {
+	SecretShares        int      `json:"secret_shares"`
+	SecretThreshold     int      `json:"secret_threshold"`
+	PgpKeys             []string `json:"pgp_keys"`
+	RequireVerification bool     `json:"require_verification"`
+}
+
+type RekeyUpdateRequest struct {
+	Key   string `json:"key"`
+	Nonce string `json:"nonce"`
+}
+
+type SavePolicyRequest struct {
+	Policy string `json:"policy"`
+}
+
+type AccessorRequest struct {
+	Accessor string `json:"accessor"`
+}
+
+type RenewAccessorRequest struct {
+	Accessor  string   `json:"accessor"`
+	Increment Duration `json:"increment"`
+}
+
+// TokenParamsRequest holds the token_* fields shared by the roles and users of
+// all auth methods. The legacy policies field is accepted as an alias of
+// token_policies.
+type TokenParamsRequest struct {
+	TokenPolicies        StringList `json:"token_policies"`
+	Policies             StringList `json:"policies"`
+	TokenTTL             Duration   `json:"token_ttl"`
+	TokenMaxTTL          Duration   `json:"token_max_ttl"`
+	TokenPeriod          Duration   `json:"token_period"`
+	TokenNumUses         int        `json:"token_num_uses"`
+	TokenBoundCIDRs      StringList `json:"token_bound_cidrs"`
+	TokenType            string     `json:"token_type"`
+	TokenNoDefaultPolicy bool       `json:"token_no_default_policy"`
+}
+
+func (p TokenParamsRequest) tokenParams() auth.TokenParams {
+	policies := p.TokenPolicies
+	if len(policies) == 0 {
+		policies = p.Policies
+	}
+
+	tokenType := p.TokenType
+	if tokenType == "default" {
+		tokenType = ""
+	}
+
+	return auth.TokenParams{
+		TokenPolicies:        policies,
+		TokenTTL:             time.Duration(p.TokenTTL),
+		TokenMaxTTL:          time.Duration(p.TokenMaxTTL),
+		TokenPeriod:          time.Duration(p.TokenPeriod),
+		TokenNumUses:         p.TokenNumUses,
+		TokenBoundCIDRs:      p.TokenBoundCIDRs,
+		TokenType:            tokenType,
+		TokenNoDefaultPolicy: p.TokenNoDefaultPolicy,
+	}
+}
+
+// StringMap accepts both a JSON object and a string holding one, which is how
+// Vault expects secret ID metadata.
+type StringMap map[string]string
+
+func (m *StringMap) UnmarshalJSON(b []byte) error {
+	var raw string
+	if err := json.Unmarshal(b, &raw); err == nil {
+		if raw == "" {
+			*m = nil
+			return nil
+		}
+		b = []byte(raw)
+	}
+
+	var value map[string]string
+	if err := json.Unmarshal(b, &value); err != nil {
+		return err
+	}
+	*m = value
+
+	return nil
+}
+
+type AppRoleRequest struct {
+	BindSecretID       *bool      `json:"bind_secret_id"`
+	SecretIDBoundCIDRs StringList `json:"secret_id_bound_cidrs"`
+	SecretIDNumUses    int        `json:"secret_id_num_uses"`
+	SecretIDTTL        Duration   `json:"secret_id_ttl"`
+	TokenParamsRequest
+}
+
+type AppRoleRoleIDRequest struct {
+	RoleID string `json:"role_id"`
+}
+
+type AppRoleSecretIDRequest struct {
+	SecretID        string     `json:"secret_id"`
+	Metadata        StringMap  `json:"metadata"`
+	CIDRList        StringList `json:"cidr_list"`
+	TokenBoundCIDRs StringList `json:"token_bound_cidrs"`
+	NumUses         int        `json:"num_uses"`
+	TTL             Duration   `json:"ttl"`
+}
+
+type AppRoleSecretIDAccessorRequest struct {
+	SecretIDAccessor string `json:"secret_id_accessor"`
+}
+
+type AppRoleLoginRequest struct {
+	RoleID   string `json:"role_id"`
+	SecretID string `json:"secret_id"`
+}
+
+type UserpassUserRequest struct {
+	Password string `json:"password"`
+	TokenParamsRequest
+}
+
+type UserpassPasswordRequest struct {
+	Password string `json:"password"`
+}
+
+type UserpassPoliciesRequest struct {
+	TokenPolicies StringList `json:"token_policies"`
+	Policies      StringList `json:"policies"`
+}
+
+type JWTConfigRequest struct {
+	JWKSURL              string     `json:"jwks_url"`
+	JWKSCAPEM            string     `json:"jwks_ca_pem"`
+	JWTValidationPubKeys StringList `json:"jwt_validation_pubkeys"`
+	BoundIssuer          string     `json:"bound_issuer"`
+	JWTSupportedAlgs     StringList `json:"jwt_supported_algs"`
+	DefaultRole          string     `json:"default_role"`
+}
+
+type JWTRoleRequest struct {
+	RoleType         string            `json:"role_type"`
+	BoundAudiences   StringList        `json:"bound_audiences"`
+	BoundSubject     string            `json:"bound_subject"`
+	BoundClaims      map[string]any    `json:"bound_claims"`
+	ClaimMappings    map[string]string `json:"claim_mappings"`
+	UserClaim        string            `json:"user_claim"`
+	GroupsClaim      string            `json:"groups_claim"`
+	ClockSkewLeeway  Duration          `json:"clock_skew_leeway"`
+	ExpirationLeeway Duration          `json:"expiration_leeway"`
+	NotBeforeLeeway  Duration          `json:"not_before_leeway"`
+	TokenParamsRequest
+}
+
+type JWTLoginRequest struct {
+	Role string `json:"role"`
+	JWT  string `json:"jwt"`
+}
+
+type CertRoleRequest struct {
+	Certificate                string     `json:"certificate"`
+	DisplayName                string     `json:"display_name"`
+	AllowedCommonNames         StringList `json:"allowed_common_names"`
+	AllowedDNSSANs             StringList `json:"allowed_dns_sans"`
+	AllowedOrganizationalUnits StringList `json:"allowed_organizational_units"`
+	TokenParamsRequest
+}
+
+type CertLoginRequest struct {
+	Name string `json:"name"`
+}
+
+type EnableAuthRequest struct {
+	Type        string `json:"type"`
+	Description string `json:"description"`
+	Config      struct {
+		DefaultLeaseTTL Duration `json:"default_lease_ttl"`
+		MaxLeaseTTL     Duration `json:"max_lease_ttl"`
+	} `json:"config"`
+	Options  map[string]string `json:"options"`
+	Local    bool              `json:"local"`
+	SealWrap bool              `json:"seal_wrap"`
+}
+
+type TuneAuthRequest struct {
+	DefaultLeaseTTL *Duration `json:"default_lease_ttl"`
+	MaxLeaseTTL     *Duration `json:"max_lease_ttl"`
+	Description     *string   `json:"description"`
+}
+
+type EntityRequest struct {
+	Id       string            `json:"id"`
+	Name     string            `json:"name"`
+	Policies StringList        `json:"policies"`
+	Metadata map[string]string `json:"metadata"`
+	Disabled *bool             `json:"disabled"`
+}
+
+type GroupRequest struct {
+	Id              string            `json:"id"`
+	Name            string            `json:"name"`
+	Type            string            `json:"type"`
+	Policies        StringList        `json:"policies"`
+	MemberEntityIds StringList        `json:"member_entity_ids"`
+	MemberGroupIds  StringList        `json:"member_group_ids"`
+	Metadata        map[string]string `json:"metadata"`
+}
+
+type IdentityAliasRequest struct {
+	Id            string            `json:"id"`
+	Name          string            `json:"name"`
+	MountAccessor string            `json:"mount_accessor"`
+	CanonicalId   string            `json:"canonical_id"`
+	Metadata      map[string]string `json:"metadata"`
+}
+
+type TOTPMethodRequest struct {
+	MethodName            string   `json:"method_name"`
+	Issuer                string   `json:"issuer"`
+	Period                Duration `json:"period"`
+	KeySize               int      `json:"key_size"`
+	Algorithm             string   `json:"algorithm"`
+	Digits                int      `json:"digits"`
+	Skew                  *int     `json:"skew"`
+	MaxValidationAttempts int      `json:"max_validation_attempts"`
+}
+
+type TOTPSecretRequest struct {
+	MethodId string `json:"method_id"`
+	EntityId string `json:"entity_id"`
+}
+
+type LoginEnforcementRequest struct {
+	MfaMethodIds        StringList `json:"mfa_method_ids"`
+	AuthMethodAccessors StringList `json:"auth_method_accessors"`
+	AuthMethodTypes     StringList `json:"auth_method_types"`
+	IdentityGroupIds    StringList `json:"identity_group_ids"`
+	IdentityEntityIds   StringList `json:"identity_entity_ids"`
+}
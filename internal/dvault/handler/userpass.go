@@ -0,0 +1,154 @@
+package handler
+
+import (
+	"encoding/json"
+	"errors"
+	"io"
+	"net/http"
+
+	"github.com/Burzich/dvault/internal/dvault"
+	"github.com/go-chi/chi/v5"
+)
+
+func (h Handler) ListUserpassUsers(w http.ResponseWriter, r *http.Request) {
+	mount := chi.URLParam(r, "auth_mount")
+
+	response, err := h.dVault.ListUserpassUsers(r.Context(), mount)
+	if err != nil {
+		h.handleError(w, r, err)
+		return
+	}
+
+	if err := json.NewEncoder(w).Encode(response); err != nil {
+		w.WriteHeader(http.StatusInternalServerError)
+		return
+	}
+}
+
+func (h Handler) GetUserpassUser(w http.ResponseWriter, r *http.Request) {
+	mount := chi.URLParam(r, "auth_mount")
+	username := chi.URLParam(r, "username")
+
+	response, err := h.dVault.GetUserpassUser(r.Context(), mount, username)
+	if err != nil {
+		h.handleError(w, r, err)
+		return
+	}
+
+	if err := json.NewEncoder(w).Encode(response); err != nil {
+		w.WriteHeader(http.StatusInternalServerError)
+		return
+	}
+}
+
+func (h Handler) SaveUserpassUser(w http.ResponseWriter, r *http.Request) {
+	mount := chi.URLParam(r, "auth_mount")
+	username := chi.URLParam(r, "username")
+
+	var user UserpassUserRequest
+	if err := json.NewDecoder(r.Body).Decode(&user); err != nil && !errors.Is(err, io.EOF) {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	response, err := h.dVault.SaveUserpassUser(r.Context(), mount, username, dvault.UserpassUser{
+		Password:    user.Password,
+		TokenParams: user.tokenParams(),
+	})
+	if err != nil {
+		h.handleError(w, r, err)
+		return
+	}
+
+	if err := json.NewEncoder(w).Encode(response); err != nil {
+		w.WriteHeader(http.StatusInternalServerError)
+		return
+	}
+}
+
+func (h Handler) DeleteUserpassUser(w http.ResponseWriter, r *http.Request) {
+	mount := chi.URLParam(r, "auth_mount")
+	username := chi.URLParam(r, "username")
+
+	response, err := h.dVault.DeleteUserpassUser(r.Context(), mount, username)
+	if err != nil {
+		h.handleError(w, r, err)
+		return
+	}
+
+	if err := json.NewEncoder(w).Encode(response); err != nil {
+		w.WriteHeader(http.StatusInternalServerError)
+		return
+	}
+}
+
+func (h Handler) UpdateUserpassPassword(w http.ResponseWriter, r *http.Request) {
+	mount := chi.URLParam(r, "auth_mount")
+	username := chi.URLParam(r, "username")
+
+	var password UserpassPasswordRequest
+	if err := json.NewDecoder(r.Body).Decode(&password); err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	response, err := h.dVault.UpdateUserpassPassword(r.Context(), mount, username, password.Password)
+	if err != nil {
+		h.handleError(w, r, err)
+		return
+	}
+
+	if err := json.NewEncoder(w).Encode(response); err != nil {
+		w.WriteHeader(http.StatusInternalServerError)
+		return
+	}
+}
+
+func (h Handler) UpdateUserpassPolicies(w http.ResponseWriter, r *http.Request) {
+	mount := chi.URLParam(r, "auth_mount")
+	username := chi.URLParam(r, "username")
+
+	var policies UserpassPoliciesRequest
+	if err := json.NewDecoder(r.Body).Decode(&policies); err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	tokenPolicies := policies.TokenPolicies
+	if len(tokenPolicies) == 0 {
+		tokenPolicies = policies.Policies
+	}
+
+	response, err := h.dVault.UpdateUserpassPolicies(r.Context(), mount, username, tokenPolicies)
+	if err != nil {
+		h.handleError(w, r, err)
+		return
+	}
+
+	if err := json.NewEncoder(w).Encode(response); err != nil {
+		w.WriteHeader(http.StatusInternalServerError)
+		return
+	}
+}
+
+func (h Handler) LoginUserpass(w http.ResponseWriter, r *http.Request) {
+	mount := chi.URLParam(r, "auth_mount")
+	username := chi.URLParam(r, "username")
+
+	var login UserpassPasswordRequest
+	if err := json.NewDecoder(r.Body).Decode(&login); err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	response, err := h.dVault.LoginUserpass(r.Context(), mount, username, login.Password)
+	if err != nil {
+		h.handleError(w, r, err)
+		return
+	}
+
+	if err := json.NewEncoder(w).Encode(response); err != nil {
+		w.WriteHeader(http.StatusInternalServerError)
+		return
+	}
+}
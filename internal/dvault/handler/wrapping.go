@@ -0,0 +1,110 @@
+package handler
+
+import (
+	"encoding/json"
+	"errors"
+	"io"
+	"net/http"
+
+	"github.com/Burzich/dvault/internal/dvault"
+)
+
+// Wrap wraps the request body for the TTL given in the X-Vault-Wrap-TTL
+// header, five minutes without it.
+func (h Handler) Wrap(w http.ResponseWriter, r *http.Request) {
+	ttl := dvault.DefaultWrapTTL
+	if header := r.Header.Get("X-Vault-Wrap-TTL"); header != "" {
+		var err error
+		if ttl, err = dvault.ParseWrapTTL(header); err != nil {
+			h.handleError(w, r, err)
+			return
+		}
+	}
+
+	var data map[string]interface{}
+	if err := json.NewDecoder(r.Body).Decode(&data); err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	response, err := h.dVault.Wrap(r.Context(), ttl, data)
+	if err != nil {
+		h.handleError(w, r, err)
+		return
+	}
+
+	if err := json.NewEncoder(w).Encode(response); err != nil {
+		w.WriteHeader(http.StatusInternalServerError)
+		return
+	}
+}
+
+func (h Handler) Unwrap(w http.ResponseWriter, r *http.Request) {
+	id, ok := wrappingToken(w, r)
+	if !ok {
+		return
+	}
+
+	response, err := h.dVault.Unwrap(r.Context(), id)
+	if err != nil {
+		h.handleError(w, r, err)
+		return
+	}
+
+	if err := json.NewEncoder(w).Encode(response); err != nil {
+		w.WriteHeader(http.StatusInternalServerError)
+		return
+	}
+}
+
+func (h Handler) LookupWrapping(w http.ResponseWriter, r *http.Request) {
+	id, ok := wrappingToken(w, r)
+	if !ok {
+		return
+	}
+
+	response, err := h.dVault.LookupWrapping(r.Context(), id)
+	if err != nil {
+		h.handleError(w, r, err)
+		return
+	}
+
+	if err := json.NewEncoder(w).Encode(response); err != nil {
+		w.WriteHeader(http.StatusInternalServerError)
+		return
+	}
+}
+
+func (h Handler) Rewrap(w http.ResponseWriter, r *http.Request) {
+	id, ok := wrappingToken(w, r)
+	if !ok {
+		return
+	}
+
+	response, err := h.dVault.Rewrap(r.Context(), id)
+	if err != nil {
+		h.handleError(w, r, err)
+		return
+	}
+
+	if err := json.NewEncoder(w).Encode(response); err != nil {
+		w.WriteHeader(http.StatusInternalServerError)
+		return
+	}
+}
+
+// wrappingToken reads the wrapping token from the optional body and falls
+// back to the client token, which is how a wrapping token is usually sent.
+func wrappingToken(w http.ResponseWriter, r *http.Request) (string, bool) {
+	var request TokenRequest
+	if err := json.NewDecoder(r.Body).Decode(&request); err != nil && !errors.Is(err, io.EOF) {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return "", false
+	}
+
+	if request.Token != "" {
+		return request.Token, true
+	}
+
+	return r.Header.Get("X-Vault-Token"), true
+}
@@ -0,0 +1,509 @@
+package dvault
+
+import (
+	"context"
+	"errors"
+	"fmt"
+
+	"github.com/Burzich/dvault/internal/dvault/identity"
+	"github.com/Burzich/dvault/internal/tools"
+)
+
+func (d *DVault) ListEntities(ctx context.Context) (Response, error) {
+	return d.listIdentity(func() ([]string, error) {
+		return d.identity.ListEntities(ctx)
+	})
+}
+
+func (d *DVault) ListEntityNames(ctx context.Context) (Response, error) {
+	return d.listIdentity(func() ([]string, error) {
+		return d.identity.ListEntityNames(ctx)
+	})
+}
+
+func (d *DVault) GetEntity(ctx context.Context, id string) (Response, error) {
+	return d.getEntity(ctx, func() (identity.Entity, error) {
+		return d.identity.GetEntity(ctx, id)
+	})
+}
+
+func (d *DVault) GetEntityByName(ctx context.Context, name string) (Response, error) {
+	return d.getEntity(ctx, func() (identity.Entity, error) {
+		return d.identity.GetEntityByName(ctx, name)
+	})
+}
+
+// SaveEntity creates an entity when id is empty and updates the entity with
+// the given id otherwise.
+func (d *DVault) SaveEntity(ctx context.Context, id string, entity Entity) (Response, error) {
+	return d.saveEntity(ctx, entity, func() (identity.Entity, error) {
+		if id == "" {
+			return identity.Entity{}, nil
+		}
+
+		return d.identity.GetEntity(ctx, id)
+	})
+}
+
+// SaveEntityByName creates or updates the entity with the given name.
+func (d *DVault) SaveEntityByName(ctx context.Context, name string, entity Entity) (Response, error) {
+	entity.Name = name
+
+	return d.saveEntity(ctx, entity, func() (identity.Entity, error) {
+		existing, err := d.identity.GetEntityByName(ctx, name)
+		if errors.Is(err, identity.ErrEntityNotFound) {
+			return identity.Entity{}, nil
+		}
+
+		return existing, err
+	})
+}
+
+func (d *DVault) DeleteEntity(ctx context.Context, id string) (Response, error) {
+	return d.deleteIdentity(func() error {
+		if err := d.identity.DeleteEntity(ctx, id); err != nil {
+			return err
+		}
+
+		return d.mfa.DeleteEntitySecrets(ctx, id)
+	})
+}
+
+func (d *DVault) DeleteEntityByName(ctx context.Context, name string) (Response, error) {
+	return d.deleteIdentity(func() error {
+		entity, err := d.identity.GetEntityByName(ctx, name)
+		if err != nil {
+			return err
+		}
+
+		if err = d.identity.DeleteEntity(ctx, entity.ID); err != nil {
+			return err
+		}
+
+		return d.mfa.DeleteEntitySecrets(ctx, entity.ID)
+	})
+}
+
+func (d *DVault) ListEntityAliases(ctx context.Context) (Response, error) {
+	return d.listIdentity(func() ([]string, error) {
+		return d.identity.ListEntityAliases(ctx)
+	})
+}
+
+func (d *DVault) GetEntityAlias(ctx context.Context, id string) (Response, error) {
+	return d.getAlias(func() (identity.Alias, error) {
+		return d.identity.GetEntityAlias(ctx, id)
+	})
+}
+
+// SaveEntityAlias creates an entity alias when id is empty and updates the
+// alias with the given id otherwise.
+func (d *DVault) SaveEntityAlias(ctx context.Context, id string, alias IdentityAlias) (Response, error) {
+	return d.saveAlias(id, alias, func(alias identity.Alias) (identity.Alias, error) {
+		return d.identity.PutEntityAlias(ctx, alias)
+	})
+}
+
+func (d *DVault) DeleteEntityAlias(ctx context.Context, id string) (Response, error) {
+	return d.deleteIdentity(func() error {
+		return d.identity.DeleteEntityAlias(ctx, id)
+	})
+}
+
+func (d *DVault) ListGroups(ctx context.Context) (Response, error) {
+	return d.listIdentity(func() ([]string, error) {
+		return d.identity.ListGroups(ctx)
+	})
+}
+
+func (d *DVault) ListGroupNames(ctx context.Context) (Response, error) {
+	return d.listIdentity(func() ([]string, error) {
+		return d.identity.ListGroupNames(ctx)
+	})
+}
+
+func (d *DVault) GetGroup(ctx context.Context, id string) (Response, error) {
+	return d.getGroup(ctx, func() (identity.Group, error) {
+		return d.identity.GetGroup(ctx, id)
+	})
+}
+
+func (d *DVault) GetGroupByName(ctx context.Context, name string) (Response, error) {
+	return d.getGroup(ctx, func() (identity.Group, error) {
+		return d.identity.GetGroupByName(ctx, name)
+	})
+}
+
+// SaveGroup creates a group when id is empty and updates the group with the
+// given id otherwise.
+func (d *DVault) SaveGroup(ctx context.Context, id string, group Group) (Response, error) {
+	return d.saveGroup(ctx, group, func() (identity.Group, error) {
+		if id == "" {
+			return identity.Group{}, nil
+		}
+
+		return d.identity.GetGroup(ctx, id)
+	})
+}
+
+// SaveGroupByName creates or updates the group with the given name.
+func (d *DVault) SaveGroupByName(ctx context.Context, name string, group Group) (Response, error) {
+	group.Name = name
+
+	return d.saveGroup(ctx, group, func() (identity.Group, error) {
+		existing, err := d.identity.GetGroupByName(ctx, name)
+		if errors.Is(err, identity.ErrGroupNotFound) {
+			return identity.Group{}, nil
+		}
+
+		return existing, err
+	})
+}
+
+func (d *DVault) DeleteGroup(ctx context.Context, id string) (Response, error) {
+	return d.deleteIdentity(func() error {
+		return d.identity.DeleteGroup(ctx, id)
+	})
+}
+
+func (d *DVault) DeleteGroupByName(ctx context.Context, name string) (Response, error) {
+	return d.deleteIdentity(func() error {
+		group, err := d.identity.GetGroupByName(ctx, name)
+		if err != nil {
+			return err
+		}
+
+		return d.identity.DeleteGroup(ctx, group.ID)
+	})
+}
+
+func (d *DVault) ListGroupAliases(ctx context.Context) (Response, error) {
+	return d.listIdentity(func() ([]string, error) {
+		return d.identity.ListGroupAliases(ctx)
+	})
+}
+
+func (d *DVault) GetGroupAlias(ctx context.Context, id string) (Response, error) {
+	return d.getAlias(func() (identity.Alias, error) {
+		return d.identity.GetGroupAlias(ctx, id)
+	})
+}
+
+// SaveGroupAlias creates a group alias when id is empty and updates the alias
+// with the given id otherwise. The alias name is matched against the groups
+// the auth mount reports on login.
+func (d *DVault) SaveGroupAlias(ctx context.Context, id string, alias IdentityAlias) (Response, error) {
+	return d.saveAlias(id, alias, func(alias identity.Alias) (identity.Alias, error) {
+		return d.identity.PutGroupAlias(ctx, alias)
+	})
+}
+
+func (d *DVault) DeleteGroupAlias(ctx context.Context, id string) (Response, error) {
+	return d.deleteIdentity(func() error {
+		return d.identity.DeleteGroupAlias(ctx, id)
+	})
+}
+
+func (d *DVault) listIdentity(list func() ([]string, error)) (Response, error) {
+	d.mu.RLock()
+	defer d.mu.RUnlock()
+
+	if d.isSealed {
+		return Response{}, ErrSealed
+	}
+
+	keys, err := list()
+	if err != nil {
+		return Response{}, err
+	}
+
+	var response Response
+	response.Data = KeyList{Keys: keys}
+	response.MountType = "identity"
+	response.RequestId = tools.GenerateXRequestID()
+
+	return response, nil
+}
+
+func (d *DVault) deleteIdentity(del func() error) (Response, error) {
+	d.mu.RLock()
+	defer d.mu.RUnlock()
+
+	if d.isSealed {
+		return Response{}, ErrSealed
+	}
+
+	if err := del(); err != nil {
+		return Response{}, err
+	}
+
+	var response Response
+	response.MountType = "identity"
+	response.RequestId = tools.GenerateXRequestID()
+
+	return response, nil
+}
+
+func (d *DVault) getEntity(ctx context.Context, get func() (identity.Entity, error)) (Response, error) {
+	d.mu.RLock()
+	defer d.mu.RUnlock()
+
+	if d.isSealed {
+		return Response{}, ErrSealed
+	}
+
+	entity, err := get()
+	if err != nil {
+		return Response{}, err
+	}
+
+	aliases, err := d.identity.EntityAliases(ctx, entity.ID)
+	if err != nil {
+		return Response{}, err
+	}
+
+	groups, err := d.identity.EntityGroups(ctx, entity.ID)
+	if err != nil {
+		return Response{}, err
+	}
+
+	data := EntityData{
+		Id:             entity.ID,
+		Name:           entity.Name,
+		Policies:       entity.Policies,
+		Metadata:       entity.Metadata,
+		Disabled:       entity.Disabled,
+		Aliases:        make([]IdentityAliasData, 0, len(aliases)),
+		GroupIds:       make([]string, 0, len(groups)),
+		CreationTime:   entity.CreationTime,
+		LastUpdateTime: entity.LastUpdateTime,
+	}
+	for _, alias := range aliases {
+		data.Aliases = append(data.Aliases, d.identityAliasData(alias))
+	}
+	for _, group := range groups {
+		data.GroupIds = append(data.GroupIds, group.ID)
+	}
+
+	var response Response
+	response.Data = data
+	response.MountType = "identity"
+	response.RequestId = tools.GenerateXRequestID()
+
+	return response, nil
+}
+
+// saveEntity applies the fields set in entity to the entity returned by get,
+// which is empty for a new entity.
+func (d *DVault) saveEntity(ctx context.Context, entity Entity, get func() (identity.Entity, error)) (Response, error) {
+	d.mu.RLock()
+	defer d.mu.RUnlock()
+
+	if d.isSealed {
+		return Response{}, ErrSealed
+	}
+
+	existing, err := get()
+	if err != nil {
+		return Response{}, err
+	}
+
+	if entity.Name != "" {
+		existing.Name = entity.Name
+	}
+	if entity.Policies != nil {
+		existing.Policies = entity.Policies
+	}
+	if entity.Metadata != nil {
+		existing.Metadata = entity.Metadata
+	}
+	if entity.Disabled != nil {
+		existing.Disabled = *entity.Disabled
+	}
+
+	saved, err := d.identity.PutEntity(ctx, existing)
+	if err != nil {
+		return Response{}, err
+	}
+
+	var response Response
+	response.Data = IdentityWriteData{Id: saved.ID, Name: saved.Name}
+	response.MountType = "identity"
+	response.RequestId = tools.GenerateXRequestID()
+
+	return response, nil
+}
+
+func (d *DVault) getGroup(ctx context.Context, get func() (identity.Group, error)) (Response, error) {
+	d.mu.RLock()
+	defer d.mu.RUnlock()
+
+	if d.isSealed {
+		return Response{}, ErrSealed
+	}
+
+	group, err := get()
+	if err != nil {
+		return Response{}, err
+	}
+
+	aliases, err := d.identity.GroupAliases(ctx, group.ID)
+	if err != nil {
+		return Response{}, err
+	}
+
+	data := GroupData{
+		Id:              group.ID,
+		Name:            group.Name,
+		Type:            group.Type,
+		Policies:        group.Policies,
+		MemberEntityIds: group.MemberEntityIDs,
+		MemberGroupIds:  group.MemberGroupIDs,
+		Metadata:        group.Metadata,
+		CreationTime:    group.CreationTime,
+		LastUpdateTime:  group.LastUpdateTime,
+	}
+	if len(aliases) > 0 {
+		alias := d.identityAliasData(aliases[0])
+		data.Alias = &alias
+	}
+
+	var response Response
+	response.Data = data
+	response.MountType = "identity"
+	response.RequestId = tools.GenerateXRequestID()
+
+	return response, nil
+}
+
+// saveGroup applies the fields set in group to the group returned by get,
+// which is empty for a new group.
+func (d *DVault) saveGroup(ctx context.Context, group Group, get func() (identity.Group, error)) (Response, error) {
+	d.mu.RLock()
+	defer d.mu.RUnlock()
+
+	if d.isSealed {
+		return Response{}, ErrSealed
+	}
+
+	existing, err := get()
+	if err != nil {
+		return Response{}, err
+	}
+
+	if group.Name != "" {
+		existing.Name = group.Name
+	}
+	if group.Type != "" {
+		existing.Type = group.Type
+	}
+	if group.Policies != nil {
+		existing.Policies = group.Policies
+	}
+	if group.MemberEntityIds != nil {
+		existing.MemberEntityIDs = group.MemberEntityIds
+	}
+	if group.MemberGroupIds != nil {
+		existing.MemberGroupIDs = group.MemberGroupIds
+	}
+	if group.Metadata != nil {
+		existing.Metadata = group.Metadata
+	}
+
+	saved, err := d.identity.PutGroup(ctx, existing)
+	if err != nil {
+		return Response{}, err
+	}
+
+	var response Response
+	response.Data = IdentityWriteData{Id: saved.ID, Name: saved.Name}
+	response.MountType = "identity"
+	response.RequestId = tools.GenerateXRequestID()
+
+	return response, nil
+}
+
+func (d *DVault) getAlias(get func() (identity.Alias, error)) (Response, error) {
+	d.mu.RLock()
+	defer d.mu.RUnlock()
+
+	if d.isSealed {
+		return Response{}, ErrSealed
+	}
+
+	alias, err := get()
+	if err != nil {
+		return Response{}, err
+	}
+
+	var response Response
+	response.Data = d.identityAliasData(alias)
+	response.MountType = "identity"
+	response.RequestId = tools.GenerateXRequestID()
+
+	return response, nil
+}
+
+// saveAlias checks that the mount accessor of the alias belongs to an
+// enabled auth method before storing it with put.
+func (d *DVault) saveAlias(id string, alias IdentityAlias, put func(identity.Alias) (identity.Alias, error)) (Response, error) {
+	d.mu.RLock()
+	defer d.mu.RUnlock()
+
+	if d.isSealed {
+		return Response{}, ErrSealed
+	}
+
+	if alias.MountAccessor != "" {
+		if _, ok := d.authMountByAccessor(alias.MountAccessor); !ok {
+			return Response{}, fmt.Errorf("%w: unknown mount accessor %q", identity.ErrInvalidIdentity, alias.MountAccessor)
+		}
+	}
+
+	saved, err := put(identity.Alias{
+		ID:            id,
+		CanonicalID:   alias.CanonicalId,
+		MountAccessor: alias.MountAccessor,
+		Name:          alias.Name,
+		Metadata:      alias.Metadata,
+	})
+	if err != nil {
+		return Response{}, err
+	}
+
+	var response Response
+	response.Data = IdentityAliasWriteData{Id: saved.ID, CanonicalId: saved.CanonicalID}
+	response.MountType = "identity"
+	response.RequestId = tools.GenerateXRequestID()
+
+	return response, nil
+}
+
+func (d *DVault) identityAliasData(alias identity.Alias) IdentityAliasData {
+	data := IdentityAliasData{
+		Id:             alias.ID,
+		CanonicalId:    alias.CanonicalID,
+		MountAccessor:  alias.MountAccessor,
+		Name:           alias.Name,
+		Metadata:       alias.Metadata,
+		CreationTime:   alias.CreationTime,
+		LastUpdateTime: alias.LastUpdateTime,
+	}
+
+	if mount, ok := d.authMountByAccessor(alias.MountAccessor); ok {
+		data.MountPath = authPath + "/" + mount.Path + "/"
+		data.MountType = mount.Type
+	}
+
+	return data
+}
+
+func (d *DVault) authMountByAccessor(accessor string) (authMount, bool) {
+	for _, mount := range d.auth {
+		if mount.Accessor == accessor {
+			return mount, true
+		}
+	}
+
+	return authMount{}, false
+}
@@ -0,0 +1,272 @@
+package identity
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"path/filepath"
+	"slices"
+	"time"
+
+	"github.com/Burzich/dvault/internal/dvault/storage"
+	"github.com/google/uuid"
+)
+
+// aliasKind separates entity aliases from group aliases, which are stored
+// the same way.
+type aliasKind string
+
+const (
+	entityAliasKind aliasKind = "entity-alias"
+	groupAliasKind  aliasKind = "group-alias"
+)
+
+func (s *Store) ListEntityAliases(ctx context.Context) ([]string, error) {
+	return s.storage.List(ctx, filepath.Join(s.path, string(entityAliasKind)))
+}
+
+func (s *Store) GetEntityAlias(ctx context.Context, id string) (Alias, error) {
+	return s.getAlias(ctx, entityAliasKind, id)
+}
+
+// PutEntityAlias creates an entity alias when its ID is empty and updates
+// the existing alias otherwise. An entity has at most one alias per mount.
+func (s *Store) PutEntityAlias(ctx context.Context, alias Alias) (Alias, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	return s.putAlias(ctx, entityAliasKind, alias)
+}
+
+func (s *Store) DeleteEntityAlias(ctx context.Context, id string) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	alias, err := s.getAlias(ctx, entityAliasKind, id)
+	if err != nil {
+		return err
+	}
+
+	return s.deleteAlias(ctx, entityAliasKind, alias)
+}
+
+func (s *Store) ListGroupAliases(ctx context.Context) ([]string, error) {
+	return s.storage.List(ctx, filepath.Join(s.path, string(groupAliasKind)))
+}
+
+func (s *Store) GetGroupAlias(ctx context.Context, id string) (Alias, error) {
+	return s.getAlias(ctx, groupAliasKind, id)
+}
+
+// PutGroupAlias creates a group alias when its ID is empty and updates the
+// existing alias otherwise. Only external groups have an alias, at most one.
+func (s *Store) PutGroupAlias(ctx context.Context, alias Alias) (Alias, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	return s.putAlias(ctx, groupAliasKind, alias)
+}
+
+func (s *Store) DeleteGroupAlias(ctx context.Context, id string) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	alias, err := s.getAlias(ctx, groupAliasKind, id)
+	if err != nil {
+		return err
+	}
+
+	return s.deleteAlias(ctx, groupAliasKind, alias)
+}
+
+// EntityAliases returns the aliases of the entity, one per auth mount.
+func (s *Store) EntityAliases(ctx context.Context, entityID string) ([]Alias, error) {
+	return s.aliasesOf(ctx, entityAliasKind, entityID)
+}
+
+// GroupAliases returns the alias of the group, if it has one.
+func (s *Store) GroupAliases(ctx context.Context, groupID string) ([]Alias, error) {
+	return s.aliasesOf(ctx, groupAliasKind, groupID)
+}
+
+func (s *Store) getAlias(ctx context.Context, kind aliasKind, id string) (Alias, error) {
+	if !validID(id) {
+		return Alias{}, ErrAliasNotFound
+	}
+
+	var alias Alias
+	err := s.read(ctx, s.aliasPath(kind, id), &alias)
+	if errors.Is(err, storage.ErrPathNotFound) {
+		return Alias{}, ErrAliasNotFound
+	}
+	if err != nil {
+		return Alias{}, err
+	}
+
+	return alias, nil
+}
+
+func (s *Store) aliasByName(ctx context.Context, kind aliasKind, accessor string, name string) (Alias, error) {
+	var id string
+	err := s.read(ctx, s.aliasIndexPath(kind, accessor, name), &id)
+	if errors.Is(err, storage.ErrPathNotFound) {
+		return Alias{}, ErrAliasNotFound
+	}
+	if err != nil {
+		return Alias{}, err
+	}
+
+	return s.getAlias(ctx, kind, id)
+}
+
+func (s *Store) listAliases(ctx context.Context, kind aliasKind) ([]Alias, error) {
+	ids, err := s.storage.List(ctx, filepath.Join(s.path, string(kind)))
+	if err != nil {
+		return nil, err
+	}
+
+	aliases := make([]Alias, 0, len(ids))
+	for _, id := range ids {
+		alias, err := s.getAlias(ctx, kind, id)
+		if errors.Is(err, ErrAliasNotFound) {
+			continue
+		}
+		if err != nil {
+			return nil, err
+		}
+		aliases = append(aliases, alias)
+	}
+
+	return aliases, nil
+}
+
+func (s *Store) aliasesOf(ctx context.Context, kind aliasKind, canonicalID string) ([]Alias, error) {
+	aliases, err := s.listAliases(ctx, kind)
+	if err != nil {
+		return nil, err
+	}
+
+	return slices.DeleteFunc(aliases, func(alias Alias) bool {
+		return alias.CanonicalID != canonicalID
+	}), nil
+}
+
+func (s *Store) putAlias(ctx context.Context, kind aliasKind, alias Alias) (Alias, error) {
+	now := time.Now()
+
+	var old Alias
+	if alias.ID == "" {
+		alias.ID = uuid.NewString()
+		alias.CreationTime = now
+	} else {
+		var err error
+		old, err = s.getAlias(ctx, kind, alias.ID)
+		if err != nil {
+			return Alias{}, err
+		}
+
+		alias.CreationTime = old.CreationTime
+		if alias.CanonicalID == "" {
+			alias.CanonicalID = old.CanonicalID
+		}
+		if alias.MountAccessor == "" {
+			alias.MountAccessor = old.MountAccessor
+		}
+		if alias.Name == "" {
+			alias.Name = old.Name
+		}
+	}
+	alias.LastUpdateTime = now
+
+	if alias.Name == "" || alias.MountAccessor == "" || alias.CanonicalID == "" {
+		return Alias{}, fmt.Errorf("%w: name, mount_accessor and canonical_id are required", ErrInvalidIdentity)
+	}
+
+	if err := s.validateCanonical(ctx, kind, alias); err != nil {
+		return Alias{}, err
+	}
+
+	if alias.Name != old.Name || alias.MountAccessor != old.MountAccessor {
+		other, err := s.aliasByName(ctx, kind, alias.MountAccessor, alias.Name)
+		if err == nil && other.ID != alias.ID {
+			return Alias{}, fmt.Errorf("%w: alias %q already exists for this mount", ErrInvalidIdentity, alias.Name)
+		}
+		if err != nil && !errors.Is(err, ErrAliasNotFound) {
+			return Alias{}, err
+		}
+
+		if err = s.write(ctx, s.aliasIndexPath(kind, alias.MountAccessor, alias.Name), alias.ID); err != nil {
+			return Alias{}, err
+		}
+
+		if old.Name != "" {
+			if err = s.delete(ctx, s.aliasIndexPath(kind, old.MountAccessor, old.Name)); err != nil {
+				return Alias{}, err
+			}
+		}
+	}
+
+	if err := s.write(ctx, s.aliasPath(kind, alias.ID), alias); err != nil {
+		return Alias{}, err
+	}
+
+	return alias, nil
+}
+
+// validateCanonical checks that the alias points to an entity without
+// another alias in the same mount, or to an external group without another
+// alias.
+func (s *Store) validateCanonical(ctx context.Context, kind aliasKind, alias Alias) error {
+	switch kind {
+	case entityAliasKind:
+		if _, err := s.getEntity(ctx, alias.CanonicalID); err != nil {
+			return fmt.Errorf("%w: canonical entity: %s", ErrInvalidIdentity, err)
+		}
+	case groupAliasKind:
+		group, err := s.getGroup(ctx, alias.CanonicalID)
+		if err != nil {
+			return fmt.Errorf("%w: canonical group: %s", ErrInvalidIdentity, err)
+		}
+
+		if group.Type != GroupTypeExternal {
+			return fmt.Errorf("%w: aliases can only be created for external groups", ErrInvalidIdentity)
+		}
+	}
+
+	aliases, err := s.listAliases(ctx, kind)
+	if err != nil {
+		return err
+	}
+
+	for _, other := range aliases {
+		if other.ID == alias.ID || other.CanonicalID != alias.CanonicalID {
+			continue
+		}
+
+		if kind == groupAliasKind {
+			return fmt.Errorf("%w: group already has an alias", ErrInvalidIdentity)
+		}
+
+		if other.MountAccessor == alias.MountAccessor {
+			return fmt.Errorf("%w: entity already has an alias for this mount", ErrInvalidIdentity)
+		}
+	}
+
+	return nil
+}
+
+func (s *Store) deleteAlias(ctx context.Context, kind aliasKind, alias Alias) error {
+	if err := s.delete(ctx, s.aliasIndexPath(kind, alias.MountAccessor, alias.Name)); err != nil {
+		return err
+	}
+
+	return s.delete(ctx, s.aliasPath(kind, alias.ID))
+}
+
+func (s *Store) aliasPath(kind aliasKind, id string) string {
+	return filepath.Join(s.path, string(kind), id)
+}
+
+func (s *Store) aliasIndexPath(kind aliasKind, accessor string, name string) string {
+	return filepath.Join(s.path, string(kind)+"-index", hashKey(accessor, name))
+}
@@ -0,0 +1,180 @@
+package identity
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"path/filepath"
+	"slices"
+	"time"
+
+	"github.com/Burzich/dvault/internal/dvault/storage"
+	"github.com/google/uuid"
+)
+
+func (s *Store) ListEntities(ctx context.Context) ([]string, error) {
+	return s.storage.List(ctx, filepath.Join(s.path, "entity"))
+}
+
+func (s *Store) ListEntityNames(ctx context.Context) ([]string, error) {
+	ids, err := s.ListEntities(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	names := make([]string, 0, len(ids))
+	for _, id := range ids {
+		entity, err := s.GetEntity(ctx, id)
+		if errors.Is(err, ErrEntityNotFound) {
+			continue
+		}
+		if err != nil {
+			return nil, err
+		}
+		names = append(names, entity.Name)
+	}
+
+	slices.Sort(names)
+
+	return names, nil
+}
+
+func (s *Store) GetEntity(ctx context.Context, id string) (Entity, error) {
+	return s.getEntity(ctx, id)
+}
+
+func (s *Store) GetEntityByName(ctx context.Context, name string) (Entity, error) {
+	var id string
+	err := s.read(ctx, s.entityNamePath(name), &id)
+	if errors.Is(err, storage.ErrPathNotFound) {
+		return Entity{}, ErrEntityNotFound
+	}
+	if err != nil {
+		return Entity{}, err
+	}
+
+	return s.getEntity(ctx, id)
+}
+
+// PutEntity creates an entity when its ID is empty and updates the existing
+// entity otherwise. New entities without a name get a generated one.
+func (s *Store) PutEntity(ctx context.Context, entity Entity) (Entity, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	return s.putEntity(ctx, entity)
+}
+
+// DeleteEntity deletes the entity with its aliases and removes it from all
+// groups.
+func (s *Store) DeleteEntity(ctx context.Context, id string) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	entity, err := s.getEntity(ctx, id)
+	if err != nil {
+		return err
+	}
+
+	aliases, err := s.aliasesOf(ctx, entityAliasKind, id)
+	if err != nil {
+		return err
+	}
+
+	for _, alias := range aliases {
+		if err = s.deleteAlias(ctx, entityAliasKind, alias); err != nil {
+			return err
+		}
+	}
+
+	if err = s.removeMember(ctx, func(g *Group) bool {
+		n := len(g.MemberEntityIDs)
+		g.MemberEntityIDs = slices.DeleteFunc(g.MemberEntityIDs, func(member string) bool { return member == id })
+		return len(g.MemberEntityIDs) != n
+	}); err != nil {
+		return err
+	}
+
+	if err = s.delete(ctx, s.entityNamePath(entity.Name)); err != nil {
+		return err
+	}
+
+	return s.delete(ctx, s.entityPath(id))
+}
+
+func (s *Store) getEntity(ctx context.Context, id string) (Entity, error) {
+	if !validID(id) {
+		return Entity{}, ErrEntityNotFound
+	}
+
+	var entity Entity
+	err := s.read(ctx, s.entityPath(id), &entity)
+	if errors.Is(err, storage.ErrPathNotFound) {
+		return Entity{}, ErrEntityNotFound
+	}
+	if err != nil {
+		return Entity{}, err
+	}
+
+	return entity, nil
+}
+
+func (s *Store) putEntity(ctx context.Context, entity Entity) (Entity, error) {
+	now := time.Now()
+
+	var old Entity
+	if entity.ID == "" {
+		entity.ID = uuid.NewString()
+		entity.CreationTime = now
+		if entity.Name == "" {
+			entity.Name = "entity_" + entity.ID[:8]
+		}
+	} else {
+		var err error
+		old, err = s.getEntity(ctx, entity.ID)
+		if err != nil {
+			return Entity{}, err
+		}
+
+		entity.CreationTime = old.CreationTime
+		if entity.Name == "" {
+			entity.Name = old.Name
+		}
+	}
+	entity.LastUpdateTime = now
+
+	if entity.Name != old.Name {
+		var owner string
+		err := s.read(ctx, s.entityNamePath(entity.Name), &owner)
+		if err == nil && owner != entity.ID {
+			return Entity{}, fmt.Errorf("%w: entity name %q is already in use", ErrInvalidIdentity, entity.Name)
+		}
+		if err != nil && !errors.Is(err, storage.ErrPathNotFound) {
+			return Entity{}, err
+		}
+
+		if err = s.write(ctx, s.entityNamePath(entity.Name), entity.ID); err != nil {
+			return Entity{}, err
+		}
+
+		if old.Name != "" {
+			if err = s.delete(ctx, s.entityNamePath(old.Name)); err != nil {
+				return Entity{}, err
+			}
+		}
+	}
+
+	if err := s.write(ctx, s.entityPath(entity.ID), entity); err != nil {
+		return Entity{}, err
+	}
+
+	return entity, nil
+}
+
+func (s *Store) entityPath(id string) string {
+	return filepath.Join(s.path, "entity", id)
+}
+
+func (s *Store) entityNamePath(name string) string {
+	return filepath.Join(s.path, "entity-name", hashKey(name))
+}
@@ -0,0 +1,9 @@
+package identity
+
+import "errors"
+
+var ErrEntityNotFound = errors.New("entity not found")
+var ErrAliasNotFound = errors.New("alias not found")
+var ErrGroupNotFound = errors.New("group not found")
+var ErrEntityDisabled = errors.New("entity is disabled")
+var ErrInvalidIdentity = errors.New("invalid identity request")
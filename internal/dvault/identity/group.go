@@ -0,0 +1,259 @@
+package identity
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"path/filepath"
+	"slices"
+	"time"
+
+	"github.com/Burzich/dvault/internal/dvault/storage"
+	"github.com/google/uuid"
+)
+
+func (s *Store) ListGroups(ctx context.Context) ([]string, error) {
+	return s.storage.List(ctx, filepath.Join(s.path, "group"))
+}
+
+func (s *Store) ListGroupNames(ctx context.Context) ([]string, error) {
+	ids, err := s.ListGroups(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	names := make([]string, 0, len(ids))
+	for _, id := range ids {
+		group, err := s.GetGroup(ctx, id)
+		if errors.Is(err, ErrGroupNotFound) {
+			continue
+		}
+		if err != nil {
+			return nil, err
+		}
+		names = append(names, group.Name)
+	}
+
+	slices.Sort(names)
+
+	return names, nil
+}
+
+func (s *Store) GetGroup(ctx context.Context, id string) (Group, error) {
+	return s.getGroup(ctx, id)
+}
+
+func (s *Store) GetGroupByName(ctx context.Context, name string) (Group, error) {
+	var id string
+	err := s.read(ctx, s.groupNamePath(name), &id)
+	if errors.Is(err, storage.ErrPathNotFound) {
+		return Group{}, ErrGroupNotFound
+	}
+	if err != nil {
+		return Group{}, err
+	}
+
+	return s.getGroup(ctx, id)
+}
+
+// PutGroup creates a group when its ID is empty and updates the existing
+// group otherwise. The members of external groups can not be set, they are
+// maintained on login.
+func (s *Store) PutGroup(ctx context.Context, group Group) (Group, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	var old Group
+	if group.ID != "" {
+		var err error
+		old, err = s.getGroup(ctx, group.ID)
+		if err != nil {
+			return Group{}, err
+		}
+
+		if group.Type == "" {
+			group.Type = old.Type
+		}
+		if group.Type != old.Type {
+			return Group{}, fmt.Errorf("%w: group type can not be changed", ErrInvalidIdentity)
+		}
+	}
+
+	if group.Type == "" {
+		group.Type = GroupTypeInternal
+	}
+
+	switch group.Type {
+	case GroupTypeInternal:
+	case GroupTypeExternal:
+		if !slices.Equal(group.MemberEntityIDs, old.MemberEntityIDs) {
+			return Group{}, fmt.Errorf("%w: member entities of external groups can not be set", ErrInvalidIdentity)
+		}
+		if len(group.MemberGroupIDs) > 0 {
+			return Group{}, fmt.Errorf("%w: external groups can not have member groups", ErrInvalidIdentity)
+		}
+	default:
+		return Group{}, fmt.Errorf("%w: invalid group type %q", ErrInvalidIdentity, group.Type)
+	}
+
+	for _, id := range group.MemberEntityIDs {
+		if _, err := s.getEntity(ctx, id); err != nil {
+			return Group{}, fmt.Errorf("%w: member entity %q: %s", ErrInvalidIdentity, id, err)
+		}
+	}
+
+	for _, id := range group.MemberGroupIDs {
+		if id == group.ID {
+			return Group{}, fmt.Errorf("%w: group can not be a member of itself", ErrInvalidIdentity)
+		}
+
+		if _, err := s.getGroup(ctx, id); err != nil {
+			return Group{}, fmt.Errorf("%w: member group %q: %s", ErrInvalidIdentity, id, err)
+		}
+	}
+
+	return s.putGroup(ctx, group)
+}
+
+// DeleteGroup deletes the group with its alias and removes it from the
+// groups it is a member of.
+func (s *Store) DeleteGroup(ctx context.Context, id string) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	group, err := s.getGroup(ctx, id)
+	if err != nil {
+		return err
+	}
+
+	aliases, err := s.aliasesOf(ctx, groupAliasKind, id)
+	if err != nil {
+		return err
+	}
+
+	for _, alias := range aliases {
+		if err = s.deleteAlias(ctx, groupAliasKind, alias); err != nil {
+			return err
+		}
+	}
+
+	if err = s.removeMember(ctx, func(g *Group) bool {
+		n := len(g.MemberGroupIDs)
+		g.MemberGroupIDs = slices.DeleteFunc(g.MemberGroupIDs, func(member string) bool { return member == id })
+		return len(g.MemberGroupIDs) != n
+	}); err != nil {
+		return err
+	}
+
+	if err = s.delete(ctx, s.groupNamePath(group.Name)); err != nil {
+		return err
+	}
+
+	return s.delete(ctx, s.groupPath(id))
+}
+
+func (s *Store) getGroup(ctx context.Context, id string) (Group, error) {
+	if !validID(id) {
+		return Group{}, ErrGroupNotFound
+	}
+
+	var group Group
+	err := s.read(ctx, s.groupPath(id), &group)
+	if errors.Is(err, storage.ErrPathNotFound) {
+		return Group{}, ErrGroupNotFound
+	}
+	if err != nil {
+		return Group{}, err
+	}
+
+	return group, nil
+}
+
+func (s *Store) putGroup(ctx context.Context, group Group) (Group, error) {
+	now := time.Now()
+
+	var old Group
+	if group.ID == "" {
+		group.ID = uuid.NewString()
+		group.CreationTime = now
+		if group.Name == "" {
+			group.Name = "group_" + group.ID[:8]
+		}
+	} else {
+		var err error
+		old, err = s.getGroup(ctx, group.ID)
+		if err != nil {
+			return Group{}, err
+		}
+
+		group.CreationTime = old.CreationTime
+		if group.Name == "" {
+			group.Name = old.Name
+		}
+	}
+	group.LastUpdateTime = now
+
+	if group.Name != old.Name {
+		var owner string
+		err := s.read(ctx, s.groupNamePath(group.Name), &owner)
+		if err == nil && owner != group.ID {
+			return Group{}, fmt.Errorf("%w: group name %q is already in use", ErrInvalidIdentity, group.Name)
+		}
+		if err != nil && !errors.Is(err, storage.ErrPathNotFound) {
+			return Group{}, err
+		}
+
+		if err = s.write(ctx, s.groupNamePath(group.Name), group.ID); err != nil {
+			return Group{}, err
+		}
+
+		if old.Name != "" {
+			if err = s.delete(ctx, s.groupNamePath(old.Name)); err != nil {
+				return Group{}, err
+			}
+		}
+	}
+
+	if err := s.write(ctx, s.groupPath(group.ID), group); err != nil {
+		return Group{}, err
+	}
+
+	return group, nil
+}
+
+// removeMember applies remove to every group and stores the groups it
+// changed.
+func (s *Store) removeMember(ctx context.Context, remove func(*Group) bool) error {
+	ids, err := s.ListGroups(ctx)
+	if err != nil {
+		return err
+	}
+
+	for _, id := range ids {
+		group, err := s.getGroup(ctx, id)
+		if errors.Is(err, ErrGroupNotFound) {
+			continue
+		}
+		if err != nil {
+			return err
+		}
+
+		if !remove(&group) {
+			continue
+		}
+
+		if _, err = s.putGroup(ctx, group); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+func (s *Store) groupPath(id string) string {
+	return filepath.Join(s.path, "group", id)
+}
+
+func (s *Store) groupNamePath(name string) string {
+	return filepath.Join(s.path, "group-name", hashKey(name))
+}
@@ -0,0 +1,323 @@
+package identity
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"path/filepath"
+	"slices"
+	"sort"
+	"sync"
+	"time"
+
+	"github.com/Burzich/dvault/internal/dvault/storage"
+	"github.com/Burzich/dvault/internal/tools"
+	"github.com/google/uuid"
+)
+
+const (
+	GroupTypeInternal = "internal"
+	GroupTypeExternal = "external"
+)
+
+// Entity is a client that may log in through several auth methods, one alias
+// per auth mount.
+type Entity struct {
+	ID             string            `json:"id"`
+	Name           string            `json:"name"`
+	Policies       []string          `json:"policies"`
+	Metadata       map[string]string `json:"metadata"`
+	Disabled       bool              `json:"disabled"`
+	CreationTime   time.Time         `json:"creation_time"`
+	LastUpdateTime time.Time         `json:"last_update_time"`
+}
+
+// Alias maps the name a client has in one auth mount, e.g. a userpass
+// username, to an entity or, for group aliases, to an external group.
+type Alias struct {
+	ID             string            `json:"id"`
+	CanonicalID    string            `json:"canonical_id"`
+	MountAccessor  string            `json:"mount_accessor"`
+	Name           string            `json:"name"`
+	Metadata       map[string]string `json:"metadata"`
+	CreationTime   time.Time         `json:"creation_time"`
+	LastUpdateTime time.Time         `json:"last_update_time"`
+}
+
+// Group grants its policies to its members. Members of internal groups are
+// managed explicitly, members of external groups are set on login from the
+// groups an auth method reports, e.g. a JWT groups claim.
+type Group struct {
+	ID              string            `json:"id"`
+	Name            string            `json:"name"`
+	Type            string            `json:"type"`
+	Policies        []string          `json:"policies"`
+	MemberEntityIDs []string          `json:"member_entity_ids"`
+	MemberGroupIDs  []string          `json:"member_group_ids"`
+	Metadata        map[string]string `json:"metadata"`
+	CreationTime    time.Time         `json:"creation_time"`
+	LastUpdateTime  time.Time         `json:"last_update_time"`
+}
+
+// Store keeps entities, groups and their aliases below path.
+type Store struct {
+	path      string
+	storage   storage.Storage
+	encryptor tools.Encryptor
+
+	mu sync.Mutex
+}
+
+func NewStore(path string, s storage.Storage, encryptor tools.Encryptor) *Store {
+	return &Store{
+		path:      path,
+		storage:   s,
+		encryptor: encryptor,
+	}
+}
+
+// Login returns the entity of the alias name in the auth mount with the
+// given accessor. An entity and alias are created on the first login.
+func (s *Store) Login(ctx context.Context, accessor string, name string, metadata map[string]string) (Entity, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	alias, err := s.aliasByName(ctx, entityAliasKind, accessor, name)
+	if err == nil {
+		entity, err := s.getEntity(ctx, alias.CanonicalID)
+		if err != nil {
+			return Entity{}, err
+		}
+
+		if entity.Disabled {
+			return Entity{}, ErrEntityDisabled
+		}
+
+		return entity, nil
+	}
+	if !errors.Is(err, ErrAliasNotFound) {
+		return Entity{}, err
+	}
+
+	entity, err := s.putEntity(ctx, Entity{})
+	if err != nil {
+		return Entity{}, err
+	}
+
+	_, err = s.putAlias(ctx, entityAliasKind, Alias{
+		CanonicalID:   entity.ID,
+		MountAccessor: accessor,
+		Name:          name,
+		Metadata:      metadata,
+	})
+	if err != nil {
+		return Entity{}, err
+	}
+
+	return entity, nil
+}
+
+// SyncExternalGroups sets the membership of the entity in the external
+// groups aliased in the auth mount with the given accessor: it becomes a
+// member of the groups named in groupNames and leaves all others.
+func (s *Store) SyncExternalGroups(ctx context.Context, entityID string, accessor string, groupNames []string) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	aliases, err := s.listAliases(ctx, groupAliasKind)
+	if err != nil {
+		return err
+	}
+
+	for _, alias := range aliases {
+		if alias.MountAccessor != accessor {
+			continue
+		}
+
+		group, err := s.getGroup(ctx, alias.CanonicalID)
+		if errors.Is(err, ErrGroupNotFound) {
+			continue
+		}
+		if err != nil {
+			return err
+		}
+
+		member := slices.Contains(group.MemberEntityIDs, entityID)
+		wanted := slices.Contains(groupNames, alias.Name)
+		if member == wanted {
+			continue
+		}
+
+		if wanted {
+			group.MemberEntityIDs = append(group.MemberEntityIDs, entityID)
+		} else {
+			group.MemberEntityIDs = slices.DeleteFunc(group.MemberEntityIDs, func(id string) bool { return id == entityID })
+		}
+
+		if _, err = s.putGroup(ctx, group); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// Policies returns the policies the entity gets from its own policies and
+// from the groups it is a member of, directly or through nested groups. An
+// entity that no longer exists has no policies.
+func (s *Store) Policies(ctx context.Context, entityID string) ([]string, error) {
+	entity, err := s.GetEntity(ctx, entityID)
+	if errors.Is(err, ErrEntityNotFound) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	if entity.Disabled {
+		return nil, ErrEntityDisabled
+	}
+
+	groups, err := s.EntityGroups(ctx, entityID)
+	if err != nil {
+		return nil, err
+	}
+
+	policies := slices.Clone(entity.Policies)
+	for _, group := range groups {
+		policies = append(policies, group.Policies...)
+	}
+
+	sort.Strings(policies)
+
+	return slices.Compact(policies), nil
+}
+
+// EntityGroups returns the groups the entity is a member of, including the
+// groups these groups are members of.
+func (s *Store) EntityGroups(ctx context.Context, entityID string) ([]Group, error) {
+	ids, err := s.storage.List(ctx, filepath.Join(s.path, "group"))
+	if err != nil {
+		return nil, err
+	}
+
+	all := make([]Group, 0, len(ids))
+	for _, id := range ids {
+		group, err := s.getGroup(ctx, id)
+		if errors.Is(err, ErrGroupNotFound) {
+			continue
+		}
+		if err != nil {
+			return nil, err
+		}
+		all = append(all, group)
+	}
+
+	seen := make(map[string]bool)
+	var result []Group
+
+	var add func(Group)
+	add = func(group Group) {
+		if seen[group.ID] {
+			return
+		}
+		seen[group.ID] = true
+		result = append(result, group)
+
+		for _, parent := range all {
+			if slices.Contains(parent.MemberGroupIDs, group.ID) {
+				add(parent)
+			}
+		}
+	}
+
+	for _, group := range all {
+		if slices.Contains(group.MemberEntityIDs, entityID) {
+			add(group)
+		}
+	}
+
+	return result, nil
+}
+
+// DeleteMountAliases removes all entity and group aliases of an auth mount
+// when the mount is disabled.
+func (s *Store) DeleteMountAliases(ctx context.Context, accessor string) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	for _, kind := range []aliasKind{entityAliasKind, groupAliasKind} {
+		aliases, err := s.listAliases(ctx, kind)
+		if err != nil {
+			return err
+		}
+
+		for _, alias := range aliases {
+			if alias.MountAccessor != accessor {
+				continue
+			}
+
+			if err = s.deleteAlias(ctx, kind, alias); err != nil {
+				return err
+			}
+		}
+	}
+
+	return nil
+}
+
+func (s *Store) read(ctx context.Context, path string, v any) error {
+	data, err := s.storage.Get(ctx, path)
+	if err != nil {
+		return err
+	}
+
+	decryptedData, err := s.encryptor.Decrypt(data)
+	if err != nil {
+		return err
+	}
+
+	return json.Unmarshal(decryptedData, v)
+}
+
+func (s *Store) write(ctx context.Context, path string, v any) error {
+	d, err := json.Marshal(v)
+	if err != nil {
+		return err
+	}
+
+	encryptedData, err := s.encryptor.Encrypt(d)
+	if err != nil {
+		return err
+	}
+
+	return s.storage.Put(ctx, path, encryptedData)
+}
+
+func (s *Store) delete(ctx context.Context, path string) error {
+	err := s.storage.Delete(ctx, path)
+	if errors.Is(err, storage.ErrPathNotFound) {
+		return nil
+	}
+
+	return err
+}
+
+// validID keeps IDs from the API out of storage paths unless they have the
+// format of the IDs generated here.
+func validID(id string) bool {
+	_, err := uuid.Parse(id)
+	return err == nil
+}
+
+func hashKey(parts ...string) string {
+	h := sha256.New()
+	for _, part := range parts {
+		h.Write([]byte(part))
+		h.Write([]byte{0})
+	}
+
+	return hex.EncodeToString(h.Sum(nil))
+}
@@ -0,0 +1,239 @@
+package dvault
+
+import (
+	"context"
+	"path/filepath"
+
+	"github.com/Burzich/dvault/internal/dvault/auth/jwt"
+	"github.com/Burzich/dvault/internal/tools"
+)
+
+func (d *DVault) GetJWTConfig(ctx context.Context, mount string) (Response, error) {
+	d.mu.RLock()
+	defer d.mu.RUnlock()
+
+	if d.isSealed {
+		return Response{}, ErrSealed
+	}
+
+	backend, err := d.jwt(mount)
+	if err != nil {
+		return Response{}, err
+	}
+
+	config, err := backend.GetConfig(ctx)
+	if err != nil {
+		return Response{}, err
+	}
+
+	var response Response
+	response.Data = JWTConfig{
+		JwksUrl:              config.JWKSURL,
+		JwksCaPem:            config.JWKSCAPEM,
+		JwtValidationPubkeys: config.JWTValidationPubKeys,
+		BoundIssuer:          config.BoundIssuer,
+		JwtSupportedAlgs:     config.JWTSupportedAlgs,
+		DefaultRole:          config.DefaultRole,
+	}
+	response.MountType = "jwt"
+	response.RequestId = tools.GenerateXRequestID()
+
+	return response, nil
+}
+
+func (d *DVault) SaveJWTConfig(ctx context.Context, mount string, config JWTConfig) (Response, error) {
+	d.mu.RLock()
+	defer d.mu.RUnlock()
+
+	if d.isSealed {
+		return Response{}, ErrSealed
+	}
+
+	backend, err := d.jwt(mount)
+	if err != nil {
+		return Response{}, err
+	}
+
+	err = backend.PutConfig(ctx, jwt.Config{
+		JWKSURL:              config.JwksUrl,
+		JWKSCAPEM:            config.JwksCaPem,
+		JWTValidationPubKeys: config.JwtValidationPubkeys,
+		BoundIssuer:          config.BoundIssuer,
+		JWTSupportedAlgs:     config.JwtSupportedAlgs,
+		DefaultRole:          config.DefaultRole,
+	})
+	if err != nil {
+		return Response{}, err
+	}
+
+	var response Response
+	response.MountType = "jwt"
+	response.RequestId = tools.GenerateXRequestID()
+
+	return response, nil
+}
+
+func (d *DVault) ListJWTRoles(ctx context.Context, mount string) (Response, error) {
+	d.mu.RLock()
+	defer d.mu.RUnlock()
+
+	if d.isSealed {
+		return Response{}, ErrSealed
+	}
+
+	backend, err := d.jwt(mount)
+	if err != nil {
+		return Response{}, err
+	}
+
+	roles, err := backend.ListRoles(ctx)
+	if err != nil {
+		return Response{}, err
+	}
+
+	var response Response
+	response.Data = KeyList{Keys: roles}
+	response.MountType = "jwt"
+	response.RequestId = tools.GenerateXRequestID()
+
+	return response, nil
+}
+
+func (d *DVault) GetJWTRole(ctx context.Context, mount string, name string) (Response, error) {
+	d.mu.RLock()
+	defer d.mu.RUnlock()
+
+	if d.isSealed {
+		return Response{}, ErrSealed
+	}
+
+	backend, err := d.jwt(mount)
+	if err != nil {
+		return Response{}, err
+	}
+
+	role, err := backend.GetRole(ctx, name)
+	if err != nil {
+		return Response{}, err
+	}
+
+	var response Response
+	response.Data = JWTRoleData{
+		RoleType:         role.RoleType,
+		BoundAudiences:   role.BoundAudiences,
+		BoundSubject:     role.BoundSubject,
+		BoundClaims:      role.BoundClaims,
+		ClaimMappings:    role.ClaimMappings,
+		UserClaim:        role.UserClaim,
+		GroupsClaim:      role.GroupsClaim,
+		ClockSkewLeeway:  int(role.ClockSkewLeeway.Seconds()),
+		ExpirationLeeway: int(role.ExpirationLeeway.Seconds()),
+		NotBeforeLeeway:  int(role.NotBeforeLeeway.Seconds()),
+		TokenParamsData:  tokenParamsData(role.TokenParams),
+	}
+	response.MountType = "jwt"
+	response.RequestId = tools.GenerateXRequestID()
+
+	return response, nil
+}
+
+func (d *DVault) SaveJWTRole(ctx context.Context, mount string, name string, role JWTRole) (Response, error) {
+	d.mu.RLock()
+	defer d.mu.RUnlock()
+
+	if d.isSealed {
+		return Response{}, ErrSealed
+	}
+
+	backend, err := d.jwt(mount)
+	if err != nil {
+		return Response{}, err
+	}
+
+	err = backend.PutRole(ctx, jwt.Role{
+		Name:             name,
+		RoleType:         role.RoleType,
+		BoundAudiences:   role.BoundAudiences,
+		BoundSubject:     role.BoundSubject,
+		BoundClaims:      role.BoundClaims,
+		ClaimMappings:    role.ClaimMappings,
+		UserClaim:        role.UserClaim,
+		GroupsClaim:      role.GroupsClaim,
+		ClockSkewLeeway:  role.ClockSkewLeeway,
+		ExpirationLeeway: role.ExpirationLeeway,
+		NotBeforeLeeway:  role.NotBeforeLeeway,
+		TokenParams:      role.TokenParams,
+	})
+	if err != nil {
+		return Response{}, err
+	}
+
+	var response Response
+	response.MountType = "jwt"
+	response.RequestId = tools.GenerateXRequestID()
+
+	return response, nil
+}
+
+func (d *DVault) DeleteJWTRole(ctx context.Context, mount string, name string) (Response, error) {
+	d.mu.RLock()
+	defer d.mu.RUnlock()
+
+	if d.isSealed {
+		return Response{}, ErrSealed
+	}
+
+	backend, err := d.jwt(mount)
+	if err != nil {
+		return Response{}, err
+	}
+
+	if err = backend.DeleteRole(ctx, name); err != nil {
+		return Response{}, err
+	}
+
+	var response Response
+	response.MountType = "jwt"
+	response.RequestId = tools.GenerateXRequestID()
+
+	return response, nil
+}
+
+// LoginJWT exchanges a signed JWT for a token carrying the token settings of
+// the role. Claims listed in claim_mappings end up in the token metadata.
+func (d *DVault) LoginJWT(ctx context.Context, mount string, role string, rawJWT string) (Response, error) {
+	d.mu.RLock()
+	defer d.mu.RUnlock()
+
+	if d.isSealed {
+		return Response{}, ErrSealed
+	}
+
+	backend, err := d.jwt(mount)
+	if err != nil {
+		return Response{}, err
+	}
+
+	result, err := backend.Login(ctx, role, rawJWT)
+	if err != nil {
+		return Response{}, err
+	}
+
+	meta := map[string]string{"role": result.Role.Name}
+	for k, v := range result.Metadata {
+		if k != "role" {
+			meta[k] = v
+		}
+	}
+
+	return d.issueLoginToken(ctx, login{
+		Mount:       mount,
+		MountType:   "jwt",
+		Path:        filepath.Join(authPath, mount, "login"),
+		DisplayName: "jwt-" + result.User,
+		Alias:       result.User,
+		Groups:      result.Groups,
+		Meta:        meta,
+		Params:      result.Role.TokenParams,
+	})
+}
@@ -0,0 +1,126 @@
+package dvault
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/Burzich/dvault/internal/dvault/auth"
+	"github.com/Burzich/dvault/internal/dvault/token"
+	"github.com/Burzich/dvault/internal/tools"
+)
+
+// login describes the token an auth method issues after it verified the
+// credentials of a client.
+type login struct {
+	Mount       string
+	MountType   string
+	Path        string
+	DisplayName string
+	Alias       string
+	Groups      []string
+	Meta        map[string]string
+	Params      auth.TokenParams
+}
+
+// issueLoginToken creates the token for a successful login. Login tokens are
+// orphans, they are not tied to any token of the caller. The lease TTLs tuned
+// on the auth mount apply when the role does not set its own. The token is
+// bound to the entity of the alias, created on the first login, and is only
+// issued once the MFA required by login enforcements is satisfied.
+func (d *DVault) issueLoginToken(ctx context.Context, l login) (Response, error) {
+	mount := d.auth[l.Mount]
+	config := mount.Config
+
+	entity, err := d.identity.Login(ctx, mount.Accessor, l.Alias, l.Meta)
+	if err != nil {
+		return Response{}, err
+	}
+
+	err = d.identity.SyncExternalGroups(ctx, entity.ID, mount.Accessor, l.Groups)
+	if err != nil {
+		return Response{}, err
+	}
+
+	if err = d.loginMFA(ctx, mount, entity.ID); err != nil {
+		return Response{}, err
+	}
+
+	identityPolicies, err := d.identity.Policies(ctx, entity.ID)
+	if err != nil {
+		return Response{}, err
+	}
+
+	maxTTL := l.Params.TokenMaxTTL
+	if maxTTL == 0 {
+		maxTTL = config.MaxLeaseTTL
+	}
+
+	ttl := l.Params.TokenTTL
+	if ttl == 0 {
+		ttl = config.DefaultLeaseTTL
+	}
+	if ttl == 0 {
+		ttl = token.DefaultTTL
+	}
+	if maxTTL > 0 && ttl > maxTTL {
+		ttl = maxTTL
+	}
+
+	entry := token.Entry{
+		Policies:       sanitizePolicies(l.Params.TokenPolicies, !l.Params.TokenNoDefaultPolicy),
+		Meta:           l.Meta,
+		DisplayName:    l.DisplayName,
+		NumUses:        l.Params.TokenNumUses,
+		Path:           l.Path,
+		Renewable:      true,
+		TTL:            ttl,
+		ExplicitMaxTTL: maxTTL,
+		Period:         l.Params.TokenPeriod,
+		BoundCIDRs:     l.Params.TokenBoundCIDRs,
+		EntityID:       entity.ID,
+	}
+
+	switch l.Params.TokenType {
+	case "", token.TypeService:
+		entry, err = d.tokens.Create(ctx, entry)
+	case token.TypeBatch:
+		entry, err = d.tokens.CreateBatch(entry)
+	default:
+		err = fmt.Errorf("%w %q", token.ErrInvalidType, l.Params.TokenType)
+	}
+	if err != nil {
+		return Response{}, err
+	}
+
+	loginAuth := tokenAuth(entry)
+	loginAuth.IdentityPolicies = identityPolicies
+	loginAuth.Policies = mergePolicies(entry.Policies, identityPolicies)
+
+	var response Response
+	response.Auth = loginAuth
+	response.MountType = l.MountType
+	response.RequestId = tools.GenerateXRequestID()
+
+	return response, nil
+}
+
+func tokenParamsData(p auth.TokenParams) TokenParamsData {
+	return TokenParamsData{
+		TokenPolicies:        p.TokenPolicies,
+		TokenTtl:             int(p.TokenTTL.Seconds()),
+		TokenMaxTtl:          int(p.TokenMaxTTL.Seconds()),
+		TokenPeriod:          int(p.TokenPeriod.Seconds()),
+		TokenNumUses:         p.TokenNumUses,
+		TokenBoundCidrs:      p.TokenBoundCIDRs,
+		TokenType:            tokenTypeOrDefault(p.TokenType),
+		TokenNoDefaultPolicy: p.TokenNoDefaultPolicy,
+	}
+}
+
+func tokenTypeOrDefault(t string) string {
+	if t == "" {
+		return "default"
+	}
+
+	return t
+}
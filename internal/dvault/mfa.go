@@ -0,0 +1,353 @@
+package dvault
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/Burzich/dvault/internal/dvault/mfa"
+	"github.com/Burzich/dvault/internal/tools"
+)
+
+// Defaults of new TOTP methods, the same as in Vault.
+const (
+	defaultTOTPPeriod    = 30 * time.Second
+	defaultTOTPKeySize   = 20
+	defaultTOTPAlgorithm = "SHA1"
+	defaultTOTPDigits    = 6
+	defaultTOTPSkew      = 1
+)
+
+func (d *DVault) ListTOTPMethods(ctx context.Context) (Response, error) {
+	return d.listIdentity(func() ([]string, error) {
+		return d.mfa.ListMethods(ctx)
+	})
+}
+
+func (d *DVault) GetTOTPMethod(ctx context.Context, id string) (Response, error) {
+	d.mu.RLock()
+	defer d.mu.RUnlock()
+
+	if d.isSealed {
+		return Response{}, ErrSealed
+	}
+
+	method, err := d.mfa.GetMethod(ctx, id)
+	if err != nil {
+		return Response{}, err
+	}
+
+	var response Response
+	response.Data = totpMethodData(method)
+	response.MountType = "identity"
+	response.RequestId = tools.GenerateXRequestID()
+
+	return response, nil
+}
+
+// SaveTOTPMethod creates a TOTP method when id is empty and updates the
+// method with the given id otherwise.
+func (d *DVault) SaveTOTPMethod(ctx context.Context, id string, method TOTPMethod) (Response, error) {
+	d.mu.RLock()
+	defer d.mu.RUnlock()
+
+	if d.isSealed {
+		return Response{}, ErrSealed
+	}
+
+	existing := mfa.Method{
+		TOTP: mfa.TOTP{
+			Period:    defaultTOTPPeriod,
+			KeySize:   defaultTOTPKeySize,
+			Algorithm: defaultTOTPAlgorithm,
+			Digits:    defaultTOTPDigits,
+			Skew:      defaultTOTPSkew,
+		},
+	}
+	if id != "" {
+		var err error
+		if existing, err = d.mfa.GetMethod(ctx, id); err != nil {
+			return Response{}, err
+		}
+	}
+
+	if method.MethodName != "" {
+		existing.Name = method.MethodName
+	}
+	if method.Issuer != "" {
+		existing.Issuer = method.Issuer
+	}
+	if method.Period != 0 {
+		existing.Period = method.Period
+	}
+	if method.KeySize != 0 {
+		existing.KeySize = method.KeySize
+	}
+	if method.Algorithm != "" {
+		existing.Algorithm = strings.ToUpper(method.Algorithm)
+	}
+	if method.Digits != 0 {
+		existing.Digits = method.Digits
+	}
+	if method.Skew != nil {
+		existing.Skew = *method.Skew
+	}
+	if method.MaxValidationAttempts != 0 {
+		existing.MaxValidationAttempts = method.MaxValidationAttempts
+	}
+
+	saved, err := d.mfa.PutMethod(ctx, existing)
+	if err != nil {
+		return Response{}, err
+	}
+
+	var response Response
+	response.Data = MFAMethodWriteData{MethodId: saved.ID}
+	response.MountType = "identity"
+	response.RequestId = tools.GenerateXRequestID()
+
+	return response, nil
+}
+
+func (d *DVault) DeleteTOTPMethod(ctx context.Context, id string) (Response, error) {
+	return d.deleteIdentity(func() error {
+		return d.mfa.DeleteMethod(ctx, id)
+	})
+}
+
+// GenerateTOTPSecret enrolls the entity of the calling token in the method.
+// The returned otpauth URL is shown only once.
+func (d *DVault) GenerateTOTPSecret(ctx context.Context, methodID string) (Response, error) {
+	entry, ok := tokenFromContext(ctx)
+	if !ok {
+		return Response{}, ErrPermissionDenied
+	}
+
+	if entry.EntityID == "" {
+		return Response{}, fmt.Errorf("%w: token has no entity", ErrPermissionDenied)
+	}
+
+	return d.AdminGenerateTOTPSecret(ctx, methodID, entry.EntityID)
+}
+
+// AdminGenerateTOTPSecret enrolls any entity in the method, e.g. for users
+// who can not log in yet because login enforcements require the method.
+func (d *DVault) AdminGenerateTOTPSecret(ctx context.Context, methodID string, entityID string) (Response, error) {
+	d.mu.RLock()
+	defer d.mu.RUnlock()
+
+	if d.isSealed {
+		return Response{}, ErrSealed
+	}
+
+	entity, err := d.identity.GetEntity(ctx, entityID)
+	if err != nil {
+		return Response{}, err
+	}
+
+	accountName := entity.Name
+	if accountName == "" {
+		accountName = entity.ID
+	}
+
+	url, err := d.mfa.GenerateSecret(ctx, methodID, entity.ID, accountName)
+	if err != nil {
+		return Response{}, err
+	}
+
+	var response Response
+	response.Data = TOTPSecretData{Url: url}
+	response.MountType = "identity"
+	response.RequestId = tools.GenerateXRequestID()
+
+	return response, nil
+}
+
+// AdminDestroyTOTPSecret removes the key of an entity, so it can enroll again.
+func (d *DVault) AdminDestroyTOTPSecret(ctx context.Context, methodID string, entityID string) (Response, error) {
+	return d.deleteIdentity(func() error {
+		return d.mfa.DestroySecret(ctx, methodID, entityID)
+	})
+}
+
+func (d *DVault) ListLoginEnforcements(ctx context.Context) (Response, error) {
+	return d.listIdentity(func() ([]string, error) {
+		return d.mfa.ListLoginEnforcements(ctx)
+	})
+}
+
+func (d *DVault) GetLoginEnforcement(ctx context.Context, name string) (Response, error) {
+	d.mu.RLock()
+	defer d.mu.RUnlock()
+
+	if d.isSealed {
+		return Response{}, ErrSealed
+	}
+
+	e, err := d.mfa.GetLoginEnforcement(ctx, name)
+	if err != nil {
+		return Response{}, err
+	}
+
+	var response Response
+	response.Data = loginEnforcementData(e)
+	response.MountType = "identity"
+	response.RequestId = tools.GenerateXRequestID()
+
+	return response, nil
+}
+
+func (d *DVault) SaveLoginEnforcement(ctx context.Context, name string, enforcement LoginEnforcement) (Response, error) {
+	d.mu.RLock()
+	defer d.mu.RUnlock()
+
+	if d.isSealed {
+		return Response{}, ErrSealed
+	}
+
+	for _, accessor := range enforcement.AuthMethodAccessors {
+		if _, ok := d.authMountByAccessor(accessor); !ok {
+			return Response{}, fmt.Errorf("%w: unknown auth method accessor %q", mfa.ErrInvalidConfig, accessor)
+		}
+	}
+
+	saved, err := d.mfa.PutLoginEnforcement(ctx, mfa.LoginEnforcement{
+		Name:                name,
+		MFAMethodIDs:        enforcement.MfaMethodIds,
+		AuthMethodAccessors: enforcement.AuthMethodAccessors,
+		AuthMethodTypes:     enforcement.AuthMethodTypes,
+		IdentityGroupIDs:    enforcement.IdentityGroupIds,
+		IdentityEntityIDs:   enforcement.IdentityEntityIds,
+	})
+	if err != nil {
+		return Response{}, err
+	}
+
+	var response Response
+	response.Data = loginEnforcementData(saved)
+	response.MountType = "identity"
+	response.RequestId = tools.GenerateXRequestID()
+
+	return response, nil
+}
+
+func (d *DVault) DeleteLoginEnforcement(ctx context.Context, name string) (Response, error) {
+	return d.deleteIdentity(func() error {
+		return d.mfa.DeleteLoginEnforcement(ctx, name)
+	})
+}
+
+// loginMFA validates the MFA the login enforcements require for a login of
+// the entity through mount.
+func (d *DVault) loginMFA(ctx context.Context, mount authMount, entityID string) error {
+	groups, err := d.identity.EntityGroups(ctx, entityID)
+	if err != nil {
+		return err
+	}
+
+	groupIDs := make([]string, 0, len(groups))
+	for _, group := range groups {
+		groupIDs = append(groupIDs, group.ID)
+	}
+
+	methods, err := d.mfa.LoginMethods(ctx, mount.Accessor, mount.Type, entityID, groupIDs)
+	if err != nil {
+		return err
+	}
+
+	return d.validateMFA(ctx, entityID, methods)
+}
+
+// validateMFA checks that the request carries a valid passcode of the entity
+// for each of the methods, which are given by ID or name.
+func (d *DVault) validateMFA(ctx context.Context, entityID string, methods []string) error {
+	if len(methods) == 0 {
+		return nil
+	}
+
+	if entityID == "" {
+		return fmt.Errorf("%w: %w: token has no entity", ErrPermissionDenied, ErrMFARequired)
+	}
+
+	credentials := mfaCredentials(ctx)
+	for _, ref := range methods {
+		method, err := d.mfa.MethodByRef(ctx, ref)
+		if errors.Is(err, mfa.ErrMethodNotFound) {
+			return fmt.Errorf("%w: %w: unknown method %q", ErrPermissionDenied, ErrMFARequired, ref)
+		}
+		if err != nil {
+			return err
+		}
+
+		passcode, ok := mfaPasscode(credentials, method, len(methods) == 1)
+		if !ok {
+			return fmt.Errorf("%w: %w: no passcode for method %q", ErrPermissionDenied, ErrMFARequired, ref)
+		}
+
+		err = d.mfa.Validate(ctx, method.ID, entityID, passcode)
+		if errors.Is(err, mfa.ErrSecretNotFound) ||
+			errors.Is(err, mfa.ErrInvalidCode) ||
+			errors.Is(err, mfa.ErrCodeUsed) ||
+			errors.Is(err, mfa.ErrTooManyAttempts) {
+			return fmt.Errorf("%w: %w", ErrPermissionDenied, err)
+		}
+		if err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// mfaPasscode picks the passcode for method from the X-Vault-MFA values. A
+// value without a method prefix is accepted if only one method is required.
+func mfaPasscode(credentials []string, method mfa.Method, single bool) (string, bool) {
+	for _, credential := range credentials {
+		ref, passcode, ok := strings.Cut(strings.TrimSpace(credential), ":")
+		if !ok {
+			if single {
+				return ref, true
+			}
+			continue
+		}
+
+		if ref == method.ID || (method.Name != "" && ref == method.Name) {
+			return passcode, true
+		}
+	}
+
+	return "", false
+}
+
+func totpMethodData(method mfa.Method) TOTPMethodData {
+	return TOTPMethodData{
+		Id:                    method.ID,
+		MethodName:            method.Name,
+		Type:                  "totp",
+		Issuer:                method.Issuer,
+		Period:                int(method.Period.Seconds()),
+		KeySize:               method.KeySize,
+		Algorithm:             method.Algorithm,
+		Digits:                method.Digits,
+		Skew:                  method.Skew,
+		MaxValidationAttempts: method.MaxValidationAttempts,
+		CreationTime:          method.CreationTime,
+		LastUpdateTime:        method.LastUpdateTime,
+	}
+}
+
+func loginEnforcementData(e mfa.LoginEnforcement) LoginEnforcementData {
+	return LoginEnforcementData{
+		Id:                  e.ID,
+		Name:                e.Name,
+		MfaMethodIds:        e.MFAMethodIDs,
+		AuthMethodAccessors: e.AuthMethodAccessors,
+		AuthMethodTypes:     e.AuthMethodTypes,
+		IdentityGroupIds:    e.IdentityGroupIDs,
+		IdentityEntityIds:   e.IdentityEntityIDs,
+		CreationTime:        e.CreationTime,
+		LastUpdateTime:      e.LastUpdateTime,
+	}
+}
@@ -0,0 +1,170 @@
+package mfa
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"path/filepath"
+	"slices"
+	"time"
+
+	"github.com/Burzich/dvault/internal/dvault/storage"
+	"github.com/google/uuid"
+)
+
+// LoginEnforcement requires the MFA methods on logins that match any of its
+// auth mounts, auth method types, groups or entities.
+type LoginEnforcement struct {
+	ID                  string    `json:"id"`
+	Name                string    `json:"name"`
+	MFAMethodIDs        []string  `json:"mfa_method_ids"`
+	AuthMethodAccessors []string  `json:"auth_method_accessors"`
+	AuthMethodTypes     []string  `json:"auth_method_types"`
+	IdentityGroupIDs    []string  `json:"identity_group_ids"`
+	IdentityEntityIDs   []string  `json:"identity_entity_ids"`
+	CreationTime        time.Time `json:"creation_time"`
+	LastUpdateTime      time.Time `json:"last_update_time"`
+}
+
+func (e LoginEnforcement) matches(accessor string, mountType string, entityID string, groupIDs []string) bool {
+	return slices.Contains(e.AuthMethodAccessors, accessor) ||
+		slices.Contains(e.AuthMethodTypes, mountType) ||
+		slices.Contains(e.IdentityEntityIDs, entityID) ||
+		slices.ContainsFunc(groupIDs, func(id string) bool {
+			return slices.Contains(e.IdentityGroupIDs, id)
+		})
+}
+
+func (s *Store) ListLoginEnforcements(ctx context.Context) ([]string, error) {
+	return s.storage.List(ctx, filepath.Join(s.path, "login-enforcement"))
+}
+
+func (s *Store) GetLoginEnforcement(ctx context.Context, name string) (LoginEnforcement, error) {
+	return s.getLoginEnforcement(ctx, name)
+}
+
+// PutLoginEnforcement creates or replaces the enforcement with the name of e.
+// Methods may be given by name, they are stored by ID.
+func (s *Store) PutLoginEnforcement(ctx context.Context, e LoginEnforcement) (LoginEnforcement, error) {
+	if !validName.MatchString(e.Name) {
+		return LoginEnforcement{}, fmt.Errorf("%w: invalid enforcement name %q", ErrInvalidConfig, e.Name)
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if len(e.MFAMethodIDs) == 0 {
+		return LoginEnforcement{}, fmt.Errorf("%w: at least one MFA method is required", ErrInvalidConfig)
+	}
+	if len(e.AuthMethodAccessors) == 0 && len(e.AuthMethodTypes) == 0 &&
+		len(e.IdentityGroupIDs) == 0 && len(e.IdentityEntityIDs) == 0 {
+		return LoginEnforcement{}, fmt.Errorf("%w: at least one auth method, group or entity is required", ErrInvalidConfig)
+	}
+
+	methodIDs := make([]string, 0, len(e.MFAMethodIDs))
+	for _, ref := range e.MFAMethodIDs {
+		method, err := s.MethodByRef(ctx, ref)
+		if errors.Is(err, ErrMethodNotFound) {
+			return LoginEnforcement{}, fmt.Errorf("%w: unknown MFA method %q", ErrInvalidConfig, ref)
+		}
+		if err != nil {
+			return LoginEnforcement{}, err
+		}
+		methodIDs = append(methodIDs, method.ID)
+	}
+	slices.Sort(methodIDs)
+	e.MFAMethodIDs = slices.Compact(methodIDs)
+
+	now := time.Now()
+	old, err := s.getLoginEnforcement(ctx, e.Name)
+	switch {
+	case err == nil:
+		e.ID = old.ID
+		e.CreationTime = old.CreationTime
+	case errors.Is(err, ErrEnforcementNotFound):
+		e.ID = uuid.NewString()
+		e.CreationTime = now
+	default:
+		return LoginEnforcement{}, err
+	}
+	e.LastUpdateTime = now
+
+	if err = s.write(ctx, s.loginEnforcementPath(e.Name), e); err != nil {
+		return LoginEnforcement{}, err
+	}
+
+	return e, nil
+}
+
+func (s *Store) DeleteLoginEnforcement(ctx context.Context, name string) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if _, err := s.getLoginEnforcement(ctx, name); err != nil {
+		return err
+	}
+
+	return s.delete(ctx, s.loginEnforcementPath(name))
+}
+
+// LoginMethods returns the IDs of the methods required for a login through
+// the auth mount with the given accessor and type by the entity, which is a
+// member of the groups.
+func (s *Store) LoginMethods(ctx context.Context, accessor string, mountType string, entityID string, groupIDs []string) ([]string, error) {
+	enforcements, err := s.listLoginEnforcements(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	var methodIDs []string
+	for _, enforcement := range enforcements {
+		if enforcement.matches(accessor, mountType, entityID, groupIDs) {
+			methodIDs = append(methodIDs, enforcement.MFAMethodIDs...)
+		}
+	}
+	slices.Sort(methodIDs)
+
+	return slices.Compact(methodIDs), nil
+}
+
+func (s *Store) getLoginEnforcement(ctx context.Context, name string) (LoginEnforcement, error) {
+	if !validName.MatchString(name) {
+		return LoginEnforcement{}, ErrEnforcementNotFound
+	}
+
+	var e LoginEnforcement
+	err := s.read(ctx, s.loginEnforcementPath(name), &e)
+	if errors.Is(err, storage.ErrPathNotFound) {
+		return LoginEnforcement{}, ErrEnforcementNotFound
+	}
+	if err != nil {
+		return LoginEnforcement{}, err
+	}
+
+	return e, nil
+}
+
+func (s *Store) listLoginEnforcements(ctx context.Context) ([]LoginEnforcement, error) {
+	names, err := s.storage.List(ctx, filepath.Join(s.path, "login-enforcement"))
+	if err != nil {
+		return nil, err
+	}
+
+	enforcements := make([]LoginEnforcement, 0, len(names))
+	for _, name := range names {
+		e, err := s.getLoginEnforcement(ctx, name)
+		if errors.Is(err, ErrEnforcementNotFound) {
+			continue
+		}
+		if err != nil {
+			return nil, err
+		}
+		enforcements = append(enforcements, e)
+	}
+
+	return enforcements, nil
+}
+
+func (s *Store) loginEnforcementPath(name string) string {
+	return filepath.Join(s.path, "login-enforcement", name)
+}
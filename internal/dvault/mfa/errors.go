@@ -0,0 +1,13 @@
+package mfa
+
+import "errors"
+
+var ErrMethodNotFound = errors.New("MFA method not found")
+var ErrEnforcementNotFound = errors.New("login enforcement not found")
+var ErrInvalidConfig = errors.New("invalid MFA configuration")
+var ErrMethodInUse = errors.New("MFA method is used by a login enforcement")
+var ErrSecretExists = errors.New("entity already has a secret for the MFA method")
+var ErrSecretNotFound = errors.New("entity has no secret for the MFA method")
+var ErrInvalidCode = errors.New("invalid MFA passcode")
+var ErrCodeUsed = errors.New("MFA passcode already used")
+var ErrTooManyAttempts = errors.New("maximum MFA validation attempts exceeded")
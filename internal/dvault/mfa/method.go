@@ -0,0 +1,157 @@
+package mfa
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"path/filepath"
+	"slices"
+	"time"
+
+	"github.com/Burzich/dvault/internal/dvault/storage"
+	"github.com/google/uuid"
+)
+
+func (s *Store) ListMethods(ctx context.Context) ([]string, error) {
+	return s.storage.List(ctx, filepath.Join(s.path, "method"))
+}
+
+func (s *Store) GetMethod(ctx context.Context, id string) (Method, error) {
+	return s.getMethod(ctx, id)
+}
+
+// MethodByRef returns the method with the ID or name ref. Policies and
+// X-Vault-MFA headers may refer to methods either way.
+func (s *Store) MethodByRef(ctx context.Context, ref string) (Method, error) {
+	if validID(ref) {
+		return s.getMethod(ctx, ref)
+	}
+
+	return s.methodByName(ctx, ref)
+}
+
+// PutMethod creates a method when its ID is empty and updates the existing
+// method otherwise. Keys that were already generated keep the parameters of
+// the method at the time.
+func (s *Store) PutMethod(ctx context.Context, method Method) (Method, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	now := time.Now()
+	if method.ID == "" {
+		method.ID = uuid.NewString()
+		method.CreationTime = now
+	} else {
+		old, err := s.getMethod(ctx, method.ID)
+		if err != nil {
+			return Method{}, err
+		}
+		method.CreationTime = old.CreationTime
+	}
+	method.LastUpdateTime = now
+
+	if method.MaxValidationAttempts == 0 {
+		method.MaxValidationAttempts = DefaultMaxValidationAttempts
+	}
+
+	if err := method.TOTP.validate(); err != nil {
+		return Method{}, err
+	}
+	if method.MaxValidationAttempts < 0 {
+		return Method{}, fmt.Errorf("%w: max_validation_attempts can not be negative", ErrInvalidConfig)
+	}
+
+	if method.Name != "" {
+		if !validName.MatchString(method.Name) || validID(method.Name) {
+			return Method{}, fmt.Errorf("%w: invalid method name %q", ErrInvalidConfig, method.Name)
+		}
+
+		other, err := s.methodByName(ctx, method.Name)
+		if err == nil && other.ID != method.ID {
+			return Method{}, fmt.Errorf("%w: method %q already exists", ErrInvalidConfig, method.Name)
+		}
+		if err != nil && !errors.Is(err, ErrMethodNotFound) {
+			return Method{}, err
+		}
+	}
+
+	if err := s.write(ctx, s.methodPath(method.ID), method); err != nil {
+		return Method{}, err
+	}
+
+	return method, nil
+}
+
+// DeleteMethod deletes the method together with the keys of all entities.
+// Methods still required by a login enforcement can not be deleted.
+func (s *Store) DeleteMethod(ctx context.Context, id string) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	method, err := s.getMethod(ctx, id)
+	if err != nil {
+		return err
+	}
+
+	enforcements, err := s.listLoginEnforcements(ctx)
+	if err != nil {
+		return err
+	}
+
+	for _, enforcement := range enforcements {
+		if slices.Contains(enforcement.MFAMethodIDs, method.ID) {
+			return fmt.Errorf("%w %q", ErrMethodInUse, enforcement.Name)
+		}
+	}
+
+	err = storage.DeleteTree(ctx, s.storage, filepath.Join(s.path, "secret", method.ID))
+	if err != nil {
+		return err
+	}
+
+	return s.delete(ctx, s.methodPath(method.ID))
+}
+
+func (s *Store) getMethod(ctx context.Context, id string) (Method, error) {
+	if !validID(id) {
+		return Method{}, ErrMethodNotFound
+	}
+
+	var method Method
+	err := s.read(ctx, s.methodPath(id), &method)
+	if errors.Is(err, storage.ErrPathNotFound) {
+		return Method{}, ErrMethodNotFound
+	}
+	if err != nil {
+		return Method{}, err
+	}
+
+	return method, nil
+}
+
+func (s *Store) methodByName(ctx context.Context, name string) (Method, error) {
+	ids, err := s.storage.List(ctx, filepath.Join(s.path, "method"))
+	if err != nil {
+		return Method{}, err
+	}
+
+	for _, id := range ids {
+		method, err := s.getMethod(ctx, id)
+		if errors.Is(err, ErrMethodNotFound) {
+			continue
+		}
+		if err != nil {
+			return Method{}, err
+		}
+
+		if method.Name == name {
+			return method, nil
+		}
+	}
+
+	return Method{}, ErrMethodNotFound
+}
+
+func (s *Store) methodPath(id string) string {
+	return filepath.Join(s.path, "method", id)
+}
@@ -0,0 +1,269 @@
+package mfa
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"path/filepath"
+	"regexp"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/Burzich/dvault/internal/dvault/storage"
+	"github.com/Burzich/dvault/internal/tools"
+	"github.com/google/uuid"
+)
+
+// DefaultMaxValidationAttempts is the number of consecutive wrong passcodes
+// after which an entity has to wait for the next period.
+const DefaultMaxValidationAttempts = 5
+
+var validName = regexp.MustCompile(`^[a-zA-Z0-9][a-zA-Z0-9_.-]*$`)
+
+// Method is a TOTP MFA method. Entities enroll in it by generating a key,
+// which they add to their authenticator app.
+type Method struct {
+	ID   string `json:"id"`
+	Name string `json:"name"`
+	TOTP
+	MaxValidationAttempts int       `json:"max_validation_attempts"`
+	CreationTime          time.Time `json:"creation_time"`
+	LastUpdateTime        time.Time `json:"last_update_time"`
+}
+
+// secret is the TOTP key of an entity for a method.
+type secret struct {
+	MethodID    string `json:"method_id"`
+	EntityID    string `json:"entity_id"`
+	AccountName string `json:"account_name"`
+	Key         string `json:"key"`
+	TOTP
+}
+
+// attempts counts the wrong passcodes of an entity for a method.
+type attempts struct {
+	count int
+	last  time.Time
+}
+
+// Store keeps MFA methods, the keys entities enrolled with and the login
+// enforcements below path. Used passcodes and failed attempts are only
+// tracked in memory.
+type Store struct {
+	path      string
+	storage   storage.Storage
+	encryptor tools.Encryptor
+
+	mu       sync.Mutex
+	used     map[string]time.Time
+	attempts map[string]attempts
+}
+
+func NewStore(path string, s storage.Storage, encryptor tools.Encryptor) *Store {
+	return &Store{
+		path:      path,
+		storage:   s,
+		encryptor: encryptor,
+		used:      make(map[string]time.Time),
+		attempts:  make(map[string]attempts),
+	}
+}
+
+// GenerateSecret creates the key of the entity for the method and returns the
+// otpauth URL of the key. An entity has to destroy its key before it can get
+// a new one.
+func (s *Store) GenerateSecret(ctx context.Context, methodID string, entityID string, accountName string) (string, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	method, err := s.getMethod(ctx, methodID)
+	if err != nil {
+		return "", err
+	}
+
+	if !validID(entityID) {
+		return "", fmt.Errorf("%w: invalid entity ID %q", ErrInvalidConfig, entityID)
+	}
+
+	_, err = s.readSecret(ctx, method.ID, entityID)
+	if err == nil {
+		return "", ErrSecretExists
+	}
+	if !errors.Is(err, ErrSecretNotFound) {
+		return "", err
+	}
+
+	key, err := generateKey(method.KeySize)
+	if err != nil {
+		return "", err
+	}
+
+	sec := secret{
+		MethodID:    method.ID,
+		EntityID:    entityID,
+		AccountName: accountName,
+		Key:         key,
+		TOTP:        method.TOTP,
+	}
+
+	if err = s.write(ctx, s.secretPath(method.ID, entityID), sec); err != nil {
+		return "", err
+	}
+
+	return keyURL(sec.TOTP, accountName, key), nil
+}
+
+func (s *Store) DestroySecret(ctx context.Context, methodID string, entityID string) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if _, err := s.readSecret(ctx, methodID, entityID); err != nil {
+		return err
+	}
+
+	return s.delete(ctx, s.secretPath(methodID, entityID))
+}
+
+// DeleteEntitySecrets destroys the keys of an entity for all methods, e.g.
+// when the entity is deleted.
+func (s *Store) DeleteEntitySecrets(ctx context.Context, entityID string) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if !validID(entityID) {
+		return nil
+	}
+
+	ids, err := s.storage.List(ctx, filepath.Join(s.path, "secret"))
+	if err != nil {
+		return err
+	}
+
+	for _, methodID := range ids {
+		methodID = strings.TrimSuffix(methodID, "/")
+		if err = s.delete(ctx, s.secretPath(methodID, entityID)); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// Validate checks a passcode of the entity for the method. Every passcode is
+// accepted only once, and after too many wrong passcodes validation fails
+// until the period is over.
+func (s *Store) Validate(ctx context.Context, methodID string, entityID string, code string) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	method, err := s.getMethod(ctx, methodID)
+	if err != nil {
+		return err
+	}
+
+	sec, err := s.readSecret(ctx, method.ID, entityID)
+	if err != nil {
+		return err
+	}
+
+	now := time.Now()
+	for key, expires := range s.used {
+		if now.After(expires) {
+			delete(s.used, key)
+		}
+	}
+
+	attemptsKey := method.ID + "/" + entityID
+	failed := s.attempts[attemptsKey]
+	if now.Sub(failed.last) >= sec.Period {
+		failed = attempts{}
+	}
+	if failed.count >= method.MaxValidationAttempts {
+		return ErrTooManyAttempts
+	}
+
+	ok, err := validCode(sec.TOTP, sec.Key, code, now)
+	if err != nil {
+		return err
+	}
+	if !ok {
+		s.attempts[attemptsKey] = attempts{count: failed.count + 1, last: now}
+		return ErrInvalidCode
+	}
+	delete(s.attempts, attemptsKey)
+
+	usedKey := attemptsKey + "/" + code
+	if _, ok = s.used[usedKey]; ok {
+		return ErrCodeUsed
+	}
+	s.used[usedKey] = now.Add(time.Duration(2*sec.Skew+1) * sec.Period)
+
+	return nil
+}
+
+func (s *Store) readSecret(ctx context.Context, methodID string, entityID string) (secret, error) {
+	if !validID(methodID) || !validID(entityID) {
+		return secret{}, ErrSecretNotFound
+	}
+
+	var sec secret
+	err := s.read(ctx, s.secretPath(methodID, entityID), &sec)
+	if errors.Is(err, storage.ErrPathNotFound) {
+		return secret{}, ErrSecretNotFound
+	}
+	if err != nil {
+		return secret{}, err
+	}
+
+	return sec, nil
+}
+
+func (s *Store) secretPath(methodID string, entityID string) string {
+	return filepath.Join(s.path, "secret", methodID, entityID)
+}
+
+func (s *Store) read(ctx context.Context, path string, v any) error {
+	data, err := s.storage.Get(ctx, path)
+	if err != nil {
+		return err
+	}
+
+	decryptedData, err := s.encryptor.Decrypt(data)
+	if err != nil {
+		return err
+	}
+
+	return json.Unmarshal(decryptedData, v)
+}
+
+func (s *Store) write(ctx context.Context, path string, v any) error {
+	d, err := json.Marshal(v)
+	if err != nil {
+		return err
+	}
+
+	encryptedData, err := s.encryptor.Encrypt(d)
+	if err != nil {
+		return err
+	}
+
+	return s.storage.Put(ctx, path, encryptedData)
+}
+
+func (s *Store) delete(ctx context.Context, path string) error {
+	err := s.storage.Delete(ctx, path)
+	if errors.Is(err, storage.ErrPathNotFound) {
+		return nil
+	}
+
+	return err
+}
+
+// validID keeps IDs from the API out of storage paths unless they have the
+// format of the IDs generated here.
+func validID(id string) bool {
+	_, err := uuid.Parse(id)
+	return err == nil
+}
@@ -0,0 +1,144 @@
+package mfa
+
+import (
+	"context"
+	"errors"
+	"net/url"
+	"testing"
+	"time"
+
+	fs "github.com/Burzich/dvault/internal/dvault/storage/disc"
+	"github.com/Burzich/dvault/internal/tools"
+	"github.com/google/uuid"
+)
+
+func newTestStore(t *testing.T) *Store {
+	t.Helper()
+
+	encryptor, err := tools.NewEncryptor("aes", make([]byte, 32))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	return NewStore("sys/mfa", fs.NewFSStorage(t.TempDir()), encryptor)
+}
+
+// enroll creates a method with the given skew and a key for a new entity. It
+// returns the method and entity IDs and the key.
+func enroll(t *testing.T, s *Store, skew int) (string, string, string) {
+	t.Helper()
+	ctx := context.Background()
+
+	method, err := s.PutMethod(ctx, Method{TOTP: TOTP{
+		Issuer:    "dvault",
+		Period:    30 * time.Second,
+		KeySize:   20,
+		Algorithm: "SHA1",
+		Digits:    6,
+		Skew:      skew,
+	}})
+	if err != nil {
+		t.Fatalf("PutMethod: %v", err)
+	}
+
+	entityID := uuid.NewString()
+	keyURL, err := s.GenerateSecret(ctx, method.ID, entityID, "alice")
+	if err != nil {
+		t.Fatalf("GenerateSecret: %v", err)
+	}
+
+	u, err := url.Parse(keyURL)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	return method.ID, entityID, u.Query().Get("secret")
+}
+
+func TestValidateSkew(t *testing.T) {
+	tests := []struct {
+		name   string
+		skew   int
+		offset int
+		err    error
+	}{
+		{"current period", 0, 0, nil},
+		{"previous period without skew", 0, -1, ErrInvalidCode},
+		{"previous period with skew", 1, -1, nil},
+		{"next period with skew", 1, 1, nil},
+		{"outside the skew window", 1, -3, ErrInvalidCode},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			s := newTestStore(t)
+			methodID, entityID, key := enroll(t, s, tt.skew)
+
+			params := TOTP{Period: 30 * time.Second, Algorithm: "SHA1", Digits: 6}
+			code, err := Code(params, key, time.Now().Add(time.Duration(tt.offset)*params.Period))
+			if err != nil {
+				t.Fatalf("Code: %v", err)
+			}
+
+			err = s.Validate(context.Background(), methodID, entityID, code)
+			if !errors.Is(err, tt.err) {
+				t.Fatalf("Validate error = %v, want %v", err, tt.err)
+			}
+		})
+	}
+}
+
+func TestValidateRejectsReuse(t *testing.T) {
+	s := newTestStore(t)
+	methodID, entityID, key := enroll(t, s, 1)
+
+	code, err := Code(TOTP{Period: 30 * time.Second, Algorithm: "SHA1", Digits: 6}, key, time.Now())
+	if err != nil {
+		t.Fatalf("Code: %v", err)
+	}
+
+	if err = s.Validate(context.Background(), methodID, entityID, code); err != nil {
+		t.Fatalf("first Validate: %v", err)
+	}
+
+	err = s.Validate(context.Background(), methodID, entityID, code)
+	if !errors.Is(err, ErrCodeUsed) {
+		t.Fatalf("second Validate error = %v, want %v", err, ErrCodeUsed)
+	}
+}
+
+func TestValidateTooManyAttempts(t *testing.T) {
+	s := newTestStore(t)
+	methodID, entityID, key := enroll(t, s, 1)
+
+	code, err := Code(TOTP{Period: 30 * time.Second, Algorithm: "SHA1", Digits: 6}, key, time.Now())
+	if err != nil {
+		t.Fatalf("Code: %v", err)
+	}
+
+	// A passcode that is not valid in any period of the skew window.
+	wrong := "000000"
+	for _, candidate := range []string{"000000", "111111", "222222", "333333"} {
+		valid, err := validCode(TOTP{Period: 30 * time.Second, Algorithm: "SHA1", Digits: 6, Skew: 1}, key, candidate, time.Now())
+		if err != nil {
+			t.Fatalf("validCode: %v", err)
+		}
+		if !valid {
+			wrong = candidate
+			break
+		}
+	}
+
+	for range DefaultMaxValidationAttempts {
+		err = s.Validate(context.Background(), methodID, entityID, wrong)
+		if !errors.Is(err, ErrInvalidCode) {
+			t.Fatalf("Validate error = %v, want %v", err, ErrInvalidCode)
+		}
+	}
+
+	// Even the right passcode is refused until the period is over.
+	err = s.Validate(context.Background(), methodID, entityID, code)
+	if !errors.Is(err, ErrTooManyAttempts) {
+		t.Fatalf("Validate error = %v, want %v", err, ErrTooManyAttempts)
+	}
+}
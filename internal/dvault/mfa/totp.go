@@ -0,0 +1,142 @@
+package mfa
+
+import (
+	"crypto/hmac"
+	"crypto/rand"
+	"crypto/sha1"
+	"crypto/sha256"
+	"crypto/sha512"
+	"crypto/subtle"
+	"encoding/base32"
+	"encoding/binary"
+	"fmt"
+	"hash"
+	"net/url"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// TOTP holds the parameters codes are computed with. Methods define them for
+// new keys, every key keeps the parameters it was generated with.
+type TOTP struct {
+	Issuer    string        `json:"issuer"`
+	Period    time.Duration `json:"period"`
+	KeySize   int           `json:"key_size"`
+	Algorithm string        `json:"algorithm"`
+	Digits    int           `json:"digits"`
+	Skew      int           `json:"skew"`
+}
+
+func (t TOTP) validate() error {
+	if t.Issuer == "" {
+		return fmt.Errorf("%w: issuer is required", ErrInvalidConfig)
+	}
+	if t.Period < time.Second || t.Period%time.Second != 0 {
+		return fmt.Errorf("%w: period must be a whole number of seconds", ErrInvalidConfig)
+	}
+	if t.KeySize < 10 {
+		return fmt.Errorf("%w: key_size must be at least 10 bytes", ErrInvalidConfig)
+	}
+	if _, ok := algorithms[t.Algorithm]; !ok {
+		return fmt.Errorf("%w: algorithm must be one of SHA1, SHA256 or SHA512", ErrInvalidConfig)
+	}
+	if t.Digits != 6 && t.Digits != 8 {
+		return fmt.Errorf("%w: digits must be 6 or 8", ErrInvalidConfig)
+	}
+	if t.Skew != 0 && t.Skew != 1 {
+		return fmt.Errorf("%w: skew must be 0 or 1", ErrInvalidConfig)
+	}
+
+	return nil
+}
+
+var algorithms = map[string]func() hash.Hash{
+	"SHA1":   sha1.New,
+	"SHA256": sha256.New,
+	"SHA512": sha512.New,
+}
+
+var encoding = base32.StdEncoding.WithPadding(base32.NoPadding)
+
+// generateKey returns a random base32 encoded TOTP key of size bytes.
+func generateKey(size int) (string, error) {
+	b := make([]byte, size)
+	if _, err := rand.Read(b); err != nil {
+		return "", err
+	}
+
+	return encoding.EncodeToString(b), nil
+}
+
+// Code computes the TOTP code of key at time t as described in RFC 6238.
+func Code(params TOTP, key string, t time.Time) (string, error) {
+	secret, err := encoding.DecodeString(strings.ToUpper(strings.TrimRight(key, "=")))
+	if err != nil {
+		return "", fmt.Errorf("%w: key is not base32 encoded", ErrInvalidConfig)
+	}
+
+	newHash, ok := algorithms[params.Algorithm]
+	if !ok {
+		return "", fmt.Errorf("%w: unsupported algorithm %q", ErrInvalidConfig, params.Algorithm)
+	}
+
+	return hotp(newHash, secret, uint64(t.Unix())/uint64(params.Period.Seconds()), params.Digits), nil
+}
+
+// hotp implements RFC 4226 with the dynamic truncation of the HMAC.
+func hotp(newHash func() hash.Hash, secret []byte, counter uint64, digits int) string {
+	var msg [8]byte
+	binary.BigEndian.PutUint64(msg[:], counter)
+
+	mac := hmac.New(newHash, secret)
+	mac.Write(msg[:])
+	sum := mac.Sum(nil)
+
+	offset := sum[len(sum)-1] & 0x0f
+	value := binary.BigEndian.Uint32(sum[offset:offset+4]) & 0x7fffffff
+
+	mod := uint32(1)
+	for range digits {
+		mod *= 10
+	}
+
+	return fmt.Sprintf("%0*d", digits, value%mod)
+}
+
+// validCode reports whether code is the TOTP code of key at time t, or of one
+// of the skew periods before or after it.
+func validCode(params TOTP, key string, code string, t time.Time) (bool, error) {
+	for i := -params.Skew; i <= params.Skew; i++ {
+		expected, err := Code(params, key, t.Add(time.Duration(i)*params.Period))
+		if err != nil {
+			return false, err
+		}
+
+		if subtle.ConstantTimeCompare([]byte(expected), []byte(code)) == 1 {
+			return true, nil
+		}
+	}
+
+	return false, nil
+}
+
+// keyURL returns the otpauth URL authenticator apps import the key from,
+// usually by scanning it as a QR code.
+func keyURL(params TOTP, accountName string, key string) string {
+	query := url.Values{}
+	query.Set("secret", key)
+	query.Set("issuer", params.Issuer)
+	query.Set("algorithm", params.Algorithm)
+	query.Set("digits", strconv.Itoa(params.Digits))
+	query.Set("period", strconv.Itoa(int(params.Period.Seconds())))
+
+	u := url.URL{
+		Scheme:   "otpauth",
+		Host:     "totp",
+		Path:     "/" + params.Issuer + ":" + accountName,
+		RawQuery: query.Encode(),
+	}
+
+	return u.String()
+}
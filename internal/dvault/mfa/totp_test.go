@@ -0,0 +1,94 @@
+package mfa
+
+import (
+	"testing"
+	"time"
+)
+
+// rfc6238Seeds are the keys of the test vectors in appendix B of RFC 6238.
+var rfc6238Seeds = map[string]string{
+	"SHA1":   "12345678901234567890",
+	"SHA256": "12345678901234567890123456789012",
+	"SHA512": "1234567890123456789012345678901234567890123456789012345678901234",
+}
+
+func TestCodeRFC6238(t *testing.T) {
+	tests := []struct {
+		unix      int64
+		algorithm string
+		code      string
+	}{
+		{59, "SHA1", "94287082"},
+		{59, "SHA256", "46119246"},
+		{59, "SHA512", "90693936"},
+		{1111111109, "SHA1", "07081804"},
+		{1111111109, "SHA256", "68084774"},
+		{1111111109, "SHA512", "25091201"},
+		{1111111111, "SHA1", "14050471"},
+		{1111111111, "SHA256", "67062674"},
+		{1111111111, "SHA512", "99943326"},
+		{1234567890, "SHA1", "89005924"},
+		{1234567890, "SHA256", "91819424"},
+		{1234567890, "SHA512", "93441116"},
+		{2000000000, "SHA1", "69279037"},
+		{2000000000, "SHA256", "90698825"},
+		{2000000000, "SHA512", "38618901"},
+		{20000000000, "SHA1", "65353130"},
+		{20000000000, "SHA256", "77737706"},
+		{20000000000, "SHA512", "47863826"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.algorithm+"/"+time.Unix(tt.unix, 0).UTC().Format(time.RFC3339), func(t *testing.T) {
+			params := TOTP{Period: 30 * time.Second, Algorithm: tt.algorithm, Digits: 8}
+			key := encoding.EncodeToString([]byte(rfc6238Seeds[tt.algorithm]))
+
+			code, err := Code(params, key, time.Unix(tt.unix, 0))
+			if err != nil {
+				t.Fatalf("Code: %v", err)
+			}
+			if code != tt.code {
+				t.Fatalf("Code = %s, want %s", code, tt.code)
+			}
+		})
+	}
+}
+
+func TestValidCodeSkew(t *testing.T) {
+	key := encoding.EncodeToString([]byte(rfc6238Seeds["SHA1"]))
+	now := time.Unix(1111111111, 0)
+
+	tests := []struct {
+		name   string
+		skew   int
+		offset int
+		valid  bool
+	}{
+		{"current period", 0, 0, true},
+		{"previous period without skew", 0, -1, false},
+		{"next period without skew", 0, 1, false},
+		{"previous period with skew", 1, -1, true},
+		{"next period with skew", 1, 1, true},
+		{"two periods back with skew", 1, -2, false},
+		{"two periods ahead with skew", 1, 2, false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			params := TOTP{Period: 30 * time.Second, Algorithm: "SHA1", Digits: 6, Skew: tt.skew}
+
+			code, err := Code(params, key, now.Add(time.Duration(tt.offset)*params.Period))
+			if err != nil {
+				t.Fatalf("Code: %v", err)
+			}
+
+			valid, err := validCode(params, key, code, now)
+			if err != nil {
+				t.Fatalf("validCode: %v", err)
+			}
+			if valid != tt.valid {
+				t.Fatalf("validCode = %t, want %t", valid, tt.valid)
+			}
+		})
+	}
+}
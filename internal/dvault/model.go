@@ -1,6 +1,10 @@
 package dvault
 
-import "time"
+import (
+	"time"
+
+	"github.com/Burzich/dvault/internal/dvault/auth"
+)
 
 type Response struct {
 	RequestId     string      `json:"request_id"`
@@ -15,15 +19,19 @@ type Response struct {
 }
 
 type InitResponse struct {
-	Keys       []string `json:"keys"`
-	KeysBase64 []string `json:"keys_base64"`
-	RootToken  string   `json:"root_token"`
+	Keys               []string `json:"keys"`
+	KeysBase64         []string `json:"keys_base64"`
+	RecoveryKeys       []string `json:"recovery_keys,omitempty"`
+	RecoveryKeysBase64 []string `json:"recovery_keys_base64,omitempty"`
+	RootToken          string   `json:"root_token"`
 }
 
 type Unseal struct {
 	Key     string `json:"key"`
 	Migrate bool   `json:"migrate"`
 	Reset   bool   `json:"reset"`
+	// Nonce optionally names the unseal attempt the key is meant for.
+	Nonce string `json:"nonce"`
 }
 
 type Init struct {
@@ -119,3 +127,489 @@ type MountData struct {
 	Type                 string `json:"type"`
 	Uuid                 string `json:"uuid"`
 }
+
+type CreateToken struct {
+	Policies        []string          `json:"policies"`
+	Meta            map[string]string `json:"meta"`
+	NoDefaultPolicy bool              `json:"no_default_policy"`
+	NoParent        bool              `json:"no_parent"`
+	Renewable       *bool             `json:"renewable"`
+	TTL             time.Duration     `json:"ttl"`
+	ExplicitMaxTTL  time.Duration     `json:"explicit_max_ttl"`
+	DisplayName     string            `json:"display_name"`
+	NumUses         int               `json:"num_uses"`
+	Type            string            `json:"type"`
+}
+
+type TokenAuth struct {
+	ClientToken      string            `json:"client_token"`
+	Accessor         string            `json:"accessor"`
+	Policies         []string          `json:"policies"`
+	TokenPolicies    []string          `json:"token_policies"`
+	IdentityPolicies []string          `json:"identity_policies,omitempty"`
+	Metadata         map[string]string `json:"metadata"`
+	LeaseDuration    int               `json:"lease_duration"`
+	Renewable        bool              `json:"renewable"`
+	EntityId         string            `json:"entity_id"`
+	TokenType        string            `json:"token_type"`
+	Orphan           bool              `json:"orphan"`
+	NumUses          int               `json:"num_uses"`
+}
+
+type TokenLookup struct {
+	Accessor       string            `json:"accessor"`
+	CreationTime   int64             `json:"creation_time"`
+	CreationTtl    int               `json:"creation_ttl"`
+	DisplayName    string            `json:"display_name"`
+	EntityId       string            `json:"entity_id"`
+	ExpireTime     *time.Time        `json:"expire_time"`
+	ExplicitMaxTtl int               `json:"explicit_max_ttl"`
+	Id             string            `json:"id"`
+	IssueTime      time.Time         `json:"issue_time"`
+	Meta           map[string]string `json:"meta"`
+	NumUses        int               `json:"num_uses"`
+	Orphan         bool              `json:"orphan"`
+	Path           string            `json:"path"`
+	Policies       []string          `json:"policies"`
+	Renewable      bool              `json:"renewable"`
+	Ttl            int               `json:"ttl"`
+	Type           string            `json:"type"`
+	Role           string            `json:"role,omitempty"`
+	Period         int               `json:"period,omitempty"`
+	BoundCidrs     []string          `json:"bound_cidrs,omitempty"`
+}
+
+type TidyStatus struct {
+	State             string    `json:"state"`
+	StartTime         time.Time `json:"start_time"`
+	EndTime           time.Time `json:"end_time"`
+	ExpiredTokens     int       `json:"expired_tokens"`
+	DanglingAccessors int       `json:"dangling_accessors"`
+	InvalidParents    int       `json:"invalid_parents"`
+	LastError         string    `json:"last_error,omitempty"`
+}
+
+type KeyStatusData struct {
+	Term        int       `json:"term"`
+	InstallTime time.Time `json:"install_time"`
+	// Terms counts the keys still in the keyring. Old terms are dropped once
+	// all data is rewrapped with the newest one.
+	Terms int `json:"terms"`
+}
+
+type GenerateRootStatus struct {
+	Started          bool   `json:"started"`
+	Nonce            string `json:"nonce"`
+	Progress         int    `json:"progress"`
+	Required         int    `json:"required"`
+	Complete         bool   `json:"complete"`
+	EncodedToken     string `json:"encoded_token"`
+	EncodedRootToken string `json:"encoded_root_token"`
+	PgpFingerprint   string `json:"pgp_fingerprint"`
+	Otp              string `json:"otp"`
+	OtpLength        int    `json:"otp_length"`
+}
+
+type GenerateRootUpdate struct {
+	Key   string `json:"key"`
+	Nonce string `json:"nonce"`
+}
+
+type RekeyInit struct {
+	SecretShares        int      `json:"secret_shares"`
+	SecretThreshold     int      `json:"secret_threshold"`
+	PgpKeys             []string `json:"pgp_keys"`
+	RequireVerification bool     `json:"require_verification"`
+}
+
+type RekeyUpdate struct {
+	Key   string `json:"key"`
+	Nonce string `json:"nonce"`
+}
+
+type RekeyStatus struct {
+	Started              bool     `json:"started"`
+	Nonce                string   `json:"nonce"`
+	T                    int      `json:"t"`
+	N                    int      `json:"n"`
+	Progress             int      `json:"progress"`
+	Required             int      `json:"required"`
+	PgpFingerprints      []string `json:"pgp_fingerprints"`
+	Backup               bool     `json:"backup"`
+	VerificationRequired bool     `json:"verification_required"`
+	Complete             bool     `json:"complete,omitempty"`
+	Keys                 []string `json:"keys,omitempty"`
+	KeysBase64           []string `json:"keys_base64,omitempty"`
+	VerificationNonce    string   `json:"verification_nonce,omitempty"`
+}
+
+type RekeyVerificationStatus struct {
+	Started  bool   `json:"started"`
+	Nonce    string `json:"nonce"`
+	T        int    `json:"t"`
+	N        int    `json:"n"`
+	Progress int    `json:"progress"`
+	Complete bool   `json:"complete,omitempty"`
+}
+
+type KeyList struct {
+	Keys []string `json:"keys"`
+}
+
+type PolicyData struct {
+	Name   string `json:"name"`
+	Policy string `json:"policy"`
+}
+
+type TokenRole struct {
+	AllowedPolicies     []string      `json:"allowed_policies"`
+	DisallowedPolicies  []string      `json:"disallowed_policies"`
+	Orphan              bool          `json:"orphan"`
+	Renewable           *bool         `json:"renewable"`
+	TokenPeriod         time.Duration `json:"token_period"`
+	TokenExplicitMaxTTL time.Duration `json:"token_explicit_max_ttl"`
+	TokenBoundCIDRs     []string      `json:"token_bound_cidrs"`
+	PathSuffix          string        `json:"path_suffix"`
+}
+
+type TokenRoleData struct {
+	Name                string   `json:"name"`
+	AllowedPolicies     []string `json:"allowed_policies"`
+	DisallowedPolicies  []string `json:"disallowed_policies"`
+	Orphan              bool     `json:"orphan"`
+	Renewable           bool     `json:"renewable"`
+	TokenPeriod         int      `json:"token_period"`
+	TokenExplicitMaxTtl int      `json:"token_explicit_max_ttl"`
+	TokenBoundCidrs     []string `json:"token_bound_cidrs"`
+	PathSuffix          string   `json:"path_suffix"`
+}
+
+type TokenParamsData struct {
+	TokenPolicies        []string `json:"token_policies"`
+	TokenTtl             int      `json:"token_ttl"`
+	TokenMaxTtl          int      `json:"token_max_ttl"`
+	TokenPeriod          int      `json:"token_period"`
+	TokenNumUses         int      `json:"token_num_uses"`
+	TokenBoundCidrs      []string `json:"token_bound_cidrs"`
+	TokenType            string   `json:"token_type"`
+	TokenNoDefaultPolicy bool     `json:"token_no_default_policy"`
+}
+
+type AppRole struct {
+	BindSecretID       *bool
+	SecretIDBoundCIDRs []string
+	SecretIDNumUses    int
+	SecretIDTTL        time.Duration
+	auth.TokenParams
+}
+
+type AppRoleData struct {
+	BindSecretId       bool     `json:"bind_secret_id"`
+	SecretIdBoundCidrs []string `json:"secret_id_bound_cidrs"`
+	SecretIdNumUses    int      `json:"secret_id_num_uses"`
+	SecretIdTtl        int      `json:"secret_id_ttl"`
+	TokenParamsData
+}
+
+type AppRoleRoleID struct {
+	RoleId string `json:"role_id"`
+}
+
+type AppRoleSecretID struct {
+	SecretID        string
+	Metadata        map[string]string
+	CIDRList        []string
+	TokenBoundCIDRs []string
+	NumUses         int
+	TTL             time.Duration
+}
+
+type AppRoleSecretIDData struct {
+	SecretId         string `json:"secret_id"`
+	SecretIdAccessor string `json:"secret_id_accessor"`
+	SecretIdTtl      int    `json:"secret_id_ttl"`
+	SecretIdNumUses  int    `json:"secret_id_num_uses"`
+}
+
+type AppRoleSecretIDLookup struct {
+	CidrList         []string          `json:"cidr_list"`
+	CreationTime     time.Time         `json:"creation_time"`
+	ExpirationTime   time.Time         `json:"expiration_time"`
+	LastUpdatedTime  time.Time         `json:"last_updated_time"`
+	Metadata         map[string]string `json:"metadata"`
+	SecretIdAccessor string            `json:"secret_id_accessor"`
+	SecretIdNumUses  int               `json:"secret_id_num_uses"`
+	SecretIdTtl      int               `json:"secret_id_ttl"`
+	TokenBoundCidrs  []string          `json:"token_bound_cidrs"`
+}
+
+type AppRoleTidy struct {
+	RemovedEntries int `json:"removed_entries"`
+}
+
+type UserpassUser struct {
+	Password string
+	auth.TokenParams
+}
+
+type UserpassUserData struct {
+	TokenParamsData
+}
+
+type JWTConfig struct {
+	JwksUrl              string   `json:"jwks_url"`
+	JwksCaPem            string   `json:"jwks_ca_pem"`
+	JwtValidationPubkeys []string `json:"jwt_validation_pubkeys"`
+	BoundIssuer          string   `json:"bound_issuer"`
+	JwtSupportedAlgs     []string `json:"jwt_supported_algs"`
+	DefaultRole          string   `json:"default_role"`
+}
+
+type JWTRole struct {
+	RoleType         string
+	BoundAudiences   []string
+	BoundSubject     string
+	BoundClaims      map[string]any
+	ClaimMappings    map[string]string
+	UserClaim        string
+	GroupsClaim      string
+	ClockSkewLeeway  time.Duration
+	ExpirationLeeway time.Duration
+	NotBeforeLeeway  time.Duration
+	auth.TokenParams
+}
+
+type JWTRoleData struct {
+	RoleType         string            `json:"role_type"`
+	BoundAudiences   []string          `json:"bound_audiences"`
+	BoundSubject     string            `json:"bound_subject"`
+	BoundClaims      map[string]any    `json:"bound_claims"`
+	ClaimMappings    map[string]string `json:"claim_mappings"`
+	UserClaim        string            `json:"user_claim"`
+	GroupsClaim      string            `json:"groups_claim"`
+	ClockSkewLeeway  int               `json:"clock_skew_leeway"`
+	ExpirationLeeway int               `json:"expiration_leeway"`
+	NotBeforeLeeway  int               `json:"not_before_leeway"`
+	TokenParamsData
+}
+
+type CertRole struct {
+	Certificate                string
+	DisplayName                string
+	AllowedCommonNames         []string
+	AllowedDNSSANs             []string
+	AllowedOrganizationalUnits []string
+	auth.TokenParams
+}
+
+type CertRoleData struct {
+	Certificate                string   `json:"certificate"`
+	DisplayName                string   `json:"display_name"`
+	AllowedCommonNames         []string `json:"allowed_common_names"`
+	AllowedDnsSans             []string `json:"allowed_dns_sans"`
+	AllowedOrganizationalUnits []string `json:"allowed_organizational_units"`
+	TokenParamsData
+}
+
+type EnableAuth struct {
+	Type            string
+	Description     string
+	DefaultLeaseTTL time.Duration
+	MaxLeaseTTL     time.Duration
+	Options         map[string]string
+	Local           bool
+	SealWrap        bool
+}
+
+type TuneAuth struct {
+	DefaultLeaseTTL *time.Duration
+	MaxLeaseTTL     *time.Duration
+	Description     *string
+}
+
+type AuthMounts struct {
+	RequestId     string                   `json:"request_id"`
+	LeaseId       string                   `json:"lease_id"`
+	Renewable     bool                     `json:"renewable"`
+	LeaseDuration int                      `json:"lease_duration"`
+	Data          map[string]AuthMountData `json:"data"`
+	WrapInfo      interface{}              `json:"wrap_info"`
+	Warnings      interface{}              `json:"warnings"`
+	Auth          interface{}              `json:"auth"`
+	MountType     string                   `json:"mount_type"`
+}
+
+type AuthMountData struct {
+	Accessor              string            `json:"accessor"`
+	Config                AuthMountConfig   `json:"config"`
+	DeprecationStatus     string            `json:"deprecation_status"`
+	Description           string            `json:"description"`
+	ExternalEntropyAccess bool              `json:"external_entropy_access"`
+	Local                 bool              `json:"local"`
+	Options               map[string]string `json:"options"`
+	PluginVersion         string            `json:"plugin_version"`
+	RunningPluginVersion  string            `json:"running_plugin_version"`
+	RunningSha256         string            `json:"running_sha256"`
+	SealWrap              bool              `json:"seal_wrap"`
+	Type                  string            `json:"type"`
+	Uuid                  string            `json:"uuid"`
+}
+
+type AuthMountConfig struct {
+	DefaultLeaseTtl int    `json:"default_lease_ttl"`
+	MaxLeaseTtl     int    `json:"max_lease_ttl"`
+	ForceNoCache    bool   `json:"force_no_cache"`
+	TokenType       string `json:"token_type"`
+}
+
+type AuthTuneData struct {
+	DefaultLeaseTtl int    `json:"default_lease_ttl"`
+	MaxLeaseTtl     int    `json:"max_lease_ttl"`
+	Description     string `json:"description"`
+	ForceNoCache    bool   `json:"force_no_cache"`
+	TokenType       string `json:"token_type"`
+}
+
+// Entity holds the fields of an entity write. Nil fields keep their stored
+// value.
+type Entity struct {
+	Name     string
+	Policies []string
+	Metadata map[string]string
+	Disabled *bool
+}
+
+type EntityData struct {
+	Id             string              `json:"id"`
+	Name           string              `json:"name"`
+	Policies       []string            `json:"policies"`
+	Metadata       map[string]string   `json:"metadata"`
+	Disabled       bool                `json:"disabled"`
+	Aliases        []IdentityAliasData `json:"aliases"`
+	GroupIds       []string            `json:"group_ids"`
+	CreationTime   time.Time           `json:"creation_time"`
+	LastUpdateTime time.Time           `json:"last_update_time"`
+}
+
+// Group holds the fields of a group write. Nil fields keep their stored
+// value.
+type Group struct {
+	Name            string
+	Type            string
+	Policies        []string
+	MemberEntityIds []string
+	MemberGroupIds  []string
+	Metadata        map[string]string
+}
+
+type GroupData struct {
+	Id              string             `json:"id"`
+	Name            string             `json:"name"`
+	Type            string             `json:"type"`
+	Policies        []string           `json:"policies"`
+	MemberEntityIds []string           `json:"member_entity_ids"`
+	MemberGroupIds  []string           `json:"member_group_ids"`
+	Metadata        map[string]string  `json:"metadata"`
+	Alias           *IdentityAliasData `json:"alias"`
+	CreationTime    time.Time          `json:"creation_time"`
+	LastUpdateTime  time.Time          `json:"last_update_time"`
+}
+
+type IdentityWriteData struct {
+	Id   string `json:"id"`
+	Name string `json:"name"`
+}
+
+type IdentityAlias struct {
+	Name          string
+	MountAccessor string
+	CanonicalId   string
+	Metadata      map[string]string
+}
+
+type IdentityAliasData struct {
+	Id             string            `json:"id"`
+	CanonicalId    string            `json:"canonical_id"`
+	MountAccessor  string            `json:"mount_accessor"`
+	MountPath      string            `json:"mount_path"`
+	MountType      string            `json:"mount_type"`
+	Name           string            `json:"name"`
+	Metadata       map[string]string `json:"metadata"`
+	CreationTime   time.Time         `json:"creation_time"`
+	LastUpdateTime time.Time         `json:"last_update_time"`
+}
+
+type IdentityAliasWriteData struct {
+	Id          string `json:"id"`
+	CanonicalId string `json:"canonical_id"`
+}
+
+type WrapInfo struct {
+	Token        string    `json:"token"`
+	Accessor     string    `json:"accessor"`
+	Ttl          int       `json:"ttl"`
+	CreationTime time.Time `json:"creation_time"`
+	CreationPath string    `json:"creation_path"`
+}
+
+type WrappingLookup struct {
+	CreationPath string    `json:"creation_path"`
+	CreationTime time.Time `json:"creation_time"`
+	CreationTtl  int       `json:"creation_ttl"`
+}
+
+// TOTPMethod holds the fields of a TOTP method write. Zero fields keep their
+// stored value, or get the default when the method is created.
+type TOTPMethod struct {
+	MethodName            string
+	Issuer                string
+	Period                time.Duration
+	KeySize               int
+	Algorithm             string
+	Digits                int
+	Skew                  *int
+	MaxValidationAttempts int
+}
+
+type TOTPMethodData struct {
+	Id                    string    `json:"id"`
+	MethodName            string    `json:"method_name"`
+	Type                  string    `json:"type"`
+	Issuer                string    `json:"issuer"`
+	Period                int       `json:"period"`
+	KeySize               int       `json:"key_size"`
+	Algorithm             string    `json:"algorithm"`
+	Digits                int       `json:"digits"`
+	Skew                  int       `json:"skew"`
+	MaxValidationAttempts int       `json:"max_validation_attempts"`
+	CreationTime          time.Time `json:"creation_time"`
+	LastUpdateTime        time.Time `json:"last_update_time"`
+}
+
+type MFAMethodWriteData struct {
+	MethodId string `json:"method_id"`
+}
+
+type TOTPSecretData struct {
+	Url string `json:"url"`
+}
+
+type LoginEnforcement struct {
+	MfaMethodIds        []string
+	AuthMethodAccessors []string
+	AuthMethodTypes     []string
+	IdentityGroupIds    []string
+	IdentityEntityIds   []string
+}
+
+type LoginEnforcementData struct {
+	Id                  string    `json:"id"`
+	Name                string    `json:"name"`
+	MfaMethodIds        []string  `json:"mfa_method_ids"`
+	AuthMethodAccessors []string  `json:"auth_method_accessors"`
+	AuthMethodTypes     []string  `json:"auth_method_types"`
+	IdentityGroupIds    []string  `json:"identity_group_ids"`
+	IdentityEntityIds   []string  `json:"identity_entity_ids"`
+	CreationTime        time.Time `json:"creation_time"`
+	LastUpdateTime      time.Time `json:"last_update_time"`
+}
@@ -0,0 +1,89 @@
+package dvault
+
+import (
+	"context"
+	"strings"
+
+	"github.com/Burzich/dvault/internal/tools"
+)
+
+func (d *DVault) ListPolicies(ctx context.Context) (Response, error) {
+	d.mu.RLock()
+	defer d.mu.RUnlock()
+
+	if d.isSealed {
+		return Response{}, ErrSealed
+	}
+
+	keys, err := d.policies.List(ctx)
+	if err != nil {
+		return Response{}, err
+	}
+
+	var response Response
+	response.Data = KeyList{Keys: keys}
+	response.RequestId = tools.GenerateXRequestID()
+
+	return response, nil
+}
+
+func (d *DVault) GetPolicy(ctx context.Context, name string) (Response, error) {
+	d.mu.RLock()
+	defer d.mu.RUnlock()
+
+	if d.isSealed {
+		return Response{}, ErrSealed
+	}
+
+	p, err := d.policies.Get(ctx, strings.ToLower(name))
+	if err != nil {
+		return Response{}, err
+	}
+
+	var response Response
+	response.Data = PolicyData{
+		Name:   p.Name,
+		Policy: p.Raw,
+	}
+	response.RequestId = tools.GenerateXRequestID()
+
+	return response, nil
+}
+
+func (d *DVault) SavePolicy(ctx context.Context, name string, raw string) (Response, error) {
+	d.mu.RLock()
+	defer d.mu.RUnlock()
+
+	if d.isSealed {
+		return Response{}, ErrSealed
+	}
+
+	err := d.policies.Put(ctx, strings.ToLower(name), raw)
+	if err != nil {
+		return Response{}, err
+	}
+
+	var response Response
+	response.RequestId = tools.GenerateXRequestID()
+
+	return response, nil
+}
+
+func (d *DVault) DeletePolicy(ctx context.Context, name string) (Response, error) {
+	d.mu.RLock()
+	defer d.mu.RUnlock()
+
+	if d.isSealed {
+		return Response{}, ErrSealed
+	}
+
+	err := d.policies.Delete(ctx, strings.ToLower(name))
+	if err != nil {
+		return Response{}, err
+	}
+
+	var response Response
+	response.RequestId = tools.GenerateXRequestID()
+
+	return response, nil
+}
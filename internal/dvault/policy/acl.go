@@ -0,0 +1,199 @@
+package policy
+
+import (
+	"slices"
+	"strings"
+)
+
+type Operation string
+
+const (
+	CreateOperation Operation = "create"
+	ReadOperation   Operation = "read"
+	UpdateOperation Operation = "update"
+	DeleteOperation Operation = "delete"
+	ListOperation   Operation = "list"
+)
+
+type ACL struct {
+	root  bool
+	rules []PathRules
+}
+
+// NewACL merges the rules of all policies. Rules for the same path are
+// combined, and a deny on a path wins over everything granted on it. The MFA
+// methods of all rules for a path are required.
+func NewACL(policies []Policy) *ACL {
+	acl := ACL{}
+	merged := make(map[string]PathRules)
+
+	for _, p := range policies {
+		if p.Name == Root {
+			acl.root = true
+		}
+
+		for _, rules := range p.Paths {
+			m := merged[rules.Path]
+			m.Capabilities |= rules.Capabilities
+			m.MFAMethods = append(m.MFAMethods, rules.MFAMethods...)
+			merged[rules.Path] = m
+		}
+	}
+
+	for path, m := range merged {
+		if m.Capabilities&Deny != 0 {
+			m.Capabilities = Deny
+		}
+
+		slices.Sort(m.MFAMethods)
+		m.MFAMethods = slices.Compact(m.MFAMethods)
+		m.Path = path
+
+		acl.rules = append(acl.rules, m)
+	}
+
+	return &acl
+}
+
+// Capabilities returns the capabilities granted on path by the most specific
+// matching rule.
+func (a *ACL) Capabilities(path string) Capability {
+	if a.root {
+		return Create | Read | Update | Delete | List | Sudo
+	}
+
+	best := a.match(path)
+	if best == nil {
+		return 0
+	}
+
+	return best.Capabilities
+}
+
+// MFAMethods returns the MFA methods the most specific matching rule requires
+// for path. The root policy requires none.
+func (a *ACL) MFAMethods(path string) []string {
+	if a.root {
+		return nil
+	}
+
+	best := a.match(path)
+	if best == nil {
+		return nil
+	}
+
+	return best.MFAMethods
+}
+
+func (a *ACL) match(path string) *PathRules {
+	var best *PathRules
+	for i := range a.rules {
+		if !Match(a.rules[i].Path, path) {
+			continue
+		}
+
+		if best == nil || lowerPriority(best.Path, a.rules[i].Path) {
+			best = &a.rules[i]
+		}
+	}
+
+	return best
+}
+
+// Allowed reports whether the operation may be performed on path. Writes are
+// permitted with either the create or the update capability, since dvault does
+// not check whether the target already exists.
+func (a *ACL) Allowed(path string, op Operation, sudo bool) bool {
+	c := a.Capabilities(path)
+	if c&Deny != 0 {
+		return false
+	}
+
+	if sudo && c&Sudo == 0 {
+		return false
+	}
+
+	switch op {
+	case CreateOperation, UpdateOperation:
+		return c&(Create|Update) != 0
+	case ReadOperation:
+		return c&Read != 0
+	case DeleteOperation:
+		return c&Delete != 0
+	case ListOperation:
+		return c&List != 0
+	default:
+		return false
+	}
+}
+
+// Match reports whether path matches pattern. A "+" segment matches any single
+// path segment and a trailing "*" matches any suffix.
+func Match(pattern string, path string) bool {
+	glob := strings.HasSuffix(pattern, "*")
+	patternSegments := strings.Split(strings.TrimSuffix(pattern, "*"), "/")
+	pathSegments := strings.Split(path, "/")
+
+	if !glob && len(patternSegments) != len(pathSegments) {
+		return false
+	}
+	if glob && len(patternSegments) > len(pathSegments) {
+		return false
+	}
+
+	for i, segment := range patternSegments {
+		if segment == "+" {
+			continue
+		}
+
+		if glob && i == len(patternSegments)-1 {
+			return strings.HasPrefix(pathSegments[i], segment)
+		}
+
+		if segment != pathSegments[i] {
+			return false
+		}
+	}
+
+	return true
+}
+
+// lowerPriority implements the priority rules Vault uses to choose between
+// several matching paths. It reports whether p1 loses against p2.
+func lowerPriority(p1 string, p2 string) bool {
+	w1, w2 := firstWildcard(p1), firstWildcard(p2)
+	if w1 != w2 {
+		return w1 < w2
+	}
+
+	g1, g2 := strings.HasSuffix(p1, "*"), strings.HasSuffix(p2, "*")
+	if g1 != g2 {
+		return g1
+	}
+
+	s1, s2 := plusSegments(p1), plusSegments(p2)
+	if s1 != s2 {
+		return s1 > s2
+	}
+
+	if len(p1) != len(p2) {
+		return len(p1) < len(p2)
+	}
+
+	return p1 < p2
+}
+
+func firstWildcard(path string) int {
+	i := strings.IndexAny(path, "+*")
+	if i == -1 {
+		return len(path)
+	}
+
+	return i
+}
+
+func plusSegments(path string) int {
+	return len(slices.DeleteFunc(strings.Split(path, "/"), func(segment string) bool {
+		return segment != "+"
+	}))
+}
@@ -0,0 +1,7 @@
+package policy
+
+import "errors"
+
+var ErrPolicyNotFound = errors.New("policy not found")
+var ErrInvalidPolicy = errors.New("invalid policy")
+var ErrImmutablePolicy = errors.New("policy can not be modified")
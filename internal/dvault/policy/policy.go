@@ -0,0 +1,132 @@
+package policy
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/hashicorp/hcl"
+)
+
+const (
+	Root    = "root"
+	Default = "default"
+
+	// ResponseWrapping is the only policy of wrapping tokens. It grants
+	// nothing, wrapping tokens are only accepted by the sys/wrapping endpoints.
+	ResponseWrapping = "response-wrapping"
+)
+
+type Capability uint8
+
+const (
+	Deny Capability = 1 << iota
+	Create
+	Read
+	Update
+	Delete
+	List
+	Sudo
+)
+
+var capabilities = map[string]Capability{
+	"deny":   Deny,
+	"create": Create,
+	"read":   Read,
+	"update": Update,
+	"delete": Delete,
+	"list":   List,
+	"sudo":   Sudo,
+}
+
+type PathRules struct {
+	Path         string
+	Capabilities Capability
+	// MFAMethods must all be satisfied with an X-Vault-MFA passcode on
+	// every request to the path.
+	MFAMethods []string
+}
+
+type Policy struct {
+	Name  string
+	Raw   string
+	Paths []PathRules
+}
+
+// Parse accepts a policy in HCL or JSON, both of which HCL v1 understands:
+//
+//	path "secret/data/*" {
+//	  capabilities = ["read", "list"]
+//	  mfa_methods  = ["totp"]
+//	}
+func Parse(name string, raw string) (Policy, error) {
+	var rawPolicy struct {
+		Path map[string]struct {
+			Capabilities []string `hcl:"capabilities"`
+			MFAMethods   []string `hcl:"mfa_methods"`
+		} `hcl:"path"`
+	}
+
+	if err := hcl.Decode(&rawPolicy, raw); err != nil {
+		return Policy{}, fmt.Errorf("%w: %s", ErrInvalidPolicy, err)
+	}
+
+	p := Policy{
+		Name: name,
+		Raw:  raw,
+	}
+
+	for path, rules := range rawPolicy.Path {
+		if i := strings.Index(path, "*"); i != -1 && i != len(path)-1 {
+			return Policy{}, fmt.Errorf("%w: path %q: glob is only allowed at the end", ErrInvalidPolicy, path)
+		}
+
+		var c Capability
+		for _, capability := range rules.Capabilities {
+			v, ok := capabilities[capability]
+			if !ok {
+				return Policy{}, fmt.Errorf("%w: path %q: unknown capability %q", ErrInvalidPolicy, path, capability)
+			}
+			c |= v
+		}
+
+		p.Paths = append(p.Paths, PathRules{
+			Path:         strings.TrimPrefix(path, "/"),
+			Capabilities: c,
+			MFAMethods:   rules.MFAMethods,
+		})
+	}
+
+	return p, nil
+}
+
+// DefaultPolicy mirrors the default policy of Vault: every token may manage
+// itself, its cubbyhole and response wrapping.
+const DefaultPolicy = `
+path "auth/token/lookup-self" {
+  capabilities = ["read"]
+}
+
+path "auth/token/renew-self" {
+  capabilities = ["update"]
+}
+
+path "auth/token/revoke-self" {
+  capabilities = ["update"]
+}
+
+path "cubbyhole/*" {
+  capabilities = ["create", "read", "update", "delete", "list"]
+}
+
+path "sys/wrapping/wrap" {
+  capabilities = ["update"]
+}
+
+path "sys/wrapping/lookup" {
+  capabilities = ["update"]
+}
+
+path "sys/wrapping/unwrap" {
+  capabilities = ["update"]
+}
+`
@@ -0,0 +1,198 @@
+package policy
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"path/filepath"
+	"regexp"
+	"slices"
+	"sync"
+
+	"github.com/Burzich/dvault/internal/dvault/storage"
+	"github.com/Burzich/dvault/internal/tools"
+)
+
+var validName = regexp.MustCompile(`^[a-z0-9_-][a-z0-9_.-]*$`)
+
+type Store struct {
+	path      string
+	storage   storage.Storage
+	encryptor tools.Encryptor
+
+	mu    sync.RWMutex
+	cache map[string]Policy
+}
+
+type record struct {
+	Name   string `json:"name"`
+	Policy string `json:"policy"`
+}
+
+func NewStore(path string, s storage.Storage, encryptor tools.Encryptor) *Store {
+	return &Store{
+		path:      path,
+		storage:   s,
+		encryptor: encryptor,
+		cache:     make(map[string]Policy),
+	}
+}
+
+// SetupDefault writes the default policy unless an operator already stored
+// their own version of it.
+func (s *Store) SetupDefault(ctx context.Context) error {
+	_, err := s.Get(ctx, Default)
+	if errors.Is(err, ErrPolicyNotFound) {
+		return s.Put(ctx, Default, DefaultPolicy)
+	}
+
+	return err
+}
+
+func (s *Store) Get(ctx context.Context, name string) (Policy, error) {
+	if name == Root {
+		return Policy{Name: Root}, nil
+	}
+
+	if !validName.MatchString(name) {
+		return Policy{}, ErrPolicyNotFound
+	}
+
+	s.mu.RLock()
+	p, ok := s.cache[name]
+	s.mu.RUnlock()
+	if ok {
+		return p, nil
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	r, err := s.read(ctx, name)
+	if err != nil {
+		return Policy{}, err
+	}
+
+	p, err = Parse(r.Name, r.Policy)
+	if err != nil {
+		return Policy{}, err
+	}
+	s.cache[name] = p
+
+	return p, nil
+}
+
+func (s *Store) Put(ctx context.Context, name string, raw string) error {
+	if name == Root || name == ResponseWrapping {
+		return ErrImmutablePolicy
+	}
+
+	if !validName.MatchString(name) {
+		return fmt.Errorf("%w: invalid name %q", ErrInvalidPolicy, name)
+	}
+
+	p, err := Parse(name, raw)
+	if err != nil {
+		return err
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if err = s.write(ctx, record{Name: name, Policy: raw}); err != nil {
+		return err
+	}
+	s.cache[name] = p
+
+	return nil
+}
+
+func (s *Store) Delete(ctx context.Context, name string) error {
+	if name == Root || name == Default {
+		return ErrImmutablePolicy
+	}
+
+	if !validName.MatchString(name) {
+		return ErrPolicyNotFound
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	delete(s.cache, name)
+
+	err := s.storage.Delete(ctx, filepath.Join(s.path, name))
+	if errors.Is(err, storage.ErrPathNotFound) {
+		return ErrPolicyNotFound
+	}
+
+	return err
+}
+
+func (s *Store) List(ctx context.Context) ([]string, error) {
+	keys, err := s.storage.List(ctx, s.path)
+	if err != nil {
+		return nil, err
+	}
+
+	keys = append(keys, Root)
+	slices.Sort(keys)
+
+	return keys, nil
+}
+
+// ACL builds the ACL for a set of policy names. Unknown policies grant
+// nothing, just like in Vault.
+func (s *Store) ACL(ctx context.Context, names []string) (*ACL, error) {
+	policies := make([]Policy, 0, len(names))
+	for _, name := range names {
+		p, err := s.Get(ctx, name)
+		if errors.Is(err, ErrPolicyNotFound) {
+			continue
+		}
+		if err != nil {
+			return nil, err
+		}
+		policies = append(policies, p)
+	}
+
+	return NewACL(policies), nil
+}
+
+func (s *Store) read(ctx context.Context, name string) (record, error) {
+	b, err := s.storage.Get(ctx, filepath.Join(s.path, name))
+	if errors.Is(err, storage.ErrPathNotFound) {
+		return record{}, ErrPolicyNotFound
+	}
+	if err != nil {
+		return record{}, err
+	}
+
+	decryptedData, err := s.encryptor.Decrypt(b)
+	if err != nil {
+		return record{}, err
+	}
+
+	var r record
+	err = json.Unmarshal(decryptedData, &r)
+	if err != nil {
+		return record{}, err
+	}
+
+	return r, nil
+}
+
+func (s *Store) write(ctx context.Context, r record) error {
+	d, err := json.Marshal(r)
+	if err != nil {
+		return err
+	}
+
+	encryptedData, err := s.encryptor.Encrypt(d)
+	if err != nil {
+		return err
+	}
+
+	return s.storage.Put(ctx, filepath.Join(s.path, r.Name), encryptedData)
+}
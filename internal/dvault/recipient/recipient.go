@@ -0,0 +1,129 @@
+// Package recipient encrypts key shares and root tokens to the public keys of
+// the operators they are handed out to, so the plaintext only ever reaches
+// its holder.
+package recipient
+
+import (
+	"bytes"
+	"encoding/base64"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"io"
+	"strings"
+	"time"
+
+	"filippo.io/age"
+	"github.com/ProtonMail/go-crypto/openpgp"
+)
+
+var ErrInvalidKey = errors.New("invalid public key")
+
+// Recipient is the public key of the holder of a secret.
+type Recipient interface {
+	// Encrypt returns the base64 encoded ciphertext of plaintext, which only
+	// the holder of the private key can decrypt.
+	Encrypt(plaintext []byte) (string, error)
+	// Fingerprint identifies the key in responses.
+	Fingerprint() string
+}
+
+// Parse parses an age X25519 recipient ("age1...") or an OpenPGP public key,
+// which is either ASCII armored or base64 encoded like Vault expects it.
+func Parse(key string) (Recipient, error) {
+	key = strings.TrimSpace(key)
+
+	if strings.HasPrefix(key, "age1") {
+		r, err := age.ParseX25519Recipient(key)
+		if err != nil {
+			return nil, fmt.Errorf("%w: %s", ErrInvalidKey, err)
+		}
+
+		return ageRecipient{recipient: r}, nil
+	}
+
+	var entities openpgp.EntityList
+	var err error
+	if strings.HasPrefix(key, "-----BEGIN PGP") {
+		entities, err = openpgp.ReadArmoredKeyRing(strings.NewReader(key))
+	} else {
+		var b []byte
+		if b, err = base64.StdEncoding.DecodeString(key); err != nil {
+			return nil, fmt.Errorf("%w: expected an age recipient, an armored or a base64 encoded OpenPGP key", ErrInvalidKey)
+		}
+		entities, err = openpgp.ReadKeyRing(bytes.NewReader(b))
+	}
+	if err != nil {
+		return nil, fmt.Errorf("%w: %s", ErrInvalidKey, err)
+	}
+
+	if len(entities) != 1 {
+		return nil, fmt.Errorf("%w: expected one OpenPGP key, got %d", ErrInvalidKey, len(entities))
+	}
+	if _, ok := entities[0].EncryptionKey(time.Now()); !ok {
+		return nil, fmt.Errorf("%w: OpenPGP key %X has no valid encryption key", ErrInvalidKey, entities[0].PrimaryKey.Fingerprint)
+	}
+
+	return pgpRecipient{entity: entities[0]}, nil
+}
+
+// ParseAll parses the keys, reporting the position of an invalid one.
+func ParseAll(keys []string) ([]Recipient, error) {
+	recipients := make([]Recipient, 0, len(keys))
+	for i, key := range keys {
+		r, err := Parse(key)
+		if err != nil {
+			return nil, fmt.Errorf("key %d: %w", i, err)
+		}
+		recipients = append(recipients, r)
+	}
+
+	return recipients, nil
+}
+
+type pgpRecipient struct {
+	entity *openpgp.Entity
+}
+
+func (r pgpRecipient) Encrypt(plaintext []byte) (string, error) {
+	var buf bytes.Buffer
+	w, err := openpgp.Encrypt(&buf, []*openpgp.Entity{r.entity}, nil, nil, nil)
+	if err != nil {
+		return "", err
+	}
+
+	return encrypted(&buf, w, plaintext)
+}
+
+func (r pgpRecipient) Fingerprint() string {
+	return hex.EncodeToString(r.entity.PrimaryKey.Fingerprint)
+}
+
+type ageRecipient struct {
+	recipient *age.X25519Recipient
+}
+
+func (r ageRecipient) Encrypt(plaintext []byte) (string, error) {
+	var buf bytes.Buffer
+	w, err := age.Encrypt(&buf, r.recipient)
+	if err != nil {
+		return "", err
+	}
+
+	return encrypted(&buf, w, plaintext)
+}
+
+func (r ageRecipient) Fingerprint() string {
+	return r.recipient.String()
+}
+
+func encrypted(buf *bytes.Buffer, w io.WriteCloser, plaintext []byte) (string, error) {
+	if _, err := w.Write(plaintext); err != nil {
+		return "", err
+	}
+	if err := w.Close(); err != nil {
+		return "", err
+	}
+
+	return base64.StdEncoding.EncodeToString(buf.Bytes()), nil
+}
@@ -0,0 +1,325 @@
+package dvault
+
+import (
+	"context"
+	"crypto/rand"
+	"crypto/sha256"
+	"crypto/subtle"
+	"errors"
+	"fmt"
+
+	"github.com/Burzich/dvault/internal/dvault/recipient"
+	"github.com/Burzich/dvault/internal/tools"
+	"github.com/cloudflare/circl/group"
+)
+
+var ErrRekeyNotStarted = errors.New("no rekey in progress")
+var ErrRekeyInProgress = errors.New("rekey already in progress")
+var ErrRekeyVerificationNotStarted = errors.New("no rekey verification in progress")
+var ErrInvalidRekey = errors.New("invalid rekey configuration")
+
+// maxShares is the most shares a root key can be split into, as in Vault.
+const maxShares = 255
+
+// rekeyAttempt collects the current shares that authorize replacing them with
+// n new shares. A new root key is split, so the old shares can no longer
+// unseal once the rekey completes. With an auto seal the shares are recovery
+// keys and a new recovery key is split instead.
+type rekeyAttempt struct {
+	nonce               string
+	n                   int
+	t                   int
+	recipients          []recipient.Recipient
+	requireVerification bool
+	shares              shareSet
+
+	// The new key waits here until a threshold of the new shares proved that
+	// their holders received them.
+	secret            []byte
+	verificationNonce string
+	verification      shareSet
+}
+
+func (d *DVault) RekeyStatus(_ context.Context) (RekeyStatus, error) {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+
+	if d.isSealed {
+		return RekeyStatus{}, ErrSealed
+	}
+
+	return d.rekeyStatus(), nil
+}
+
+func (d *DVault) StartRekey(_ context.Context, init RekeyInit) (RekeyStatus, error) {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+
+	if d.isSealed {
+		return RekeyStatus{}, ErrSealed
+	}
+
+	if d.rekey != nil {
+		return RekeyStatus{}, ErrRekeyInProgress
+	}
+
+	if init.SecretShares < 1 || init.SecretShares > maxShares {
+		return RekeyStatus{}, fmt.Errorf("%w: secret_shares must be between 1 and %d", ErrInvalidRekey, maxShares)
+	}
+	if init.SecretThreshold < 1 || init.SecretThreshold > init.SecretShares {
+		return RekeyStatus{}, fmt.Errorf("%w: secret_threshold must be between 1 and secret_shares", ErrInvalidRekey)
+	}
+	if len(init.PgpKeys) != 0 && len(init.PgpKeys) != init.SecretShares {
+		return RekeyStatus{}, fmt.Errorf("%w: %d pgp_keys given for %d secret_shares", ErrInvalidRekey, len(init.PgpKeys), init.SecretShares)
+	}
+
+	recipients, err := recipient.ParseAll(init.PgpKeys)
+	if err != nil {
+		return RekeyStatus{}, fmt.Errorf("pgp_keys: %w", err)
+	}
+
+	d.rekey = &rekeyAttempt{
+		nonce:               tools.GenerateXRequestID(),
+		n:                   init.SecretShares,
+		t:                   init.SecretThreshold,
+		recipients:          recipients,
+		requireVerification: init.RequireVerification,
+	}
+
+	return d.rekeyStatus(), nil
+}
+
+func (d *DVault) CancelRekey(_ context.Context) error {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+
+	d.rekey = nil
+
+	return nil
+}
+
+// UpdateRekey adds a current share. Once the threshold is reached the new
+// shares are returned; without required verification they are in effect
+// immediately.
+func (d *DVault) UpdateRekey(_ context.Context, update RekeyUpdate) (RekeyStatus, error) {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+
+	if d.isSealed {
+		return RekeyStatus{}, ErrSealed
+	}
+
+	attempt := d.rekey
+	if attempt == nil {
+		return RekeyStatus{}, ErrRekeyNotStarted
+	}
+
+	if update.Nonce != attempt.nonce {
+		return RekeyStatus{}, ErrInvalidNonce
+	}
+
+	if attempt.secret != nil {
+		return RekeyStatus{}, fmt.Errorf("%w: rekey is waiting for verification", ErrRekeyInProgress)
+	}
+
+	if err := attempt.shares.add(update.Key); err != nil {
+		return RekeyStatus{}, err
+	}
+
+	if attempt.shares.len() < d.T {
+		return d.rekeyStatus(), nil
+	}
+
+	if err := d.checkShares(attempt.shares.list()); err != nil {
+		d.rekey = nil
+		return RekeyStatus{}, err
+	}
+
+	secret := group.P256.RandomScalar(rand.Reader)
+	keys, err := splitSecret(secret, uint(attempt.n), uint(attempt.t))
+	if err != nil {
+		return RekeyStatus{}, err
+	}
+
+	secretBytes, err := secret.MarshalBinary()
+	if err != nil {
+		return RekeyStatus{}, err
+	}
+
+	if len(attempt.recipients) != 0 {
+		if keys, err = encryptShares(attempt.recipients, keys); err != nil {
+			return RekeyStatus{}, err
+		}
+	}
+
+	status := d.rekeyStatus()
+	status.Complete = true
+	status.Keys = keys
+	status.KeysBase64 = keys
+
+	if attempt.requireVerification {
+		attempt.secret = secretBytes
+		attempt.verificationNonce = tools.GenerateXRequestID()
+		status.VerificationNonce = attempt.verificationNonce
+
+		return status, nil
+	}
+
+	if err = d.replaceShares(secretBytes, attempt.n, attempt.t); err != nil {
+		return RekeyStatus{}, err
+	}
+
+	return status, nil
+}
+
+func (d *DVault) RekeyVerificationStatus(_ context.Context) (RekeyVerificationStatus, error) {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+
+	if d.isSealed {
+		return RekeyVerificationStatus{}, ErrSealed
+	}
+
+	if d.rekey == nil || d.rekey.secret == nil {
+		return RekeyVerificationStatus{}, ErrRekeyVerificationNotStarted
+	}
+
+	return d.rekeyVerificationStatus(), nil
+}
+
+// CancelRekeyVerification discards the shares submitted for verification and
+// starts over with a new nonce. The rekey itself stays pending.
+func (d *DVault) CancelRekeyVerification(_ context.Context) (RekeyVerificationStatus, error) {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+
+	if d.isSealed {
+		return RekeyVerificationStatus{}, ErrSealed
+	}
+
+	if d.rekey == nil || d.rekey.secret == nil {
+		return RekeyVerificationStatus{}, ErrRekeyVerificationNotStarted
+	}
+
+	d.rekey.verificationNonce = tools.GenerateXRequestID()
+	d.rekey.verification = shareSet{}
+
+	return d.rekeyVerificationStatus(), nil
+}
+
+// VerifyRekey adds a new share. Once the new threshold is reached and the
+// shares recover the new root key, it replaces the old one.
+func (d *DVault) VerifyRekey(_ context.Context, update RekeyUpdate) (RekeyVerificationStatus, error) {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+
+	if d.isSealed {
+		return RekeyVerificationStatus{}, ErrSealed
+	}
+
+	attempt := d.rekey
+	if attempt == nil || attempt.secret == nil {
+		return RekeyVerificationStatus{}, ErrRekeyVerificationNotStarted
+	}
+
+	if update.Nonce != attempt.verificationNonce {
+		return RekeyVerificationStatus{}, ErrInvalidNonce
+	}
+
+	if err := attempt.verification.add(update.Key); err != nil {
+		return RekeyVerificationStatus{}, err
+	}
+
+	if attempt.verification.len() < attempt.t {
+		return d.rekeyVerificationStatus(), nil
+	}
+
+	secret, err := combineShares(attempt.verification.list(), attempt.t)
+	if err != nil {
+		return RekeyVerificationStatus{}, err
+	}
+
+	if subtle.ConstantTimeCompare(secret, attempt.secret) != 1 {
+		attempt.verification = shareSet{}
+		return RekeyVerificationStatus{}, fmt.Errorf("%w: shares do not match the new key", ErrInvalidShare)
+	}
+
+	status := d.rekeyVerificationStatus()
+	status.Complete = true
+
+	if err = d.replaceShares(secret, attempt.n, attempt.t); err != nil {
+		return RekeyVerificationStatus{}, err
+	}
+
+	return status, nil
+}
+
+// replaceShares puts the key the new shares were split from in effect and
+// ends the rekey. Attempts that collect the old shares are dropped.
+//
+// Auto seals keep the root key, so the rekey only replaces the recovery key
+// in the seal state, which one atomic write commits.
+func (d *DVault) replaceShares(secret []byte, n int, t int) error {
+	if d.seal != nil {
+		recoveryKeyHash := sha256.Sum256(secret)
+		state := *d.sealState
+		state.RecoveryKeyHash = recoveryKeyHash[:]
+		state.RecoveryShares = n
+		state.RecoveryThreshold = t
+		if err := d.saveSealState(state); err != nil {
+			return err
+		}
+	} else {
+		keyringData, err := d.keyring.MarshalBinary()
+		if err != nil {
+			return err
+		}
+
+		if err = d.saveKey(secret, keyringData, uint(n), uint(t)); err != nil {
+			return err
+		}
+
+		d.rootKey = secret
+	}
+
+	d.N = n
+	d.T = t
+	d.rekey = nil
+	d.generateRoot = nil
+
+	return nil
+}
+
+func (d *DVault) rekeyStatus() RekeyStatus {
+	if d.rekey == nil {
+		return RekeyStatus{
+			Required: d.T,
+		}
+	}
+
+	fingerprints := make([]string, 0, len(d.rekey.recipients))
+	for _, r := range d.rekey.recipients {
+		fingerprints = append(fingerprints, r.Fingerprint())
+	}
+
+	return RekeyStatus{
+		Started:              true,
+		Nonce:                d.rekey.nonce,
+		T:                    d.rekey.t,
+		N:                    d.rekey.n,
+		Progress:             d.rekey.shares.len(),
+		Required:             d.T,
+		PgpFingerprints:      fingerprints,
+		VerificationRequired: d.rekey.requireVerification,
+	}
+}
+
+func (d *DVault) rekeyVerificationStatus() RekeyVerificationStatus {
+	return RekeyVerificationStatus{
+		Started:  true,
+		Nonce:    d.rekey.verificationNonce,
+		T:        d.rekey.t,
+		N:        d.rekey.n,
+		Progress: d.rekey.verification.len(),
+	}
+}
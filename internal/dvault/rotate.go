@@ -0,0 +1,261 @@
+package dvault
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log/slog"
+	"path/filepath"
+	"slices"
+	"strings"
+
+	"github.com/Burzich/dvault/internal/dvault/storage"
+	"github.com/Burzich/dvault/internal/tools"
+)
+
+// errRewrapStopped ends a rewrap pass when the vault is sealed or the keyring
+// it works on is replaced.
+var errRewrapStopped = errors.New("rewrap stopped")
+
+// errRewrapDecrypt marks an entry the keyring could not decrypt. The pass goes
+// on, but the older terms are kept, as the entry may still need them.
+var errRewrapDecrypt = errors.New("could not decrypt entry")
+
+// unencryptedFiles are the files in the root of the storage that are not
+// encrypted with the keyring, along with their temporary files.
+var unencryptedFiles = []string{
+	"key",
+	"key-*.tmp",
+	sealStateFile,
+	sealStateFile + "-*.tmp",
+}
+
+// rewrapper re-encrypts everything in storage with the newest term of a
+// keyring and then drops the older terms.
+type rewrapper struct {
+	keyring *tools.Keyring
+	ctx     context.Context
+	cancel  context.CancelFunc
+}
+
+// Rotate adds a new term to the keyring. New writes are encrypted with it at
+// once; existing data is rewrapped in the background.
+func (d *DVault) Rotate(_ context.Context) (Response, error) {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+
+	if d.isSealed {
+		return Response{}, ErrSealed
+	}
+
+	key, err := d.keyring.Rotate(d.persistKeyring)
+	if err != nil {
+		return Response{}, err
+	}
+
+	d.logger.Info("rotated keyring", slog.Int("term", int(key.Term)))
+	d.startRewrap()
+
+	var response Response
+	response.RequestId = tools.GenerateXRequestID()
+
+	return response, nil
+}
+
+func (d *DVault) KeyStatus(_ context.Context) (Response, error) {
+	d.mu.RLock()
+	defer d.mu.RUnlock()
+
+	if d.isSealed {
+		return Response{}, ErrSealed
+	}
+
+	key := d.keyring.Active()
+
+	var response Response
+	response.Data = KeyStatusData{
+		Term:        int(key.Term),
+		InstallTime: key.InstallTime,
+		Terms:       d.keyring.Len(),
+	}
+	response.RequestId = tools.GenerateXRequestID()
+
+	return response, nil
+}
+
+func (d *DVault) persistKeyring(keyringData []byte) error {
+	return d.saveKey(d.rootKey, keyringData, uint(d.N), uint(d.T))
+}
+
+// startRewrap starts a rewrap of the current keyring unless one is running.
+// A running pass notices a rotation on its own and starts over.
+func (d *DVault) startRewrap() {
+	if d.rewrap != nil && d.rewrap.keyring == d.keyring {
+		return
+	}
+	d.stopRewrap()
+
+	ctx, cancel := context.WithCancel(context.Background())
+	r := &rewrapper{keyring: d.keyring, ctx: ctx, cancel: cancel}
+	d.rewrap = r
+
+	go d.runRewrap(r)
+}
+
+func (d *DVault) stopRewrap() {
+	if d.rewrap != nil {
+		d.rewrap.cancel()
+		d.rewrap = nil
+	}
+}
+
+func (d *DVault) runRewrap(r *rewrapper) {
+	defer func() {
+		d.mu.Lock()
+		if d.rewrap == r {
+			d.rewrap = nil
+		}
+		d.mu.Unlock()
+	}()
+
+	for {
+		term := r.keyring.Active().Term
+
+		var result rewrapResult
+		err := d.rewrapTree(r, "", &result)
+		if errors.Is(err, errRewrapStopped) {
+			return
+		}
+		if err != nil {
+			d.logger.Error("rewrap storage", slog.String("error", err.Error()))
+			return
+		}
+
+		if result.failed > 0 {
+			d.logger.Error("rewrap storage: keeping older key terms",
+				slog.Int("term", int(term)), slog.Int("failed", result.failed))
+			return
+		}
+
+		done, err := d.finishRewrap(r, term)
+		if errors.Is(err, errRewrapStopped) {
+			return
+		}
+		if err != nil {
+			d.logger.Error("prune keyring", slog.String("error", err.Error()))
+			return
+		}
+		if done {
+			d.logger.Info("rewrapped storage", slog.Int("term", int(term)), slog.Int("rewrapped", result.rewrapped))
+			return
+		}
+	}
+}
+
+// finishRewrap drops the terms before term, unless the keyring was rotated
+// during the pass and another one is needed. It must only be called after a
+// pass that rewrapped every entry.
+func (d *DVault) finishRewrap(r *rewrapper, term uint32) (bool, error) {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+
+	if r.ctx.Err() != nil || d.isSealed {
+		return false, errRewrapStopped
+	}
+
+	if r.keyring.Active().Term != term {
+		return false, nil
+	}
+
+	return true, r.keyring.Prune(term, d.persistKeyring)
+}
+
+// rewrapResult counts the entries of a pass.
+type rewrapResult struct {
+	rewrapped int
+	failed    int
+}
+
+// rewrapTree re-encrypts the entries below path that are not encrypted with
+// the newest term. Entries that can not be decrypted are logged and counted
+// as failed.
+func (d *DVault) rewrapTree(r *rewrapper, path string, result *rewrapResult) error {
+	keys, err := d.Storage.List(r.ctx, path)
+	if err != nil {
+		return err
+	}
+
+	for _, key := range keys {
+		if dir, ok := strings.CutSuffix(key, "/"); ok {
+			if err = d.rewrapTree(r, filepath.Join(path, dir), result); err != nil {
+				return err
+			}
+			continue
+		}
+
+		entryPath := filepath.Join(path, key)
+		if isUnencrypted(entryPath) {
+			continue
+		}
+
+		ok, err := d.rewrapEntry(r, entryPath)
+		if errors.Is(err, errRewrapDecrypt) {
+			d.logger.Error("rewrap entry", slog.String("path", entryPath), slog.String("error", err.Error()))
+			result.failed++
+			continue
+		}
+		if err != nil {
+			return err
+		}
+		if ok {
+			result.rewrapped++
+		}
+	}
+
+	return nil
+}
+
+// rewrapEntry holds the vault lock, so requests can not change the entry
+// between reading and writing it back. Token revocations run in the
+// background without the vault lock, so token and cubbyhole entries are
+// rewritten under the lock of the token store instead.
+func (d *DVault) rewrapEntry(r *rewrapper, path string) (bool, error) {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+
+	if r.ctx.Err() != nil || d.isSealed {
+		return false, errRewrapStopped
+	}
+
+	if isBelow(path, tokenPath) || isBelow(path, cubbyholePath) {
+		return d.tokens.Rewrite(r.ctx, path, r.rewrap)
+	}
+
+	return storage.Rewrite(r.ctx, d.Storage, path, r.rewrap)
+}
+
+// rewrap returns data encrypted with the newest term, or nil when it already
+// is.
+func (r *rewrapper) rewrap(data []byte) ([]byte, error) {
+	if len(data) == 0 || r.keyring.Current(data) {
+		return nil, nil
+	}
+
+	plaintext, err := r.keyring.Decrypt(data)
+	if err != nil {
+		return nil, fmt.Errorf("%w: %s", errRewrapDecrypt, err)
+	}
+
+	return r.keyring.Encrypt(plaintext)
+}
+
+func isUnencrypted(path string) bool {
+	return slices.ContainsFunc(unencryptedFiles, func(pattern string) bool {
+		ok, _ := filepath.Match(pattern, path)
+		return ok
+	})
+}
+
+func isBelow(path string, dir string) bool {
+	return strings.HasPrefix(path, dir+"/")
+}
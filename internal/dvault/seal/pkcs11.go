@@ -0,0 +1,274 @@
+//go:build pkcs11
+
+package seal
+
+import (
+	"context"
+	"crypto/rand"
+	"errors"
+	"fmt"
+	"strconv"
+	"strings"
+	"sync"
+
+	"github.com/Burzich/dvault/internal/config"
+	"github.com/miekg/pkcs11"
+)
+
+const (
+	gcmNonceSize = 12
+	gcmTagBits   = 128
+)
+
+// PKCS11 wraps the root key with a key that never leaves an HSM: AES keys
+// with AES-GCM, RSA key pairs with RSA-OAEP.
+type PKCS11 struct {
+	ctx *pkcs11.Ctx
+
+	// A session runs one operation at a time.
+	mu      sync.Mutex
+	session pkcs11.SessionHandle
+
+	keyType    uint
+	encryptKey pkcs11.ObjectHandle
+	decryptKey pkcs11.ObjectHandle
+}
+
+func NewPKCS11(cfg config.PKCS11Seal) (Seal, error) {
+	if cfg.Lib == "" || cfg.KeyLabel == "" {
+		return nil, fmt.Errorf("%w: pkcs11 seal requires lib and key_label", ErrInvalidConfig)
+	}
+	if (cfg.Slot == "") == (cfg.TokenLabel == "") {
+		return nil, fmt.Errorf("%w: pkcs11 seal requires either slot or token_label", ErrInvalidConfig)
+	}
+
+	ctx := pkcs11.New(cfg.Lib)
+	if ctx == nil {
+		return nil, fmt.Errorf("%w: could not load pkcs11 library %q", ErrInvalidConfig, cfg.Lib)
+	}
+
+	if err := ctx.Initialize(); err != nil && !errors.Is(err, pkcs11.Error(pkcs11.CKR_CRYPTOKI_ALREADY_INITIALIZED)) {
+		ctx.Destroy()
+		return nil, fmt.Errorf("pkcs11 initialize: %w", err)
+	}
+
+	p := &PKCS11{ctx: ctx}
+	if err := p.open(cfg); err != nil {
+		ctx.Finalize()
+		ctx.Destroy()
+		return nil, err
+	}
+
+	return p, nil
+}
+
+// open logs in to the token and looks up the key.
+func (p *PKCS11) open(cfg config.PKCS11Seal) error {
+	slot, err := p.findSlot(cfg)
+	if err != nil {
+		return err
+	}
+
+	p.session, err = p.ctx.OpenSession(slot, pkcs11.CKF_SERIAL_SESSION)
+	if err != nil {
+		return fmt.Errorf("pkcs11 open session: %w", err)
+	}
+
+	err = p.ctx.Login(p.session, pkcs11.CKU_USER, cfg.PIN)
+	if err != nil && !errors.Is(err, pkcs11.Error(pkcs11.CKR_USER_ALREADY_LOGGED_IN)) {
+		p.ctx.CloseSession(p.session)
+		return fmt.Errorf("pkcs11 login: %w", err)
+	}
+
+	if err = p.findKey(cfg.KeyLabel); err != nil {
+		p.ctx.CloseSession(p.session)
+		return err
+	}
+
+	return nil
+}
+
+func (p *PKCS11) findSlot(cfg config.PKCS11Seal) (uint, error) {
+	slots, err := p.ctx.GetSlotList(true)
+	if err != nil {
+		return 0, fmt.Errorf("pkcs11 list slots: %w", err)
+	}
+
+	if cfg.Slot != "" {
+		id, err := strconv.ParseUint(cfg.Slot, 0, 64)
+		if err != nil {
+			return 0, fmt.Errorf("%w: invalid pkcs11 slot %q", ErrInvalidConfig, cfg.Slot)
+		}
+
+		for _, slot := range slots {
+			if slot == uint(id) {
+				return slot, nil
+			}
+		}
+
+		return 0, fmt.Errorf("%w: no token in pkcs11 slot %s", ErrInvalidConfig, cfg.Slot)
+	}
+
+	for _, slot := range slots {
+		info, err := p.ctx.GetTokenInfo(slot)
+		if err != nil {
+			return 0, fmt.Errorf("pkcs11 token info: %w", err)
+		}
+
+		// Token labels are padded with spaces to 32 bytes.
+		if strings.TrimRight(info.Label, " \x00") == cfg.TokenLabel {
+			return slot, nil
+		}
+	}
+
+	return 0, fmt.Errorf("%w: no pkcs11 token labeled %q", ErrInvalidConfig, cfg.TokenLabel)
+}
+
+// findKey looks for an AES key with the label, then for an RSA key pair.
+func (p *PKCS11) findKey(label string) error {
+	secretKey, ok, err := p.findObject(label, pkcs11.CKO_SECRET_KEY, pkcs11.CKK_AES)
+	if err != nil {
+		return err
+	}
+	if ok {
+		p.keyType = pkcs11.CKK_AES
+		p.encryptKey = secretKey
+		p.decryptKey = secretKey
+
+		return nil
+	}
+
+	publicKey, okPublic, err := p.findObject(label, pkcs11.CKO_PUBLIC_KEY, pkcs11.CKK_RSA)
+	if err != nil {
+		return err
+	}
+	privateKey, okPrivate, err := p.findObject(label, pkcs11.CKO_PRIVATE_KEY, pkcs11.CKK_RSA)
+	if err != nil {
+		return err
+	}
+	if !okPublic || !okPrivate {
+		return fmt.Errorf("%w: no pkcs11 AES key or RSA key pair labeled %q", ErrInvalidConfig, label)
+	}
+
+	p.keyType = pkcs11.CKK_RSA
+	p.encryptKey = publicKey
+	p.decryptKey = privateKey
+
+	return nil
+}
+
+func (p *PKCS11) findObject(label string, class uint, keyType uint) (pkcs11.ObjectHandle, bool, error) {
+	err := p.ctx.FindObjectsInit(p.session, []*pkcs11.Attribute{
+		pkcs11.NewAttribute(pkcs11.CKA_CLASS, class),
+		pkcs11.NewAttribute(pkcs11.CKA_KEY_TYPE, keyType),
+		pkcs11.NewAttribute(pkcs11.CKA_LABEL, label),
+	})
+	if err != nil {
+		return 0, false, fmt.Errorf("pkcs11 find key: %w", err)
+	}
+	defer p.ctx.FindObjectsFinal(p.session)
+
+	objects, _, err := p.ctx.FindObjects(p.session, 2)
+	if err != nil {
+		return 0, false, fmt.Errorf("pkcs11 find key: %w", err)
+	}
+
+	switch len(objects) {
+	case 0:
+		return 0, false, nil
+	case 1:
+		return objects[0], true, nil
+	default:
+		return 0, false, fmt.Errorf("%w: more than one pkcs11 key labeled %q", ErrInvalidConfig, label)
+	}
+}
+
+func (p *PKCS11) Type() string {
+	return "pkcs11"
+}
+
+// Encrypt prefixes AES-GCM ciphertexts with their nonce.
+func (p *PKCS11) Encrypt(_ context.Context, plaintext []byte) ([]byte, error) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	if p.keyType == pkcs11.CKK_RSA {
+		ciphertext, err := p.rsa(plaintext, p.ctx.EncryptInit, p.ctx.Encrypt, p.encryptKey)
+		if err != nil {
+			return nil, fmt.Errorf("pkcs11 encrypt: %w", err)
+		}
+
+		return ciphertext, nil
+	}
+
+	nonce := make([]byte, gcmNonceSize)
+	if _, err := rand.Read(nonce); err != nil {
+		return nil, err
+	}
+
+	params := pkcs11.NewGCMParams(nonce, nil, gcmTagBits)
+	defer params.Free()
+
+	err := p.ctx.EncryptInit(p.session, []*pkcs11.Mechanism{pkcs11.NewMechanism(pkcs11.CKM_AES_GCM, params)}, p.encryptKey)
+	if err != nil {
+		return nil, fmt.Errorf("pkcs11 encrypt: %w", err)
+	}
+
+	ciphertext, err := p.ctx.Encrypt(p.session, plaintext)
+	if err != nil {
+		return nil, fmt.Errorf("pkcs11 encrypt: %w", err)
+	}
+
+	// Some HSMs ignore the given nonce and pick their own.
+	return append(params.IV(), ciphertext...), nil
+}
+
+func (p *PKCS11) Decrypt(_ context.Context, ciphertext []byte) ([]byte, error) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	if p.keyType == pkcs11.CKK_RSA {
+		plaintext, err := p.rsa(ciphertext, p.ctx.DecryptInit, p.ctx.Decrypt, p.decryptKey)
+		if err != nil {
+			return nil, fmt.Errorf("pkcs11 decrypt: %w", err)
+		}
+
+		return plaintext, nil
+	}
+
+	if len(ciphertext) < gcmNonceSize {
+		return nil, errors.New("pkcs11 decrypt: ciphertext too short")
+	}
+
+	params := pkcs11.NewGCMParams(ciphertext[:gcmNonceSize], nil, gcmTagBits)
+	defer params.Free()
+
+	err := p.ctx.DecryptInit(p.session, []*pkcs11.Mechanism{pkcs11.NewMechanism(pkcs11.CKM_AES_GCM, params)}, p.decryptKey)
+	if err != nil {
+		return nil, fmt.Errorf("pkcs11 decrypt: %w", err)
+	}
+
+	plaintext, err := p.ctx.Decrypt(p.session, ciphertext[gcmNonceSize:])
+	if err != nil {
+		return nil, fmt.Errorf("pkcs11 decrypt: %w", err)
+	}
+
+	return plaintext, nil
+}
+
+// rsa runs an RSA-OAEP operation. SHA-1 is the only OAEP hash SoftHSM
+// supports; OAEP does not depend on its collision resistance.
+func (p *PKCS11) rsa(
+	data []byte,
+	init func(pkcs11.SessionHandle, []*pkcs11.Mechanism, pkcs11.ObjectHandle) error,
+	op func(pkcs11.SessionHandle, []byte) ([]byte, error),
+	key pkcs11.ObjectHandle,
+) ([]byte, error) {
+	params := pkcs11.NewOAEPParams(pkcs11.CKM_SHA_1, pkcs11.CKG_MGF1_SHA1, pkcs11.CKZ_DATA_SPECIFIED, nil)
+
+	if err := init(p.session, []*pkcs11.Mechanism{pkcs11.NewMechanism(pkcs11.CKM_RSA_PKCS_OAEP, params)}, key); err != nil {
+		return nil, err
+	}
+
+	return op(p.session, data)
+}
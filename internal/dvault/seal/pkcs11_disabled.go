@@ -0,0 +1,15 @@
+//go:build !pkcs11
+
+package seal
+
+import (
+	"fmt"
+
+	"github.com/Burzich/dvault/internal/config"
+)
+
+// NewPKCS11 fails in builds without the pkcs11 tag, which do not need cgo or
+// an HSM library.
+func NewPKCS11(_ config.PKCS11Seal) (Seal, error) {
+	return nil, fmt.Errorf("%w: pkcs11 seal is not supported by this build, rebuild with -tags pkcs11", ErrInvalidConfig)
+}
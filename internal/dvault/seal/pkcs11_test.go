@@ -0,0 +1,264 @@
+//go:build pkcs11
+
+package seal
+
+import (
+	"bytes"
+	"context"
+	"crypto/rand"
+	"encoding/hex"
+	"errors"
+	"os"
+	"strings"
+	"testing"
+
+	"github.com/Burzich/dvault/internal/config"
+	"github.com/miekg/pkcs11"
+)
+
+// The tests run against SoftHSM2. They need SOFTHSM2_CONF pointing to a
+// configuration with a writable token directory, and the module at
+// SOFTHSM2_MODULE or the default Debian path.
+const defaultSoftHSMModule = "/usr/lib/softhsm/libsofthsm2.so"
+
+const (
+	testSOPIN     = "12345678"
+	testUserPIN   = "1234"
+	testAESLabel  = "dvault-aes"
+	testRSALabel  = "dvault-rsa"
+	rootKeyLength = 32
+)
+
+func TestPKCS11RoundTrip(t *testing.T) {
+	lib, tokenLabel := newSoftHSMToken(t)
+
+	for _, keyLabel := range []string{testAESLabel, testRSALabel} {
+		t.Run(keyLabel, func(t *testing.T) {
+			s, err := NewPKCS11(config.PKCS11Seal{
+				Lib:        lib,
+				TokenLabel: tokenLabel,
+				PIN:        testUserPIN,
+				KeyLabel:   keyLabel,
+			})
+			if err != nil {
+				t.Fatalf("NewPKCS11: %v", err)
+			}
+
+			plaintext := make([]byte, rootKeyLength)
+			if _, err = rand.Read(plaintext); err != nil {
+				t.Fatal(err)
+			}
+
+			ciphertext, err := s.Encrypt(context.Background(), plaintext)
+			if err != nil {
+				t.Fatalf("Encrypt: %v", err)
+			}
+			if bytes.Contains(ciphertext, plaintext) {
+				t.Fatal("ciphertext contains the plaintext")
+			}
+
+			decrypted, err := s.Decrypt(context.Background(), ciphertext)
+			if err != nil {
+				t.Fatalf("Decrypt: %v", err)
+			}
+			if !bytes.Equal(decrypted, plaintext) {
+				t.Fatalf("Decrypt = %x, want %x", decrypted, plaintext)
+			}
+
+			ciphertext[len(ciphertext)-1] ^= 1
+			if _, err = s.Decrypt(context.Background(), ciphertext); err == nil {
+				t.Fatal("Decrypt of a modified ciphertext succeeded")
+			}
+		})
+	}
+}
+
+func TestPKCS11WrongPIN(t *testing.T) {
+	lib, tokenLabel := newSoftHSMToken(t)
+
+	_, err := NewPKCS11(config.PKCS11Seal{
+		Lib:        lib,
+		TokenLabel: tokenLabel,
+		PIN:        "wrong",
+		KeyLabel:   testAESLabel,
+	})
+	if !errors.Is(err, pkcs11.Error(pkcs11.CKR_PIN_INCORRECT)) {
+		t.Fatalf("NewPKCS11 error = %v, want %v", err, pkcs11.Error(pkcs11.CKR_PIN_INCORRECT))
+	}
+}
+
+func TestPKCS11MissingLabel(t *testing.T) {
+	lib, tokenLabel := newSoftHSMToken(t)
+
+	tests := []struct {
+		name string
+		cfg  config.PKCS11Seal
+	}{
+		{
+			name: "key",
+			cfg:  config.PKCS11Seal{Lib: lib, TokenLabel: tokenLabel, PIN: testUserPIN, KeyLabel: "missing"},
+		},
+		{
+			name: "token",
+			cfg:  config.PKCS11Seal{Lib: lib, TokenLabel: "missing", PIN: testUserPIN, KeyLabel: testAESLabel},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, err := NewPKCS11(tt.cfg)
+			if !errors.Is(err, ErrInvalidConfig) {
+				t.Fatalf("NewPKCS11 error = %v, want %v", err, ErrInvalidConfig)
+			}
+		})
+	}
+}
+
+// newSoftHSMToken initializes a token of its own for the test, holding an AES
+// key and an RSA key pair, and returns the module path and the token label.
+func newSoftHSMToken(t *testing.T) (string, string) {
+	t.Helper()
+
+	if os.Getenv("SOFTHSM2_CONF") == "" {
+		t.Skip("SOFTHSM2_CONF is not set")
+	}
+
+	lib := os.Getenv("SOFTHSM2_MODULE")
+	if lib == "" {
+		lib = defaultSoftHSMModule
+	}
+	if _, err := os.Stat(lib); err != nil {
+		t.Skipf("SoftHSM2 module not found: %v", err)
+	}
+
+	ctx := pkcs11.New(lib)
+	if ctx == nil {
+		t.Fatalf("could not load %s", lib)
+	}
+	if err := ctx.Initialize(); err != nil && !errors.Is(err, pkcs11.Error(pkcs11.CKR_CRYPTOKI_ALREADY_INITIALIZED)) {
+		t.Fatalf("Initialize: %v", err)
+	}
+
+	suffix := make([]byte, 4)
+	if _, err := rand.Read(suffix); err != nil {
+		t.Fatal(err)
+	}
+	tokenLabel := "dvault-" + hex.EncodeToString(suffix)
+
+	if err := ctx.InitToken(freeSlot(t, ctx), testSOPIN, tokenLabel); err != nil {
+		t.Fatalf("InitToken: %v", err)
+	}
+
+	// SoftHSM moves an initialized token to a new slot.
+	slot := tokenSlot(t, ctx, tokenLabel)
+
+	session, err := ctx.OpenSession(slot, pkcs11.CKF_SERIAL_SESSION|pkcs11.CKF_RW_SESSION)
+	if err != nil {
+		t.Fatalf("OpenSession: %v", err)
+	}
+	defer ctx.CloseSession(session)
+
+	if err = ctx.Login(session, pkcs11.CKU_SO, testSOPIN); err != nil {
+		t.Fatalf("Login SO: %v", err)
+	}
+	if err = ctx.InitPIN(session, testUserPIN); err != nil {
+		t.Fatalf("InitPIN: %v", err)
+	}
+	if err = ctx.Logout(session); err != nil {
+		t.Fatalf("Logout SO: %v", err)
+	}
+
+	if err = ctx.Login(session, pkcs11.CKU_USER, testUserPIN); err != nil {
+		t.Fatalf("Login: %v", err)
+	}
+	// Logging out leaves the token without a logged in session, so the seal
+	// has to log in with its own PIN.
+	defer ctx.Logout(session)
+
+	_, err = ctx.GenerateKey(session,
+		[]*pkcs11.Mechanism{pkcs11.NewMechanism(pkcs11.CKM_AES_KEY_GEN, nil)},
+		[]*pkcs11.Attribute{
+			pkcs11.NewAttribute(pkcs11.CKA_CLASS, pkcs11.CKO_SECRET_KEY),
+			pkcs11.NewAttribute(pkcs11.CKA_KEY_TYPE, pkcs11.CKK_AES),
+			pkcs11.NewAttribute(pkcs11.CKA_TOKEN, true),
+			pkcs11.NewAttribute(pkcs11.CKA_VALUE_LEN, 32),
+			pkcs11.NewAttribute(pkcs11.CKA_ENCRYPT, true),
+			pkcs11.NewAttribute(pkcs11.CKA_DECRYPT, true),
+			pkcs11.NewAttribute(pkcs11.CKA_SENSITIVE, true),
+			pkcs11.NewAttribute(pkcs11.CKA_EXTRACTABLE, false),
+			pkcs11.NewAttribute(pkcs11.CKA_LABEL, testAESLabel),
+		})
+	if err != nil {
+		t.Fatalf("GenerateKey: %v", err)
+	}
+
+	_, _, err = ctx.GenerateKeyPair(session,
+		[]*pkcs11.Mechanism{pkcs11.NewMechanism(pkcs11.CKM_RSA_PKCS_KEY_PAIR_GEN, nil)},
+		[]*pkcs11.Attribute{
+			pkcs11.NewAttribute(pkcs11.CKA_CLASS, pkcs11.CKO_PUBLIC_KEY),
+			pkcs11.NewAttribute(pkcs11.CKA_KEY_TYPE, pkcs11.CKK_RSA),
+			pkcs11.NewAttribute(pkcs11.CKA_TOKEN, true),
+			pkcs11.NewAttribute(pkcs11.CKA_ENCRYPT, true),
+			pkcs11.NewAttribute(pkcs11.CKA_MODULUS_BITS, 2048),
+			pkcs11.NewAttribute(pkcs11.CKA_PUBLIC_EXPONENT, []byte{1, 0, 1}),
+			pkcs11.NewAttribute(pkcs11.CKA_LABEL, testRSALabel),
+		},
+		[]*pkcs11.Attribute{
+			pkcs11.NewAttribute(pkcs11.CKA_CLASS, pkcs11.CKO_PRIVATE_KEY),
+			pkcs11.NewAttribute(pkcs11.CKA_KEY_TYPE, pkcs11.CKK_RSA),
+			pkcs11.NewAttribute(pkcs11.CKA_TOKEN, true),
+			pkcs11.NewAttribute(pkcs11.CKA_PRIVATE, true),
+			pkcs11.NewAttribute(pkcs11.CKA_DECRYPT, true),
+			pkcs11.NewAttribute(pkcs11.CKA_SENSITIVE, true),
+			pkcs11.NewAttribute(pkcs11.CKA_LABEL, testRSALabel),
+		})
+	if err != nil {
+		t.Fatalf("GenerateKeyPair: %v", err)
+	}
+
+	return lib, tokenLabel
+}
+
+func freeSlot(t *testing.T, ctx *pkcs11.Ctx) uint {
+	t.Helper()
+
+	slots, err := ctx.GetSlotList(true)
+	if err != nil {
+		t.Fatalf("GetSlotList: %v", err)
+	}
+
+	for _, slot := range slots {
+		info, err := ctx.GetTokenInfo(slot)
+		if err != nil {
+			t.Fatalf("GetTokenInfo: %v", err)
+		}
+		if info.Flags&pkcs11.CKF_TOKEN_INITIALIZED == 0 {
+			return slot
+		}
+	}
+
+	t.Fatal("no free SoftHSM slot")
+	return 0
+}
+
+func tokenSlot(t *testing.T, ctx *pkcs11.Ctx, label string) uint {
+	t.Helper()
+
+	slots, err := ctx.GetSlotList(true)
+	if err != nil {
+		t.Fatalf("GetSlotList: %v", err)
+	}
+
+	for _, slot := range slots {
+		info, err := ctx.GetTokenInfo(slot)
+		if err != nil {
+			t.Fatalf("GetTokenInfo: %v", err)
+		}
+		if strings.TrimRight(info.Label, " \x00") == label {
+			return slot
+		}
+	}
+
+	t.Fatalf("no SoftHSM token labeled %q", label)
+	return 0
+}
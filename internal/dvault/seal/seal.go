@@ -0,0 +1,37 @@
+// Package seal protects the root key with an external key management service,
+// so the vault can unseal itself on startup instead of waiting for key
+// holders to submit their shares.
+package seal
+
+import (
+	"context"
+	"errors"
+	"fmt"
+
+	"github.com/Burzich/dvault/internal/config"
+)
+
+var ErrInvalidConfig = errors.New("invalid seal configuration")
+
+// Seal wraps and unwraps the root key.
+type Seal interface {
+	// Type is the seal type reported by the seal status.
+	Type() string
+	Encrypt(ctx context.Context, plaintext []byte) ([]byte, error)
+	Decrypt(ctx context.Context, ciphertext []byte) ([]byte, error)
+}
+
+// New creates the configured seal. It returns nil for the Shamir seal, which
+// splits the root key into shares instead of wrapping it.
+func New(cfg config.Seal) (Seal, error) {
+	switch cfg.Type {
+	case "", "shamir":
+		return nil, nil
+	case "transit":
+		return NewTransit(cfg.Transit)
+	case "pkcs11":
+		return NewPKCS11(cfg.PKCS11)
+	default:
+		return nil, fmt.Errorf("%w: unknown seal type %q", ErrInvalidConfig, cfg.Type)
+	}
+}
@@ -0,0 +1,159 @@
+package seal
+
+import (
+	"bytes"
+	"context"
+	"crypto/tls"
+	"crypto/x509"
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"os"
+	"strings"
+	"time"
+
+	"github.com/Burzich/dvault/internal/config"
+)
+
+const (
+	transitTimeout     = 30 * time.Second
+	transitMaxResponse = 1 << 20
+	defaultTransitPath = "transit"
+)
+
+// Transit wraps the root key with a key of a Vault transit secrets engine, or
+// anything speaking its encrypt and decrypt API.
+type Transit struct {
+	client   *http.Client
+	endpoint string
+	keyName  string
+	token    string
+}
+
+func NewTransit(cfg config.TransitSeal) (*Transit, error) {
+	if cfg.Address == "" || cfg.KeyName == "" {
+		return nil, fmt.Errorf("%w: transit seal requires address and key_name", ErrInvalidConfig)
+	}
+
+	address, err := url.Parse(cfg.Address)
+	if err != nil || (address.Scheme != "http" && address.Scheme != "https") {
+		return nil, fmt.Errorf("%w: invalid transit address %q", ErrInvalidConfig, cfg.Address)
+	}
+
+	mountPath := strings.Trim(cfg.MountPath, "/")
+	if mountPath == "" {
+		mountPath = defaultTransitPath
+	}
+
+	transport := http.DefaultTransport.(*http.Transport).Clone()
+	if cfg.CACert != "" {
+		caPEM, err := os.ReadFile(cfg.CACert)
+		if err != nil {
+			return nil, fmt.Errorf("%w: %s", ErrInvalidConfig, err)
+		}
+
+		pool := x509.NewCertPool()
+		if !pool.AppendCertsFromPEM(caPEM) {
+			return nil, fmt.Errorf("%w: could not parse transit CA certificate", ErrInvalidConfig)
+		}
+		transport.TLSClientConfig = &tls.Config{RootCAs: pool, MinVersion: tls.VersionTLS12}
+	}
+
+	return &Transit{
+		client:   &http.Client{Transport: transport, Timeout: transitTimeout},
+		endpoint: address.JoinPath("v1", mountPath).String(),
+		keyName:  cfg.KeyName,
+		token:    cfg.Token,
+	}, nil
+}
+
+func (t *Transit) Type() string {
+	return "transit"
+}
+
+func (t *Transit) Encrypt(ctx context.Context, plaintext []byte) ([]byte, error) {
+	var response struct {
+		Ciphertext string `json:"ciphertext"`
+	}
+	err := t.do(ctx, "encrypt", map[string]string{
+		"plaintext": base64.StdEncoding.EncodeToString(plaintext),
+	}, &response)
+	if err != nil {
+		return nil, err
+	}
+
+	if response.Ciphertext == "" {
+		return nil, errors.New("transit encrypt: empty ciphertext")
+	}
+
+	return []byte(response.Ciphertext), nil
+}
+
+func (t *Transit) Decrypt(ctx context.Context, ciphertext []byte) ([]byte, error) {
+	var response struct {
+		Plaintext string `json:"plaintext"`
+	}
+	err := t.do(ctx, "decrypt", map[string]string{
+		"ciphertext": string(ciphertext),
+	}, &response)
+	if err != nil {
+		return nil, err
+	}
+
+	plaintext, err := base64.StdEncoding.DecodeString(response.Plaintext)
+	if err != nil {
+		return nil, fmt.Errorf("transit decrypt: %w", err)
+	}
+
+	return plaintext, nil
+}
+
+// do posts body to the encrypt or decrypt endpoint of the key and decodes the
+// data of the response into v.
+func (t *Transit) do(ctx context.Context, operation string, body any, v any) error {
+	b, err := json.Marshal(body)
+	if err != nil {
+		return err
+	}
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, t.endpoint+"/"+operation+"/"+url.PathEscape(t.keyName), bytes.NewReader(b))
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Content-Type", "application/json")
+	if t.token != "" {
+		req.Header.Set("X-Vault-Token", t.token)
+	}
+
+	resp, err := t.client.Do(req)
+	if err != nil {
+		return fmt.Errorf("transit %s: %w", operation, err)
+	}
+	defer resp.Body.Close()
+
+	data, err := io.ReadAll(io.LimitReader(resp.Body, transitMaxResponse))
+	if err != nil {
+		return fmt.Errorf("transit %s: %w", operation, err)
+	}
+
+	if resp.StatusCode != http.StatusOK {
+		return fmt.Errorf("transit %s: unexpected status %s: %s", operation, resp.Status, bytes.TrimSpace(data))
+	}
+
+	var response struct {
+		Data json.RawMessage `json:"data"`
+	}
+	if err = json.Unmarshal(data, &response); err != nil {
+		return fmt.Errorf("transit %s: %w", operation, err)
+	}
+
+	if err = json.Unmarshal(response.Data, v); err != nil {
+		return fmt.Errorf("transit %s: %w", operation, err)
+	}
+
+	return nil
+}
@@ -0,0 +1,293 @@
+package seal
+
+import (
+	"bytes"
+	"context"
+	"crypto/rand"
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync"
+	"testing"
+
+	"github.com/Burzich/dvault/internal/config"
+)
+
+const (
+	testTransitToken = "s.transit"
+	testTransitKey   = "dvault"
+)
+
+// testTransit stands in for a transit engine mounted at path. Its ciphertexts
+// are random references to the plaintexts it was given.
+type testTransit struct {
+	path   string
+	server *httptest.Server
+
+	mu          sync.Mutex
+	ciphertexts map[string]string
+	tokens      [][]string
+}
+
+func newTestTransit(t *testing.T, path string) *testTransit {
+	t.Helper()
+
+	tr := &testTransit{path: path, ciphertexts: make(map[string]string)}
+	tr.server = httptest.NewServer(http.HandlerFunc(tr.serveHTTP))
+	t.Cleanup(tr.server.Close)
+
+	return tr
+}
+
+func (tr *testTransit) serveHTTP(w http.ResponseWriter, r *http.Request) {
+	tr.mu.Lock()
+	defer tr.mu.Unlock()
+
+	tr.tokens = append(tr.tokens, r.Header.Values("X-Vault-Token"))
+
+	if r.Method != http.MethodPost {
+		writeTransitError(w, http.StatusMethodNotAllowed, "unsupported operation")
+		return
+	}
+	if r.Header.Get("X-Vault-Token") != testTransitToken {
+		writeTransitError(w, http.StatusForbidden, "permission denied")
+		return
+	}
+
+	var body struct {
+		Plaintext  string `json:"plaintext"`
+		Ciphertext string `json:"ciphertext"`
+	}
+	if err := json.NewDecoder(r.Body).Decode(&body); err != nil {
+		writeTransitError(w, http.StatusBadRequest, err.Error())
+		return
+	}
+
+	switch r.URL.Path {
+	case "/v1/" + tr.path + "/encrypt/" + testTransitKey:
+		reference := make([]byte, 16)
+		_, _ = rand.Read(reference)
+		ciphertext := "vault:v1:" + base64.StdEncoding.EncodeToString(reference)
+		tr.ciphertexts[ciphertext] = body.Plaintext
+
+		writeTransitData(w, map[string]string{"ciphertext": ciphertext})
+	case "/v1/" + tr.path + "/decrypt/" + testTransitKey:
+		plaintext, ok := tr.ciphertexts[body.Ciphertext]
+		if !ok {
+			writeTransitError(w, http.StatusBadRequest, "cipher: message authentication failed")
+			return
+		}
+
+		writeTransitData(w, map[string]string{"plaintext": plaintext})
+	default:
+		writeTransitError(w, http.StatusNotFound, "no handler for route "+r.URL.Path)
+	}
+}
+
+// requests returns the X-Vault-Token headers of the requests received so far.
+func (tr *testTransit) requests() [][]string {
+	tr.mu.Lock()
+	defer tr.mu.Unlock()
+
+	return tr.tokens
+}
+
+func writeTransitData(w http.ResponseWriter, data any) {
+	w.Header().Set("Content-Type", "application/json")
+	_ = json.NewEncoder(w).Encode(map[string]any{"data": data})
+}
+
+func writeTransitError(w http.ResponseWriter, status int, message string) {
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(status)
+	_ = json.NewEncoder(w).Encode(map[string]any{"errors": []string{message}})
+}
+
+func TestTransitRoundTrip(t *testing.T) {
+	tr := newTestTransit(t, "transit")
+
+	s, err := NewTransit(config.TransitSeal{
+		Address: tr.server.URL,
+		Token:   testTransitToken,
+		KeyName: testTransitKey,
+	})
+	if err != nil {
+		t.Fatalf("NewTransit: %v", err)
+	}
+
+	plaintext := make([]byte, 32)
+	if _, err = rand.Read(plaintext); err != nil {
+		t.Fatal(err)
+	}
+
+	ciphertext, err := s.Encrypt(context.Background(), plaintext)
+	if err != nil {
+		t.Fatalf("Encrypt: %v", err)
+	}
+	if !strings.HasPrefix(string(ciphertext), "vault:v1:") {
+		t.Fatalf("Encrypt = %q, want a transit ciphertext", ciphertext)
+	}
+
+	decrypted, err := s.Decrypt(context.Background(), ciphertext)
+	if err != nil {
+		t.Fatalf("Decrypt: %v", err)
+	}
+	if !bytes.Equal(decrypted, plaintext) {
+		t.Fatalf("Decrypt = %x, want %x", decrypted, plaintext)
+	}
+
+	if _, err = s.Decrypt(context.Background(), []byte("vault:v1:unknown")); err == nil {
+		t.Fatal("Decrypt of an unknown ciphertext succeeded")
+	}
+}
+
+func TestTransitToken(t *testing.T) {
+	tests := []struct {
+		name    string
+		token   string
+		want    []string
+		wantErr bool
+	}{
+		{name: "token", token: testTransitToken, want: []string{testTransitToken}},
+		{name: "wrong token", token: "s.wrong", want: []string{"s.wrong"}, wantErr: true},
+		{name: "no token", token: "", want: nil, wantErr: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			tr := newTestTransit(t, "transit")
+
+			s, err := NewTransit(config.TransitSeal{
+				Address: tr.server.URL,
+				Token:   tt.token,
+				KeyName: testTransitKey,
+			})
+			if err != nil {
+				t.Fatalf("NewTransit: %v", err)
+			}
+
+			_, err = s.Encrypt(context.Background(), []byte("root key"))
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("Encrypt error = %v, want error %v", err, tt.wantErr)
+			}
+
+			requests := tr.requests()
+			if len(requests) != 1 {
+				t.Fatalf("got %d requests, want 1", len(requests))
+			}
+			if strings.Join(requests[0], ",") != strings.Join(tt.want, ",") {
+				t.Fatalf("X-Vault-Token = %q, want %q", requests[0], tt.want)
+			}
+		})
+	}
+}
+
+func TestTransitMountPath(t *testing.T) {
+	tests := []struct {
+		name      string
+		mountPath string
+		path      string
+	}{
+		{name: "default", mountPath: "", path: "transit"},
+		{name: "custom", mountPath: "seal", path: "seal"},
+		{name: "slashes", mountPath: "/seal/", path: "seal"},
+		{name: "nested", mountPath: "kms/transit", path: "kms/transit"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			tr := newTestTransit(t, tt.path)
+
+			s, err := NewTransit(config.TransitSeal{
+				Address:   tr.server.URL,
+				Token:     testTransitToken,
+				MountPath: tt.mountPath,
+				KeyName:   testTransitKey,
+			})
+			if err != nil {
+				t.Fatalf("NewTransit: %v", err)
+			}
+
+			ciphertext, err := s.Encrypt(context.Background(), []byte("root key"))
+			if err != nil {
+				t.Fatalf("Encrypt: %v", err)
+			}
+			if _, err = s.Decrypt(context.Background(), ciphertext); err != nil {
+				t.Fatalf("Decrypt: %v", err)
+			}
+		})
+	}
+}
+
+func TestTransitResponseErrors(t *testing.T) {
+	tests := []struct {
+		name      string
+		operation string
+		status    int
+		body      string
+	}{
+		{name: "server error", operation: "encrypt", status: http.StatusInternalServerError, body: `{"errors":["internal error"]}`},
+		{name: "sealed", operation: "decrypt", status: http.StatusServiceUnavailable, body: `{"errors":["Vault is sealed"]}`},
+		{name: "invalid json", operation: "encrypt", status: http.StatusOK, body: `{"data":`},
+		{name: "data not an object", operation: "encrypt", status: http.StatusOK, body: `{"data":"vault:v1:abc"}`},
+		{name: "no data", operation: "encrypt", status: http.StatusOK, body: `{}`},
+		{name: "empty ciphertext", operation: "encrypt", status: http.StatusOK, body: `{"data":{"ciphertext":""}}`},
+		{name: "plaintext not a string", operation: "decrypt", status: http.StatusOK, body: `{"data":{"plaintext":1}}`},
+		{name: "plaintext not base64", operation: "decrypt", status: http.StatusOK, body: `{"data":{"plaintext":"not base64!"}}`},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				w.Header().Set("Content-Type", "application/json")
+				w.WriteHeader(tt.status)
+				_, _ = w.Write([]byte(tt.body))
+			}))
+			t.Cleanup(server.Close)
+
+			s, err := NewTransit(config.TransitSeal{
+				Address: server.URL,
+				Token:   testTransitToken,
+				KeyName: testTransitKey,
+			})
+			if err != nil {
+				t.Fatalf("NewTransit: %v", err)
+			}
+
+			if tt.operation == "encrypt" {
+				_, err = s.Encrypt(context.Background(), []byte("root key"))
+			} else {
+				_, err = s.Decrypt(context.Background(), []byte("vault:v1:abc"))
+			}
+			if err == nil {
+				t.Fatalf("%s succeeded", tt.operation)
+			}
+			if !strings.HasPrefix(err.Error(), "transit "+tt.operation) {
+				t.Fatalf("%s error = %v, want a transit %[1]s error", tt.operation, err)
+			}
+		})
+	}
+}
+
+func TestNewTransitInvalidConfig(t *testing.T) {
+	tests := []struct {
+		name string
+		cfg  config.TransitSeal
+	}{
+		{name: "no address", cfg: config.TransitSeal{KeyName: testTransitKey}},
+		{name: "no key", cfg: config.TransitSeal{Address: "http://127.0.0.1:8200"}},
+		{name: "scheme", cfg: config.TransitSeal{Address: "127.0.0.1:8200", KeyName: testTransitKey}},
+		{name: "ca cert", cfg: config.TransitSeal{Address: "https://127.0.0.1:8200", KeyName: testTransitKey, CACert: "missing.pem"}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if _, err := NewTransit(tt.cfg); !errors.Is(err, ErrInvalidConfig) {
+				t.Fatalf("NewTransit error = %v, want %v", err, ErrInvalidConfig)
+			}
+		})
+	}
+}
@@ -0,0 +1,164 @@
+package dvault
+
+import (
+	"crypto/rand"
+	"encoding/base64"
+	"fmt"
+	"strings"
+
+	"github.com/Burzich/dvault/internal/dvault/recipient"
+	"github.com/cloudflare/circl/group"
+	"github.com/cloudflare/circl/secretsharing"
+)
+
+// shareSet collects the key shares submitted for an unseal or a root
+// generation. Shares are validated when they are added and counted once per
+// share ID, so a holder submitting their share twice does not use up a slot
+// of the threshold.
+type shareSet struct {
+	keys map[string]string
+}
+
+// add adds the share key, which is the base64 encoded value and ID of the
+// share separated by "#". A share that was already added is ignored.
+func (s *shareSet) add(key string) error {
+	_, id, err := parseShare(key)
+	if err != nil {
+		return err
+	}
+
+	if existing, ok := s.keys[id]; ok {
+		if existing != key {
+			return fmt.Errorf("%w: another share with the same ID was already submitted", ErrInvalidShare)
+		}
+		return nil
+	}
+
+	if s.keys == nil {
+		s.keys = make(map[string]string)
+	}
+	s.keys[id] = key
+
+	return nil
+}
+
+func (s *shareSet) len() int {
+	return len(s.keys)
+}
+
+func (s *shareSet) list() []string {
+	keys := make([]string, 0, len(s.keys))
+	for _, key := range s.keys {
+		keys = append(keys, key)
+	}
+
+	return keys
+}
+
+// splitSecret splits the root key, or with an auto seal the recovery key,
+// into n shares, t of which recover it.
+func splitSecret(secret group.Scalar, n uint, t uint) ([]string, error) {
+	g := group.P256
+	ss := secretsharing.New(rand.Reader, t-1, secret)
+
+	keys := make([]string, 0, n)
+	for range n {
+		share := ss.ShareWithID(g.RandomScalar(rand.Reader))
+
+		shareValueBytes, err := share.Value.MarshalBinary()
+		if err != nil {
+			return nil, err
+		}
+		shareIdBytes, err := share.ID.MarshalBinary()
+		if err != nil {
+			return nil, err
+		}
+
+		shareValueBase64 := base64.StdEncoding.EncodeToString(shareValueBytes)
+		shareIdBase64 := base64.StdEncoding.EncodeToString(shareIdBytes)
+		keys = append(keys, shareValueBase64+"#"+shareIdBase64)
+	}
+
+	return keys, nil
+}
+
+// combineShares recovers the secret from the shares of a split with
+// threshold t.
+func combineShares(keys []string, t int) ([]byte, error) {
+	shares := make([]secretsharing.Share, 0, len(keys))
+	for _, key := range keys {
+		share, _, err := parseShare(key)
+		if err != nil {
+			return nil, err
+		}
+		shares = append(shares, share)
+	}
+
+	secret, err := secretsharing.Recover(uint(t)-1, shares)
+	if err != nil {
+		return nil, err
+	}
+
+	return secret.MarshalBinary()
+}
+
+// encryptShares encrypts each key share to the recipient at the same position.
+func encryptShares(recipients []recipient.Recipient, keys []string) ([]string, error) {
+	encrypted := make([]string, len(keys))
+	for i, key := range keys {
+		var err error
+		if encrypted[i], err = recipients[i].Encrypt([]byte(key)); err != nil {
+			return nil, err
+		}
+	}
+
+	return encrypted, nil
+}
+
+// parseShare decodes a share key. The ID is returned in its canonical
+// encoding, which identifies the share regardless of how it was submitted.
+func parseShare(key string) (secretsharing.Share, string, error) {
+	valueBase64, idBase64, ok := strings.Cut(strings.TrimSpace(key), "#")
+	if !ok {
+		return secretsharing.Share{}, "", fmt.Errorf("%w: expected value and ID separated by #", ErrInvalidShare)
+	}
+
+	value, err := parseScalar(valueBase64)
+	if err != nil {
+		return secretsharing.Share{}, "", fmt.Errorf("%w: value: %s", ErrInvalidShare, err)
+	}
+
+	id, err := parseScalar(idBase64)
+	if err != nil {
+		return secretsharing.Share{}, "", fmt.Errorf("%w: ID: %s", ErrInvalidShare, err)
+	}
+	// The polynomial evaluated at zero is the secret itself.
+	if id.IsZero() {
+		return secretsharing.Share{}, "", fmt.Errorf("%w: ID must not be zero", ErrInvalidShare)
+	}
+
+	idBytes, err := id.MarshalBinary()
+	if err != nil {
+		return secretsharing.Share{}, "", err
+	}
+
+	return secretsharing.Share{ID: id, Value: value}, base64.StdEncoding.EncodeToString(idBytes), nil
+}
+
+func parseScalar(s string) (group.Scalar, error) {
+	b, err := base64.StdEncoding.DecodeString(s)
+	if err != nil {
+		return nil, err
+	}
+
+	if length := group.P256.Params().ScalarLength; uint(len(b)) != length {
+		return nil, fmt.Errorf("expected %d bytes, got %d", length, len(b))
+	}
+
+	scalar := group.P256.NewScalar()
+	if err = scalar.UnmarshalBinary(b); err != nil {
+		return nil, err
+	}
+
+	return scalar, nil
+}
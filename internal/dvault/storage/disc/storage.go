@@ -65,3 +65,26 @@ func (f Storage) Delete(_ context.Context, path string) error {
 
 	return nil
 }
+
+func (f Storage) List(_ context.Context, path string) ([]string, error) {
+	p := filepath.Join(f.mountPoint, path)
+
+	dirEntries, err := os.ReadDir(p)
+	if errors.Is(err, os.ErrNotExist) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	keys := make([]string, 0, len(dirEntries))
+	for _, dirEntry := range dirEntries {
+		if dirEntry.IsDir() {
+			keys = append(keys, dirEntry.Name()+"/")
+			continue
+		}
+		keys = append(keys, dirEntry.Name())
+	}
+
+	return keys, nil
+}
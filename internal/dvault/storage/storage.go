@@ -1,9 +1,61 @@
 package storage
 
-import "context"
+import (
+	"context"
+	"errors"
+	"path/filepath"
+	"strings"
+)
 
 type Storage interface {
 	Put(ctx context.Context, path string, data []byte) error
 	Get(ctx context.Context, path string) ([]byte, error)
 	Delete(ctx context.Context, path string) error
+	List(ctx context.Context, path string) ([]string, error)
+}
+
+// Rewrite replaces the entry at path with what rewrite returns for it. A nil
+// result leaves the entry as it is. It reports whether the entry was written.
+func Rewrite(ctx context.Context, s Storage, path string, rewrite func(data []byte) ([]byte, error)) (bool, error) {
+	data, err := s.Get(ctx, path)
+	if errors.Is(err, ErrPathNotFound) {
+		return false, nil
+	}
+	if err != nil {
+		return false, err
+	}
+
+	data, err = rewrite(data)
+	if err != nil || data == nil {
+		return false, err
+	}
+
+	return true, s.Put(ctx, path, data)
+}
+
+// DeleteTree deletes path and everything below it. Storages only delete empty
+// directories, so the entries are removed depth first.
+func DeleteTree(ctx context.Context, s Storage, path string) error {
+	keys, err := s.List(ctx, path)
+	if err != nil {
+		return err
+	}
+
+	for _, key := range keys {
+		if dir, ok := strings.CutSuffix(key, "/"); ok {
+			err = DeleteTree(ctx, s, filepath.Join(path, dir))
+		} else {
+			err = s.Delete(ctx, filepath.Join(path, key))
+		}
+		if err != nil && !errors.Is(err, ErrPathNotFound) {
+			return err
+		}
+	}
+
+	err = s.Delete(ctx, path)
+	if err != nil && !errors.Is(err, ErrPathNotFound) {
+		return err
+	}
+
+	return nil
 }
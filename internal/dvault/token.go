@@ -0,0 +1,453 @@
+package dvault
+
+import (
+	"context"
+	"fmt"
+	"slices"
+	"time"
+
+	"github.com/Burzich/dvault/internal/dvault/policy"
+	"github.com/Burzich/dvault/internal/dvault/token"
+	"github.com/Burzich/dvault/internal/tools"
+)
+
+func (d *DVault) CreateToken(ctx context.Context, create CreateToken) (Response, error) {
+	d.mu.RLock()
+	defer d.mu.RUnlock()
+
+	if d.isSealed {
+		return Response{}, ErrSealed
+	}
+
+	parent, ok := tokenFromContext(ctx)
+	if !ok {
+		return Response{}, ErrPermissionDenied
+	}
+
+	// no_parent is only honoured for root or sudo callers, like in Vault.
+	orphan := false
+	if create.NoParent {
+		acl, err := d.policies.ACL(ctx, parent.Policies)
+		if err != nil {
+			return Response{}, err
+		}
+
+		if acl.Capabilities("auth/token/create")&policy.Sudo == 0 {
+			return Response{}, fmt.Errorf("%w: root or sudo privileges required to create orphan token", ErrPermissionDenied)
+		}
+		orphan = true
+	}
+
+	return d.createToken(ctx, parent, create, orphan)
+}
+
+// CreateOrphanToken creates a token without a parent, so it outlives the
+// token that created it. Access is governed by the ACL on create-orphan alone.
+func (d *DVault) CreateOrphanToken(ctx context.Context, create CreateToken) (Response, error) {
+	d.mu.RLock()
+	defer d.mu.RUnlock()
+
+	if d.isSealed {
+		return Response{}, ErrSealed
+	}
+
+	parent, ok := tokenFromContext(ctx)
+	if !ok {
+		return Response{}, ErrPermissionDenied
+	}
+
+	return d.createToken(ctx, parent, create, true)
+}
+
+func (d *DVault) createToken(ctx context.Context, parent token.Entry, create CreateToken, orphan bool) (Response, error) {
+	if parent.Type == token.TypeBatch {
+		return Response{}, fmt.Errorf("%w: batch tokens can not create child tokens", ErrPermissionDenied)
+	}
+
+	policies, err := childPolicies(parent, create.Policies, create.NoDefaultPolicy)
+	if err != nil {
+		return Response{}, err
+	}
+
+	renewable := true
+	if create.Renewable != nil {
+		renewable = *create.Renewable
+	}
+
+	ttl := create.TTL
+	if ttl == 0 {
+		ttl = token.DefaultTTL
+	}
+
+	displayName := "token"
+	if create.DisplayName != "" {
+		displayName = "token-" + create.DisplayName
+	}
+
+	path := "auth/token/create"
+	parentID := parent.ID
+	if orphan {
+		path = "auth/token/create-orphan"
+		parentID = ""
+	}
+
+	entry := token.Entry{
+		Policies:       policies,
+		Meta:           create.Meta,
+		DisplayName:    displayName,
+		NumUses:        create.NumUses,
+		Path:           path,
+		Parent:         parentID,
+		EntityID:       parent.EntityID,
+		Renewable:      renewable,
+		TTL:            ttl,
+		ExplicitMaxTTL: create.ExplicitMaxTTL,
+	}
+
+	switch create.Type {
+	case "", token.TypeService:
+		entry, err = d.tokens.Create(ctx, entry)
+	case token.TypeBatch:
+		entry, err = d.tokens.CreateBatch(entry)
+	default:
+		err = fmt.Errorf("%w %q", token.ErrInvalidType, create.Type)
+	}
+	if err != nil {
+		return Response{}, err
+	}
+
+	var response Response
+	response.Auth = tokenAuth(entry)
+	response.MountType = "token"
+	response.RequestId = tools.GenerateXRequestID()
+
+	return response, nil
+}
+
+func (d *DVault) LookupToken(ctx context.Context, id string) (Response, error) {
+	d.mu.RLock()
+	defer d.mu.RUnlock()
+
+	if d.isSealed {
+		return Response{}, ErrSealed
+	}
+
+	entry, err := d.tokens.Lookup(ctx, id)
+	if err != nil {
+		return Response{}, err
+	}
+
+	var response Response
+	response.Data = tokenLookup(entry)
+	response.MountType = "token"
+	response.RequestId = tools.GenerateXRequestID()
+
+	return response, nil
+}
+
+// LookupSelfToken describes the calling token as it was resolved for this
+// request, so it also works when the request used the last use of the token.
+func (d *DVault) LookupSelfToken(ctx context.Context) (Response, error) {
+	entry, ok := tokenFromContext(ctx)
+	if !ok {
+		return Response{}, ErrPermissionDenied
+	}
+
+	d.mu.RLock()
+	defer d.mu.RUnlock()
+
+	if d.isSealed {
+		return Response{}, ErrSealed
+	}
+
+	var response Response
+	response.Data = tokenLookup(entry)
+	response.MountType = "token"
+	response.RequestId = tools.GenerateXRequestID()
+
+	return response, nil
+}
+
+func (d *DVault) RenewToken(ctx context.Context, id string, increment time.Duration) (Response, error) {
+	d.mu.RLock()
+	defer d.mu.RUnlock()
+
+	if d.isSealed {
+		return Response{}, ErrSealed
+	}
+
+	entry, err := d.tokens.Renew(ctx, id, increment)
+	if err != nil {
+		return Response{}, err
+	}
+
+	var response Response
+	response.Auth = tokenAuth(entry)
+	response.MountType = "token"
+	response.RequestId = tools.GenerateXRequestID()
+
+	return response, nil
+}
+
+func (d *DVault) RenewSelfToken(ctx context.Context, increment time.Duration) (Response, error) {
+	entry, ok := tokenFromContext(ctx)
+	if !ok {
+		return Response{}, ErrPermissionDenied
+	}
+
+	return d.RenewToken(ctx, entry.ID, increment)
+}
+
+func (d *DVault) RevokeToken(ctx context.Context, id string) (Response, error) {
+	d.mu.RLock()
+	defer d.mu.RUnlock()
+
+	if d.isSealed {
+		return Response{}, ErrSealed
+	}
+
+	err := d.tokens.Revoke(ctx, id)
+	if err != nil {
+		return Response{}, err
+	}
+
+	var response Response
+	response.MountType = "token"
+	response.RequestId = tools.GenerateXRequestID()
+
+	return response, nil
+}
+
+// RevokeOrphanToken revokes a token but leaves its children in place, they
+// become orphans.
+func (d *DVault) RevokeOrphanToken(ctx context.Context, id string) (Response, error) {
+	d.mu.RLock()
+	defer d.mu.RUnlock()
+
+	if d.isSealed {
+		return Response{}, ErrSealed
+	}
+
+	err := d.tokens.RevokeOrphan(ctx, id)
+	if err != nil {
+		return Response{}, err
+	}
+
+	var response Response
+	response.MountType = "token"
+	response.RequestId = tools.GenerateXRequestID()
+
+	return response, nil
+}
+
+func (d *DVault) RevokeSelfToken(ctx context.Context) (Response, error) {
+	entry, ok := tokenFromContext(ctx)
+	if !ok {
+		return Response{}, ErrPermissionDenied
+	}
+
+	return d.RevokeToken(ctx, entry.ID)
+}
+
+func (d *DVault) ListTokenAccessors(ctx context.Context) (Response, error) {
+	d.mu.RLock()
+	defer d.mu.RUnlock()
+
+	if d.isSealed {
+		return Response{}, ErrSealed
+	}
+
+	accessors, err := d.tokens.ListAccessors(ctx)
+	if err != nil {
+		return Response{}, err
+	}
+
+	var response Response
+	response.Data = KeyList{Keys: accessors}
+	response.MountType = "token"
+	response.RequestId = tools.GenerateXRequestID()
+
+	return response, nil
+}
+
+// LookupAccessorToken, RenewAccessorToken and RevokeAccessorToken let
+// operators manage a token without knowing its value, so the token ID is
+// never part of their responses.
+func (d *DVault) LookupAccessorToken(ctx context.Context, accessor string) (Response, error) {
+	d.mu.RLock()
+	defer d.mu.RUnlock()
+
+	if d.isSealed {
+		return Response{}, ErrSealed
+	}
+
+	entry, err := d.tokens.LookupAccessor(ctx, accessor)
+	if err != nil {
+		return Response{}, err
+	}
+
+	lookup := tokenLookup(entry)
+	lookup.Id = ""
+
+	var response Response
+	response.Data = lookup
+	response.MountType = "token"
+	response.RequestId = tools.GenerateXRequestID()
+
+	return response, nil
+}
+
+func (d *DVault) RenewAccessorToken(ctx context.Context, accessor string, increment time.Duration) (Response, error) {
+	d.mu.RLock()
+	defer d.mu.RUnlock()
+
+	if d.isSealed {
+		return Response{}, ErrSealed
+	}
+
+	entry, err := d.tokens.LookupAccessor(ctx, accessor)
+	if err != nil {
+		return Response{}, err
+	}
+
+	entry, err = d.tokens.Renew(ctx, entry.ID, increment)
+	if err != nil {
+		return Response{}, err
+	}
+
+	auth := tokenAuth(entry)
+	auth.ClientToken = ""
+
+	var response Response
+	response.Auth = auth
+	response.MountType = "token"
+	response.RequestId = tools.GenerateXRequestID()
+
+	return response, nil
+}
+
+func (d *DVault) RevokeAccessorToken(ctx context.Context, accessor string) (Response, error) {
+	d.mu.RLock()
+	defer d.mu.RUnlock()
+
+	if d.isSealed {
+		return Response{}, ErrSealed
+	}
+
+	entry, err := d.tokens.LookupAccessor(ctx, accessor)
+	if err != nil {
+		return Response{}, err
+	}
+
+	err = d.tokens.Revoke(ctx, entry.ID)
+	if err != nil {
+		return Response{}, err
+	}
+
+	var response Response
+	response.MountType = "token"
+	response.RequestId = tools.GenerateXRequestID()
+
+	return response, nil
+}
+
+func tokenAuth(entry token.Entry) TokenAuth {
+	return TokenAuth{
+		ClientToken:   entry.ID,
+		Accessor:      entry.Accessor,
+		Policies:      entry.Policies,
+		TokenPolicies: entry.Policies,
+		Metadata:      entry.Meta,
+		LeaseDuration: int(entry.RemainingTTL(time.Now()).Seconds()),
+		Renewable:     entry.Renewable,
+		EntityId:      entry.EntityID,
+		TokenType:     tokenType(entry),
+		Orphan:        entry.Parent == "",
+		NumUses:       entry.NumUses,
+	}
+}
+
+func tokenLookup(entry token.Entry) TokenLookup {
+	var expireTime *time.Time
+	if !entry.ExpireTime.IsZero() {
+		expireTime = &entry.ExpireTime
+	}
+
+	return TokenLookup{
+		Accessor:       entry.Accessor,
+		CreationTime:   entry.CreationTime.Unix(),
+		CreationTtl:    int(entry.TTL.Seconds()),
+		DisplayName:    entry.DisplayName,
+		EntityId:       entry.EntityID,
+		ExpireTime:     expireTime,
+		ExplicitMaxTtl: int(entry.ExplicitMaxTTL.Seconds()),
+		Id:             entry.ID,
+		IssueTime:      entry.IssueTime,
+		Meta:           entry.Meta,
+		NumUses:        max(entry.NumUses, 0),
+		Orphan:         entry.Parent == "",
+		Path:           entry.Path,
+		Policies:       entry.Policies,
+		Renewable:      entry.Renewable,
+		Ttl:            int(entry.RemainingTTL(time.Now()).Seconds()),
+		Type:           tokenType(entry),
+		Role:           entry.Role,
+		Period:         int(entry.Period.Seconds()),
+		BoundCidrs:     entry.BoundCIDRs,
+	}
+}
+
+// tokenType defaults to service for entries stored before token types
+// existed.
+func tokenType(entry token.Entry) string {
+	if entry.Type == "" {
+		return token.TypeService
+	}
+
+	return entry.Type
+}
+
+// childPolicies computes the policies of a token created by parent. Without
+// explicit policies the child inherits those of its parent, and only a root
+// token may hand out policies it does not hold itself.
+func childPolicies(parent token.Entry, requested []string, noDefaultPolicy bool) ([]string, error) {
+	policies := slices.Clone(requested)
+	if len(policies) == 0 {
+		policies = slices.Clone(parent.Policies)
+	}
+
+	if !slices.Contains(parent.Policies, policy.Root) {
+		for _, p := range policies {
+			if p != policy.Default && !slices.Contains(parent.Policies, p) {
+				return nil, fmt.Errorf("%w: child policies must be subset of parent", ErrPermissionDenied)
+			}
+		}
+	}
+
+	return sanitizePolicies(policies, !noDefaultPolicy), nil
+}
+
+func sanitizePolicies(policies []string, addDefault bool) []string {
+	if slices.Contains(policies, policy.Root) {
+		return []string{policy.Root}
+	}
+
+	if !addDefault {
+		policies = slices.DeleteFunc(policies, func(p string) bool {
+			return p == policy.Default
+		})
+	} else if !slices.Contains(policies, policy.Default) {
+		policies = append(policies, policy.Default)
+	}
+	slices.Sort(policies)
+
+	return slices.Compact(policies)
+}
+
+func rootTokenEntry() token.Entry {
+	return token.Entry{
+		Policies:    []string{policy.Root},
+		DisplayName: "root",
+		Path:        "auth/token/root",
+	}
+}
@@ -0,0 +1,75 @@
+package token
+
+import (
+	"encoding/base64"
+	"encoding/json"
+	"strings"
+	"time"
+)
+
+// IsBatch reports whether id is a batch token.
+func IsBatch(id string) bool {
+	return strings.HasPrefix(id, BatchPrefix)
+}
+
+// CreateBatch issues a batch token. The entry itself is sealed with the
+// barrier encryptor and becomes the token, so nothing is written to storage.
+// Batch tokens are orphans that can be neither renewed nor revoked and simply
+// stop working once they expire.
+func (s *Store) CreateBatch(entry Entry) (Entry, error) {
+	if entry.NumUses != 0 || entry.Period != 0 {
+		return Entry{}, ErrInvalidBatch
+	}
+
+	if entry.TTL <= 0 {
+		entry.TTL = DefaultTTL
+	}
+
+	now := time.Now()
+	entry.ID = ""
+	entry.Type = TypeBatch
+	entry.Accessor = ""
+	entry.Parent = ""
+	entry.Renewable = false
+	entry.CreationTime = now
+	entry.IssueTime = now
+	entry.ExpireTime = expireTime(entry, now, entry.TTL)
+
+	d, err := json.Marshal(entry)
+	if err != nil {
+		return Entry{}, err
+	}
+
+	encryptedData, err := s.encryptor.Encrypt(d)
+	if err != nil {
+		return Entry{}, err
+	}
+
+	entry.ID = BatchPrefix + base64.RawURLEncoding.EncodeToString(encryptedData)
+
+	return entry, nil
+}
+
+func (s *Store) openBatch(id string) (Entry, error) {
+	encryptedData, err := base64.RawURLEncoding.DecodeString(strings.TrimPrefix(id, BatchPrefix))
+	if err != nil {
+		return Entry{}, ErrTokenNotFound
+	}
+
+	d, err := s.encryptor.Decrypt(encryptedData)
+	if err != nil {
+		return Entry{}, ErrTokenNotFound
+	}
+
+	var entry Entry
+	if err = json.Unmarshal(d, &entry); err != nil {
+		return Entry{}, ErrTokenNotFound
+	}
+
+	if entry.Type != TypeBatch || entry.Expired(time.Now()) {
+		return Entry{}, ErrTokenNotFound
+	}
+	entry.ID = id
+
+	return entry, nil
+}
@@ -0,0 +1,12 @@
+package token
+
+import "errors"
+
+var ErrTokenNotFound = errors.New("bad token")
+var ErrNotRenewable = errors.New("token is not renewable")
+var ErrRoleNotFound = errors.New("role not found")
+var ErrInvalidRole = errors.New("invalid role")
+var ErrBatchToken = errors.New("operation not supported on batch tokens")
+var ErrInvalidBatch = errors.New("batch tokens can not be periodic or use-limited")
+var ErrInvalidType = errors.New("invalid token type")
+var ErrInvalidNumUses = errors.New("number of uses cannot be negative")
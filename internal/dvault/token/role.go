@@ -0,0 +1,129 @@
+package token
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net"
+	"path/filepath"
+	"regexp"
+	"strings"
+	"time"
+
+	"github.com/Burzich/dvault/internal/dvault/storage"
+)
+
+var validRoleName = regexp.MustCompile(`^[a-zA-Z0-9_-][a-zA-Z0-9_.-]*$`)
+
+type Role struct {
+	Name                string        `json:"name"`
+	AllowedPolicies     []string      `json:"allowed_policies"`
+	DisallowedPolicies  []string      `json:"disallowed_policies"`
+	Orphan              bool          `json:"orphan"`
+	Renewable           bool          `json:"renewable"`
+	TokenPeriod         time.Duration `json:"token_period"`
+	TokenExplicitMaxTTL time.Duration `json:"token_explicit_max_ttl"`
+	TokenBoundCIDRs     []string      `json:"token_bound_cidrs"`
+	PathSuffix          string        `json:"path_suffix"`
+}
+
+func (s *Store) GetRole(ctx context.Context, name string) (Role, error) {
+	if !validRoleName.MatchString(name) {
+		return Role{}, ErrRoleNotFound
+	}
+
+	b, err := s.storage.Get(ctx, s.rolePath(name))
+	if errors.Is(err, storage.ErrPathNotFound) {
+		return Role{}, ErrRoleNotFound
+	}
+	if err != nil {
+		return Role{}, err
+	}
+
+	decryptedData, err := s.encryptor.Decrypt(b)
+	if err != nil {
+		return Role{}, err
+	}
+
+	var role Role
+	err = json.Unmarshal(decryptedData, &role)
+	if err != nil {
+		return Role{}, err
+	}
+
+	return role, nil
+}
+
+func (s *Store) PutRole(ctx context.Context, role Role) error {
+	if !validRoleName.MatchString(role.Name) {
+		return fmt.Errorf("%w: invalid role name %q", ErrInvalidRole, role.Name)
+	}
+
+	if strings.Contains(role.PathSuffix, "..") {
+		return fmt.Errorf("%w: path_suffix can not contain '..'", ErrInvalidRole)
+	}
+
+	for _, cidr := range role.TokenBoundCIDRs {
+		if _, err := ParseCIDR(cidr); err != nil {
+			return fmt.Errorf("%w: %s", ErrInvalidRole, err)
+		}
+	}
+
+	d, err := json.Marshal(role)
+	if err != nil {
+		return err
+	}
+
+	encryptedData, err := s.encryptor.Encrypt(d)
+	if err != nil {
+		return err
+	}
+
+	return s.storage.Put(ctx, s.rolePath(role.Name), encryptedData)
+}
+
+func (s *Store) DeleteRole(ctx context.Context, name string) error {
+	if !validRoleName.MatchString(name) {
+		return ErrRoleNotFound
+	}
+
+	err := s.storage.Delete(ctx, s.rolePath(name))
+	if errors.Is(err, storage.ErrPathNotFound) {
+		return ErrRoleNotFound
+	}
+
+	return err
+}
+
+func (s *Store) ListRoles(ctx context.Context) ([]string, error) {
+	return s.storage.List(ctx, filepath.Join(s.path, "role"))
+}
+
+func (s *Store) rolePath(name string) string {
+	return filepath.Join(s.path, "role", name)
+}
+
+// ParseCIDR accepts a CIDR block or a single IP address.
+func ParseCIDR(cidr string) (*net.IPNet, error) {
+	if !strings.Contains(cidr, "/") {
+		ip := net.ParseIP(cidr)
+		if ip == nil {
+			return nil, fmt.Errorf("invalid CIDR %q", cidr)
+		}
+
+		bits := 32
+		if ip.To4() == nil {
+			bits = 128
+		}
+
+		return &net.IPNet{IP: ip, Mask: net.CIDRMask(bits, bits)}, nil
+	}
+
+	_, ipNet, err := net.ParseCIDR(cidr)
+	if err != nil {
+		return nil, err
+	}
+
+	return ipNet, nil
+}
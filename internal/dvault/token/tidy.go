@@ -0,0 +1,195 @@
+package token
+
+import (
+	"context"
+	"errors"
+	"path/filepath"
+	"strings"
+	"time"
+
+	"github.com/Burzich/dvault/internal/dvault/storage"
+)
+
+// TidyResult counts what a tidy or expiration pass cleaned up.
+type TidyResult struct {
+	Expired           int
+	DanglingAccessors int
+	InvalidParents    int
+}
+
+// RevokeExpired revokes every token whose TTL elapsed or whose uses ran out,
+// together with its children.
+func (s *Store) RevokeExpired(ctx context.Context) (int, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	return s.revokeExpired(ctx)
+}
+
+// Tidy revokes expired tokens and removes index entries left behind by
+// tokens that no longer exist. Tokens whose parent is gone are revoked.
+func (s *Store) Tidy(ctx context.Context) (TidyResult, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	var result TidyResult
+	var err error
+
+	result.Expired, err = s.revokeExpired(ctx)
+	if err != nil {
+		return result, err
+	}
+
+	result.DanglingAccessors, err = s.tidyAccessors(ctx)
+	if err != nil {
+		return result, err
+	}
+
+	result.InvalidParents, err = s.tidyParents(ctx)
+	if err != nil {
+		return result, err
+	}
+
+	return result, nil
+}
+
+// Rewrite rewrites the entry at path, like storage.Rewrite, while no
+// revocation runs. Revocations also destroy cubbyholes, so this guards those
+// entries as well.
+func (s *Store) Rewrite(ctx context.Context, path string, rewrite func(data []byte) ([]byte, error)) (bool, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	return storage.Rewrite(ctx, s.storage, path, rewrite)
+}
+
+func (s *Store) revokeExpired(ctx context.Context) (int, error) {
+	hashes, err := s.storage.List(ctx, filepath.Join(s.path, "id"))
+	if err != nil {
+		return 0, err
+	}
+
+	now := time.Now()
+	revoked := 0
+	for _, hash := range hashes {
+		if err = ctx.Err(); err != nil {
+			return revoked, err
+		}
+
+		entry, err := s.readEntry(ctx, hash)
+		if errors.Is(err, ErrTokenNotFound) {
+			continue
+		}
+		if err != nil {
+			return revoked, err
+		}
+
+		if !entry.Expired(now) && !entry.UsesExhausted() {
+			continue
+		}
+
+		if err = s.revokeTree(ctx, hash); err != nil {
+			return revoked, err
+		}
+		revoked++
+	}
+
+	return revoked, nil
+}
+
+func (s *Store) tidyAccessors(ctx context.Context) (int, error) {
+	accessors, err := s.storage.List(ctx, filepath.Join(s.path, "accessor"))
+	if err != nil {
+		return 0, err
+	}
+
+	removed := 0
+	for _, accessor := range accessors {
+		id, err := s.readAccessor(ctx, accessor)
+		if errors.Is(err, ErrTokenNotFound) {
+			continue
+		}
+		if err != nil {
+			return removed, err
+		}
+
+		_, err = s.readEntry(ctx, hashID(id))
+		if errors.Is(err, ErrTokenNotFound) {
+			if err = s.deleteIfExists(ctx, s.accessorPath(accessor)); err != nil {
+				return removed, err
+			}
+			removed++
+			continue
+		}
+		if err != nil {
+			return removed, err
+		}
+	}
+
+	return removed, nil
+}
+
+func (s *Store) tidyParents(ctx context.Context) (int, error) {
+	parents, err := s.storage.List(ctx, filepath.Join(s.path, "parent"))
+	if err != nil {
+		return 0, err
+	}
+
+	revoked := 0
+	for _, parentHash := range parents {
+		parentHash = strings.TrimSuffix(parentHash, "/")
+
+		children, err := s.storage.List(ctx, s.childrenPath(parentHash))
+		if err != nil {
+			return revoked, err
+		}
+
+		_, err = s.readEntry(ctx, parentHash)
+		if err == nil {
+			if err = s.tidyChildren(ctx, parentHash, children); err != nil {
+				return revoked, err
+			}
+			continue
+		}
+		if !errors.Is(err, ErrTokenNotFound) {
+			return revoked, err
+		}
+
+		for _, childHash := range children {
+			if _, err = s.readEntry(ctx, childHash); err == nil {
+				revoked++
+			}
+			if err = s.revokeTree(ctx, childHash); err != nil {
+				return revoked, err
+			}
+			if err = s.deleteIfExists(ctx, s.childPath(parentHash, childHash)); err != nil {
+				return revoked, err
+			}
+		}
+
+		if err = s.deleteIfExists(ctx, s.childrenPath(parentHash)); err != nil {
+			return revoked, err
+		}
+	}
+
+	return revoked, nil
+}
+
+// tidyChildren drops index entries of children that no longer exist.
+func (s *Store) tidyChildren(ctx context.Context, parentHash string, children []string) error {
+	for _, childHash := range children {
+		_, err := s.readEntry(ctx, childHash)
+		if err == nil {
+			continue
+		}
+		if !errors.Is(err, ErrTokenNotFound) {
+			return err
+		}
+
+		if err = s.deleteIfExists(ctx, s.childPath(parentHash, childHash)); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
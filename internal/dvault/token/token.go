@@ -1 +1,553 @@
 package token
+
+import (
+	"context"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"math/big"
+	"path/filepath"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/Burzich/dvault/internal/dvault/storage"
+	"github.com/Burzich/dvault/internal/tools"
+)
+
+const (
+	ServicePrefix = "hvs."
+	BatchPrefix   = "hvb."
+
+	TypeService = "service"
+	TypeBatch   = "batch"
+
+	DefaultTTL = 768 * time.Hour
+	MaxTTL     = 768 * time.Hour
+
+	IDLength = 24
+	alphabet = "0123456789abcdefghijklmnopqrstuvwxyzABCDEFGHIJKLMNOPQRSTUVWXYZ"
+)
+
+type Entry struct {
+	ID             string            `json:"id"`
+	Type           string            `json:"type"`
+	Accessor       string            `json:"accessor"`
+	Policies       []string          `json:"policies"`
+	Meta           map[string]string `json:"meta"`
+	DisplayName    string            `json:"display_name"`
+	NumUses        int               `json:"num_uses"`
+	Path           string            `json:"path"`
+	Parent         string            `json:"parent"`
+	Role           string            `json:"role"`
+	EntityID       string            `json:"entity_id"`
+	Renewable      bool              `json:"renewable"`
+	TTL            time.Duration     `json:"ttl"`
+	ExplicitMaxTTL time.Duration     `json:"explicit_max_ttl"`
+	Period         time.Duration     `json:"period"`
+	BoundCIDRs     []string          `json:"bound_cidrs"`
+	CreationTime   time.Time         `json:"creation_time"`
+	IssueTime      time.Time         `json:"issue_time"`
+	ExpireTime     time.Time         `json:"expire_time"`
+}
+
+func (e Entry) Expired(now time.Time) bool {
+	return !e.ExpireTime.IsZero() && !now.Before(e.ExpireTime)
+}
+
+// UsesExhausted reports whether a use-limited token consumed its last use.
+// Such a token is kept with a negative use count until it is revoked.
+func (e Entry) UsesExhausted() bool {
+	return e.NumUses < 0
+}
+
+func (e Entry) RemainingTTL(now time.Time) time.Duration {
+	if e.ExpireTime.IsZero() {
+		return 0
+	}
+
+	remaining := e.ExpireTime.Sub(now)
+	if remaining < 0 {
+		return 0
+	}
+
+	return remaining
+}
+
+type Store struct {
+	path      string
+	storage   storage.Storage
+	encryptor tools.Encryptor
+	onRevoke  func(ctx context.Context, entry Entry) error
+
+	mu sync.Mutex
+}
+
+func NewStore(path string, s storage.Storage, encryptor tools.Encryptor) *Store {
+	return &Store{
+		path:      path,
+		storage:   s,
+		encryptor: encryptor,
+	}
+}
+
+// OnRevoke registers fn to be called for every service token before it is
+// removed, whether it is revoked explicitly, with its parent or because it
+// expired. The token is kept when fn fails, so the revocation can be retried.
+func (s *Store) OnRevoke(fn func(ctx context.Context, entry Entry) error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	s.onRevoke = fn
+}
+
+func (s *Store) Create(ctx context.Context, entry Entry) (Entry, error) {
+	if entry.NumUses < 0 {
+		return Entry{}, ErrInvalidNumUses
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	id, err := GenerateID(ServicePrefix)
+	if err != nil {
+		return Entry{}, err
+	}
+
+	accessor, err := randomString(IDLength)
+	if err != nil {
+		return Entry{}, err
+	}
+
+	now := time.Now()
+	entry.ID = id
+	entry.Type = TypeService
+	entry.Accessor = accessor
+	entry.CreationTime = now
+	entry.IssueTime = now
+	entry.ExpireTime = expireTime(entry, now, entry.TTL)
+
+	if err = s.writeEntry(ctx, entry); err != nil {
+		return Entry{}, err
+	}
+
+	if err = s.writeAccessor(ctx, entry); err != nil {
+		return Entry{}, err
+	}
+
+	if entry.Parent != "" {
+		err = s.storage.Put(ctx, s.childPath(hashID(entry.Parent), hashID(entry.ID)), nil)
+		if err != nil {
+			return Entry{}, err
+		}
+	}
+
+	return entry, nil
+}
+
+func (s *Store) Lookup(ctx context.Context, id string) (Entry, error) {
+	if IsBatch(id) {
+		return s.openBatch(id)
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	return s.lookup(ctx, id)
+}
+
+// Use consumes one use of a use-limited token. Concurrent requests are
+// serialized, so a token is never used more often than allowed. After its
+// last use the token is exhausted and no longer found, it has to be revoked
+// by the caller or by RevokeExpired.
+func (s *Store) Use(ctx context.Context, id string) (Entry, error) {
+	if IsBatch(id) {
+		return s.openBatch(id)
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	entry, err := s.lookup(ctx, id)
+	if err != nil {
+		return Entry{}, err
+	}
+
+	if entry.NumUses == 0 {
+		return entry, nil
+	}
+
+	entry.NumUses--
+	if entry.NumUses == 0 {
+		entry.NumUses = -1
+	}
+
+	if err = s.writeEntry(ctx, entry); err != nil {
+		return Entry{}, err
+	}
+
+	return entry, nil
+}
+
+func (s *Store) LookupAccessor(ctx context.Context, accessor string) (Entry, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	id, err := s.readAccessor(ctx, accessor)
+	if err != nil {
+		return Entry{}, err
+	}
+
+	return s.lookup(ctx, id)
+}
+
+func (s *Store) ListAccessors(ctx context.Context) ([]string, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	return s.storage.List(ctx, filepath.Join(s.path, "accessor"))
+}
+
+func (s *Store) Renew(ctx context.Context, id string, increment time.Duration) (Entry, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	entry, err := s.lookup(ctx, id)
+	if err != nil {
+		return Entry{}, err
+	}
+
+	if !entry.Renewable {
+		return Entry{}, ErrNotRenewable
+	}
+
+	if entry.ExpireTime.IsZero() {
+		return entry, nil
+	}
+
+	// Periodic tokens are always extended by exactly their period.
+	if increment <= 0 || entry.Period > 0 {
+		increment = entry.TTL
+	}
+
+	entry.ExpireTime = expireTime(entry, time.Now(), increment)
+
+	if err = s.writeEntry(ctx, entry); err != nil {
+		return Entry{}, err
+	}
+
+	return entry, nil
+}
+
+// Revoke revokes the token together with all of its descendants. Children
+// are removed before their parents, so an interrupted revocation never leaves
+// a child without a parent behind.
+func (s *Store) Revoke(ctx context.Context, id string) error {
+	if IsBatch(id) {
+		return ErrBatchToken
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	hash := hashID(id)
+	if _, err := s.readEntry(ctx, hash); err != nil {
+		return err
+	}
+
+	return s.revokeTree(ctx, hash)
+}
+
+// RevokeOrphan revokes only the token itself, its children become orphans.
+func (s *Store) RevokeOrphan(ctx context.Context, id string) error {
+	if IsBatch(id) {
+		return ErrBatchToken
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	hash := hashID(id)
+	if _, err := s.readEntry(ctx, hash); err != nil {
+		return err
+	}
+
+	children, err := s.storage.List(ctx, s.childrenPath(hash))
+	if err != nil {
+		return err
+	}
+
+	for _, childHash := range children {
+		child, err := s.readEntry(ctx, childHash)
+		if err != nil && !errors.Is(err, ErrTokenNotFound) {
+			return err
+		}
+
+		if err == nil {
+			child.Parent = ""
+			if err = s.writeEntry(ctx, child); err != nil {
+				return err
+			}
+		}
+
+		if err = s.deleteIfExists(ctx, s.childPath(hash, childHash)); err != nil {
+			return err
+		}
+	}
+
+	return s.revokeHash(ctx, hash)
+}
+
+// RevokePrefix revokes every token created below the path prefix, e.g. all
+// tokens issued by an auth method, together with their children.
+func (s *Store) RevokePrefix(ctx context.Context, prefix string) (int, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	hashes, err := s.storage.List(ctx, filepath.Join(s.path, "id"))
+	if err != nil {
+		return 0, err
+	}
+
+	prefix = strings.TrimSuffix(prefix, "/") + "/"
+
+	revoked := 0
+	for _, hash := range hashes {
+		entry, err := s.readEntry(ctx, hash)
+		if errors.Is(err, ErrTokenNotFound) {
+			continue
+		}
+		if err != nil {
+			return revoked, err
+		}
+
+		if !strings.HasPrefix(entry.Path, prefix) {
+			continue
+		}
+
+		if err = s.revokeTree(ctx, hash); err != nil {
+			return revoked, err
+		}
+		revoked++
+	}
+
+	return revoked, nil
+}
+
+func (s *Store) revokeTree(ctx context.Context, hash string) error {
+	children, err := s.storage.List(ctx, s.childrenPath(hash))
+	if err != nil {
+		return err
+	}
+
+	for _, childHash := range children {
+		if err = s.revokeTree(ctx, childHash); err != nil {
+			return err
+		}
+	}
+
+	return s.revokeHash(ctx, hash)
+}
+
+func (s *Store) revokeHash(ctx context.Context, hash string) error {
+	entry, err := s.readEntry(ctx, hash)
+	if errors.Is(err, ErrTokenNotFound) {
+		return s.deleteIfExists(ctx, s.childrenPath(hash))
+	}
+	if err != nil {
+		return err
+	}
+
+	if s.onRevoke != nil {
+		if err = s.onRevoke(ctx, entry); err != nil {
+			return err
+		}
+	}
+
+	if err = s.deleteIfExists(ctx, s.accessorPath(entry.Accessor)); err != nil {
+		return err
+	}
+
+	if entry.Parent != "" {
+		if err = s.deleteIfExists(ctx, s.childPath(hashID(entry.Parent), hash)); err != nil {
+			return err
+		}
+	}
+
+	if err = s.deleteIfExists(ctx, s.childrenPath(hash)); err != nil {
+		return err
+	}
+
+	return s.deleteIfExists(ctx, s.entryPath(hash))
+}
+
+func (s *Store) deleteIfExists(ctx context.Context, path string) error {
+	err := s.storage.Delete(ctx, path)
+	if errors.Is(err, storage.ErrPathNotFound) {
+		return nil
+	}
+
+	return err
+}
+
+func (s *Store) lookup(ctx context.Context, id string) (Entry, error) {
+	if IsBatch(id) {
+		return s.openBatch(id)
+	}
+
+	entry, err := s.readEntry(ctx, hashID(id))
+	if err != nil {
+		return Entry{}, err
+	}
+
+	if entry.Expired(time.Now()) || entry.UsesExhausted() {
+		return Entry{}, ErrTokenNotFound
+	}
+
+	return entry, nil
+}
+
+func (s *Store) readEntry(ctx context.Context, hash string) (Entry, error) {
+	b, err := s.storage.Get(ctx, s.entryPath(hash))
+	if errors.Is(err, storage.ErrPathNotFound) {
+		return Entry{}, ErrTokenNotFound
+	}
+	if err != nil {
+		return Entry{}, err
+	}
+
+	decryptedData, err := s.encryptor.Decrypt(b)
+	if err != nil {
+		return Entry{}, err
+	}
+
+	var entry Entry
+	err = json.Unmarshal(decryptedData, &entry)
+	if err != nil {
+		return Entry{}, err
+	}
+
+	return entry, nil
+}
+
+func (s *Store) writeEntry(ctx context.Context, entry Entry) error {
+	d, err := json.Marshal(entry)
+	if err != nil {
+		return err
+	}
+
+	encryptedData, err := s.encryptor.Encrypt(d)
+	if err != nil {
+		return err
+	}
+
+	return s.storage.Put(ctx, s.entryPath(hashID(entry.ID)), encryptedData)
+}
+
+func (s *Store) readAccessor(ctx context.Context, accessor string) (string, error) {
+	if accessor == "" || strings.ContainsAny(accessor, "./") {
+		return "", ErrTokenNotFound
+	}
+
+	b, err := s.storage.Get(ctx, s.accessorPath(accessor))
+	if errors.Is(err, storage.ErrPathNotFound) {
+		return "", ErrTokenNotFound
+	}
+	if err != nil {
+		return "", err
+	}
+
+	id, err := s.encryptor.Decrypt(b)
+	if err != nil {
+		return "", err
+	}
+
+	return string(id), nil
+}
+
+func (s *Store) writeAccessor(ctx context.Context, entry Entry) error {
+	encryptedData, err := s.encryptor.Encrypt([]byte(entry.ID))
+	if err != nil {
+		return err
+	}
+
+	return s.storage.Put(ctx, s.accessorPath(entry.Accessor), encryptedData)
+}
+
+func (s *Store) accessorPath(accessor string) string {
+	return filepath.Join(s.path, "accessor", accessor)
+}
+
+func (s *Store) entryPath(hash string) string {
+	return filepath.Join(s.path, "id", hash)
+}
+
+func (s *Store) childrenPath(parentHash string) string {
+	return filepath.Join(s.path, "parent", parentHash)
+}
+
+func (s *Store) childPath(parentHash string, childHash string) string {
+	return filepath.Join(s.path, "parent", parentHash, childHash)
+}
+
+func expireTime(entry Entry, now time.Time, ttl time.Duration) time.Time {
+	if ttl <= 0 {
+		return time.Time{}
+	}
+
+	expire := now.Add(ttl)
+
+	// Periodic tokens live as long as they are renewed, unless an explicit
+	// max TTL is set.
+	var maxExpire time.Time
+	switch {
+	case entry.ExplicitMaxTTL > 0 && (entry.Period > 0 || entry.ExplicitMaxTTL < MaxTTL):
+		maxExpire = entry.CreationTime.Add(entry.ExplicitMaxTTL)
+	case entry.Period > 0:
+		return expire
+	default:
+		maxExpire = entry.CreationTime.Add(MaxTTL)
+	}
+
+	if expire.After(maxExpire) {
+		return maxExpire
+	}
+
+	return expire
+}
+
+func hashID(id string) string {
+	sum := sha256.Sum256([]byte(id))
+	return hex.EncodeToString(sum[:])
+}
+
+func GenerateID(prefix string) (string, error) {
+	id, err := randomString(IDLength)
+	if err != nil {
+		return "", err
+	}
+
+	return prefix + id, nil
+}
+
+// GenerateOTP returns a one-time pad of the same length as a service token,
+// used to encode a token handed out by root generation.
+func GenerateOTP() (string, error) {
+	return randomString(len(ServicePrefix) + IDLength)
+}
+
+func randomString(length int) (string, error) {
+	b := make([]byte, length)
+	max := big.NewInt(int64(len(alphabet)))
+	for i := range b {
+		n, err := rand.Int(rand.Reader, max)
+		if err != nil {
+			return "", err
+		}
+		b[i] = alphabet[n.Int64()]
+	}
+
+	return string(b), nil
+}
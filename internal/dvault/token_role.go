@@ -0,0 +1,242 @@
+package dvault
+
+import (
+	"context"
+	"fmt"
+	"slices"
+	"strings"
+
+	"github.com/Burzich/dvault/internal/dvault/policy"
+	"github.com/Burzich/dvault/internal/dvault/token"
+	"github.com/Burzich/dvault/internal/tools"
+)
+
+func (d *DVault) ListTokenRoles(ctx context.Context) (Response, error) {
+	d.mu.RLock()
+	defer d.mu.RUnlock()
+
+	if d.isSealed {
+		return Response{}, ErrSealed
+	}
+
+	roles, err := d.tokens.ListRoles(ctx)
+	if err != nil {
+		return Response{}, err
+	}
+
+	var response Response
+	response.Data = KeyList{Keys: roles}
+	response.MountType = "token"
+	response.RequestId = tools.GenerateXRequestID()
+
+	return response, nil
+}
+
+func (d *DVault) GetTokenRole(ctx context.Context, name string) (Response, error) {
+	d.mu.RLock()
+	defer d.mu.RUnlock()
+
+	if d.isSealed {
+		return Response{}, ErrSealed
+	}
+
+	role, err := d.tokens.GetRole(ctx, name)
+	if err != nil {
+		return Response{}, err
+	}
+
+	var response Response
+	response.Data = TokenRoleData{
+		Name:                role.Name,
+		AllowedPolicies:     role.AllowedPolicies,
+		DisallowedPolicies:  role.DisallowedPolicies,
+		Orphan:              role.Orphan,
+		Renewable:           role.Renewable,
+		TokenPeriod:         int(role.TokenPeriod.Seconds()),
+		TokenExplicitMaxTtl: int(role.TokenExplicitMaxTTL.Seconds()),
+		TokenBoundCidrs:     role.TokenBoundCIDRs,
+		PathSuffix:          role.PathSuffix,
+	}
+	response.MountType = "token"
+	response.RequestId = tools.GenerateXRequestID()
+
+	return response, nil
+}
+
+func (d *DVault) SaveTokenRole(ctx context.Context, name string, role TokenRole) (Response, error) {
+	d.mu.RLock()
+	defer d.mu.RUnlock()
+
+	if d.isSealed {
+		return Response{}, ErrSealed
+	}
+
+	renewable := true
+	if role.Renewable != nil {
+		renewable = *role.Renewable
+	}
+
+	err := d.tokens.PutRole(ctx, token.Role{
+		Name:                name,
+		AllowedPolicies:     role.AllowedPolicies,
+		DisallowedPolicies:  role.DisallowedPolicies,
+		Orphan:              role.Orphan,
+		Renewable:           renewable,
+		TokenPeriod:         role.TokenPeriod,
+		TokenExplicitMaxTTL: role.TokenExplicitMaxTTL,
+		TokenBoundCIDRs:     role.TokenBoundCIDRs,
+		PathSuffix:          strings.Trim(role.PathSuffix, "/"),
+	})
+	if err != nil {
+		return Response{}, err
+	}
+
+	var response Response
+	response.MountType = "token"
+	response.RequestId = tools.GenerateXRequestID()
+
+	return response, nil
+}
+
+func (d *DVault) DeleteTokenRole(ctx context.Context, name string) (Response, error) {
+	d.mu.RLock()
+	defer d.mu.RUnlock()
+
+	if d.isSealed {
+		return Response{}, ErrSealed
+	}
+
+	err := d.tokens.DeleteRole(ctx, name)
+	if err != nil {
+		return Response{}, err
+	}
+
+	var response Response
+	response.MountType = "token"
+	response.RequestId = tools.GenerateXRequestID()
+
+	return response, nil
+}
+
+func (d *DVault) CreateRoleToken(ctx context.Context, roleName string, create CreateToken) (Response, error) {
+	d.mu.RLock()
+	defer d.mu.RUnlock()
+
+	if d.isSealed {
+		return Response{}, ErrSealed
+	}
+
+	parent, ok := tokenFromContext(ctx)
+	if !ok {
+		return Response{}, ErrPermissionDenied
+	}
+
+	if parent.Type == token.TypeBatch {
+		return Response{}, fmt.Errorf("%w: batch tokens can not create child tokens", ErrPermissionDenied)
+	}
+
+	role, err := d.tokens.GetRole(ctx, roleName)
+	if err != nil {
+		return Response{}, err
+	}
+
+	policies, err := rolePolicies(parent, role, create.Policies, create.NoDefaultPolicy)
+	if err != nil {
+		return Response{}, err
+	}
+
+	renewable := role.Renewable
+	if create.Renewable != nil {
+		renewable = renewable && *create.Renewable
+	}
+
+	ttl := create.TTL
+	if ttl == 0 {
+		ttl = token.DefaultTTL
+	}
+	if role.TokenPeriod > 0 {
+		ttl = role.TokenPeriod
+	}
+
+	explicitMaxTTL := create.ExplicitMaxTTL
+	if role.TokenExplicitMaxTTL > 0 && (explicitMaxTTL == 0 || role.TokenExplicitMaxTTL < explicitMaxTTL) {
+		explicitMaxTTL = role.TokenExplicitMaxTTL
+	}
+
+	displayName := "token"
+	if create.DisplayName != "" {
+		displayName = "token-" + create.DisplayName
+	}
+
+	path := "auth/token/create/" + role.Name
+	if role.PathSuffix != "" {
+		path += "/" + role.PathSuffix
+	}
+
+	parentID := parent.ID
+	if role.Orphan {
+		parentID = ""
+	}
+
+	entry, err := d.tokens.Create(ctx, token.Entry{
+		Policies:       policies,
+		Meta:           create.Meta,
+		DisplayName:    displayName,
+		NumUses:        create.NumUses,
+		Path:           path,
+		Role:           role.Name,
+		Parent:         parentID,
+		EntityID:       parent.EntityID,
+		Renewable:      renewable,
+		TTL:            ttl,
+		ExplicitMaxTTL: explicitMaxTTL,
+		Period:         role.TokenPeriod,
+		BoundCIDRs:     role.TokenBoundCIDRs,
+	})
+	if err != nil {
+		return Response{}, err
+	}
+
+	var response Response
+	response.Auth = tokenAuth(entry)
+	response.MountType = "token"
+	response.RequestId = tools.GenerateXRequestID()
+
+	return response, nil
+}
+
+// rolePolicies computes the policies of a token created against role. A role
+// with allowed_policies may grant them regardless of the parent's policies,
+// otherwise the usual parent subset rule applies. Disallowed policies are
+// rejected in both cases.
+func rolePolicies(parent token.Entry, role token.Role, requested []string, noDefaultPolicy bool) ([]string, error) {
+	var policies []string
+	if len(role.AllowedPolicies) > 0 {
+		policies = slices.Clone(requested)
+		if len(policies) == 0 {
+			policies = slices.Clone(role.AllowedPolicies)
+		}
+
+		for _, p := range policies {
+			if p != policy.Default && !slices.Contains(role.AllowedPolicies, p) {
+				return nil, fmt.Errorf("%w: token policies %v must be subset of the role's allowed policies", ErrPermissionDenied, policies)
+			}
+		}
+	} else {
+		var err error
+		policies, err = childPolicies(parent, requested, noDefaultPolicy)
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	for _, p := range policies {
+		if p != policy.Default && slices.Contains(role.DisallowedPolicies, p) {
+			return nil, fmt.Errorf("%w: token policy %q is disallowed by the role", ErrPermissionDenied, p)
+		}
+	}
+
+	addDefault := !noDefaultPolicy && !slices.Contains(role.DisallowedPolicies, policy.Default)
+
+	return sanitizePolicies(policies, addDefault), nil
+}
@@ -0,0 +1,189 @@
+package dvault
+
+import (
+	"context"
+	"path/filepath"
+
+	"github.com/Burzich/dvault/internal/dvault/auth/userpass"
+	"github.com/Burzich/dvault/internal/tools"
+)
+
+func (d *DVault) ListUserpassUsers(ctx context.Context, mount string) (Response, error) {
+	d.mu.RLock()
+	defer d.mu.RUnlock()
+
+	if d.isSealed {
+		return Response{}, ErrSealed
+	}
+
+	backend, err := d.userpass(mount)
+	if err != nil {
+		return Response{}, err
+	}
+
+	users, err := backend.ListUsers(ctx)
+	if err != nil {
+		return Response{}, err
+	}
+
+	var response Response
+	response.Data = KeyList{Keys: users}
+	response.MountType = "userpass"
+	response.RequestId = tools.GenerateXRequestID()
+
+	return response, nil
+}
+
+func (d *DVault) GetUserpassUser(ctx context.Context, mount string, name string) (Response, error) {
+	d.mu.RLock()
+	defer d.mu.RUnlock()
+
+	if d.isSealed {
+		return Response{}, ErrSealed
+	}
+
+	backend, err := d.userpass(mount)
+	if err != nil {
+		return Response{}, err
+	}
+
+	user, err := backend.GetUser(ctx, name)
+	if err != nil {
+		return Response{}, err
+	}
+
+	var response Response
+	response.Data = UserpassUserData{TokenParamsData: tokenParamsData(user.TokenParams)}
+	response.MountType = "userpass"
+	response.RequestId = tools.GenerateXRequestID()
+
+	return response, nil
+}
+
+func (d *DVault) SaveUserpassUser(ctx context.Context, mount string, name string, user UserpassUser) (Response, error) {
+	d.mu.RLock()
+	defer d.mu.RUnlock()
+
+	if d.isSealed {
+		return Response{}, ErrSealed
+	}
+
+	backend, err := d.userpass(mount)
+	if err != nil {
+		return Response{}, err
+	}
+
+	err = backend.PutUser(ctx, userpass.User{Name: name, TokenParams: user.TokenParams}, user.Password)
+	if err != nil {
+		return Response{}, err
+	}
+
+	var response Response
+	response.MountType = "userpass"
+	response.RequestId = tools.GenerateXRequestID()
+
+	return response, nil
+}
+
+func (d *DVault) DeleteUserpassUser(ctx context.Context, mount string, name string) (Response, error) {
+	d.mu.RLock()
+	defer d.mu.RUnlock()
+
+	if d.isSealed {
+		return Response{}, ErrSealed
+	}
+
+	backend, err := d.userpass(mount)
+	if err != nil {
+		return Response{}, err
+	}
+
+	if err = backend.DeleteUser(ctx, name); err != nil {
+		return Response{}, err
+	}
+
+	var response Response
+	response.MountType = "userpass"
+	response.RequestId = tools.GenerateXRequestID()
+
+	return response, nil
+}
+
+func (d *DVault) UpdateUserpassPassword(ctx context.Context, mount string, name string, password string) (Response, error) {
+	d.mu.RLock()
+	defer d.mu.RUnlock()
+
+	if d.isSealed {
+		return Response{}, ErrSealed
+	}
+
+	backend, err := d.userpass(mount)
+	if err != nil {
+		return Response{}, err
+	}
+
+	if err = backend.SetPassword(ctx, name, password); err != nil {
+		return Response{}, err
+	}
+
+	var response Response
+	response.MountType = "userpass"
+	response.RequestId = tools.GenerateXRequestID()
+
+	return response, nil
+}
+
+func (d *DVault) UpdateUserpassPolicies(ctx context.Context, mount string, name string, policies []string) (Response, error) {
+	d.mu.RLock()
+	defer d.mu.RUnlock()
+
+	if d.isSealed {
+		return Response{}, ErrSealed
+	}
+
+	backend, err := d.userpass(mount)
+	if err != nil {
+		return Response{}, err
+	}
+
+	if err = backend.SetPolicies(ctx, name, policies); err != nil {
+		return Response{}, err
+	}
+
+	var response Response
+	response.MountType = "userpass"
+	response.RequestId = tools.GenerateXRequestID()
+
+	return response, nil
+}
+
+// LoginUserpass exchanges a username and password for a token carrying the
+// token settings of the user.
+func (d *DVault) LoginUserpass(ctx context.Context, mount string, name string, password string) (Response, error) {
+	d.mu.RLock()
+	defer d.mu.RUnlock()
+
+	if d.isSealed {
+		return Response{}, ErrSealed
+	}
+
+	backend, err := d.userpass(mount)
+	if err != nil {
+		return Response{}, err
+	}
+
+	user, err := backend.Login(ctx, name, password)
+	if err != nil {
+		return Response{}, err
+	}
+
+	return d.issueLoginToken(ctx, login{
+		Mount:       mount,
+		MountType:   "userpass",
+		Path:        filepath.Join(authPath, mount, "login", user.Name),
+		DisplayName: "userpass-" + user.Name,
+		Alias:       user.Name,
+		Meta:        map[string]string{"username": user.Name},
+		Params:      user.TokenParams,
+	})
+}
@@ -0,0 +1,246 @@
+package dvault
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"slices"
+	"strconv"
+	"time"
+
+	"github.com/Burzich/dvault/internal/dvault/cubbyhole"
+	"github.com/Burzich/dvault/internal/dvault/policy"
+	"github.com/Burzich/dvault/internal/dvault/token"
+	"github.com/Burzich/dvault/internal/tools"
+)
+
+const (
+	wrapCreationPath = "sys/wrapping/wrap"
+
+	// wrappedResponsePath is where the wrapped response is kept in the
+	// cubbyhole of the wrapping token.
+	wrappedResponsePath = "response"
+
+	// DefaultWrapTTL is the TTL of sys/wrapping/wrap without a
+	// X-Vault-Wrap-TTL header, as in Vault.
+	DefaultWrapTTL = 5 * time.Minute
+)
+
+// ParseWrapTTL parses the X-Vault-Wrap-TTL header, which is either a number
+// of seconds or a duration like "5m".
+func ParseWrapTTL(value string) (time.Duration, error) {
+	if seconds, err := strconv.Atoi(value); err == nil {
+		return time.Duration(seconds) * time.Second, nil
+	}
+
+	ttl, err := time.ParseDuration(value)
+	if err != nil {
+		return 0, fmt.Errorf("%w: %q", ErrInvalidWrapTTL, value)
+	}
+
+	return ttl, nil
+}
+
+// WrapResponse stores the JSON response of a request to path in the cubbyhole
+// of a new single use token. The wrap info of that token is returned in place
+// of the response.
+func (d *DVault) WrapResponse(ctx context.Context, path string, ttl time.Duration, response []byte) (Response, error) {
+	d.mu.RLock()
+	defer d.mu.RUnlock()
+
+	if d.isSealed {
+		return Response{}, ErrSealed
+	}
+
+	return d.wrap(ctx, path, ttl, response)
+}
+
+// Wrap wraps arbitrary data, which is returned as the data of the response on
+// unwrap.
+func (d *DVault) Wrap(ctx context.Context, ttl time.Duration, data map[string]interface{}) (Response, error) {
+	d.mu.RLock()
+	defer d.mu.RUnlock()
+
+	if d.isSealed {
+		return Response{}, ErrSealed
+	}
+
+	var wrapped Response
+	wrapped.Data = data
+	wrapped.RequestId = tools.GenerateXRequestID()
+
+	b, err := json.Marshal(wrapped)
+	if err != nil {
+		return Response{}, err
+	}
+
+	return d.wrap(ctx, wrapCreationPath, ttl, b)
+}
+
+// Unwrap returns the wrapped response and revokes the wrapping token, so a
+// response can be unwrapped only once.
+func (d *DVault) Unwrap(ctx context.Context, id string) (Response, error) {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+
+	if d.isSealed {
+		return Response{}, ErrSealed
+	}
+
+	entry, raw, err := d.wrappedResponse(ctx, id)
+	if err != nil {
+		return Response{}, err
+	}
+
+	if err = d.revokeWrappingToken(ctx, entry); err != nil {
+		return Response{}, err
+	}
+
+	var response Response
+	if err = json.Unmarshal(raw, &response); err != nil {
+		return Response{}, err
+	}
+	response.RequestId = tools.GenerateXRequestID()
+
+	return response, nil
+}
+
+// LookupWrapping returns the properties of a wrapping token without using it.
+func (d *DVault) LookupWrapping(ctx context.Context, id string) (Response, error) {
+	d.mu.RLock()
+	defer d.mu.RUnlock()
+
+	if d.isSealed {
+		return Response{}, ErrSealed
+	}
+
+	entry, err := d.wrappingToken(ctx, id)
+	if err != nil {
+		return Response{}, err
+	}
+
+	var response Response
+	response.Data = WrappingLookup{
+		CreationPath: entry.Path,
+		CreationTime: entry.CreationTime,
+		CreationTtl:  int(entry.TTL.Seconds()),
+	}
+	response.RequestId = tools.GenerateXRequestID()
+
+	return response, nil
+}
+
+// Rewrap moves a wrapped response to a new wrapping token with the same TTL
+// and revokes the old token.
+func (d *DVault) Rewrap(ctx context.Context, id string) (Response, error) {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+
+	if d.isSealed {
+		return Response{}, ErrSealed
+	}
+
+	entry, raw, err := d.wrappedResponse(ctx, id)
+	if err != nil {
+		return Response{}, err
+	}
+
+	response, err := d.wrap(ctx, entry.Path, entry.TTL, raw)
+	if err != nil {
+		return Response{}, err
+	}
+
+	if err = d.revokeWrappingToken(ctx, entry); err != nil {
+		return Response{}, err
+	}
+
+	return response, nil
+}
+
+func (d *DVault) wrap(ctx context.Context, path string, ttl time.Duration, raw []byte) (Response, error) {
+	if ttl <= 0 || ttl > token.MaxTTL {
+		return Response{}, fmt.Errorf("%w: must be between 1s and %s", ErrInvalidWrapTTL, token.MaxTTL)
+	}
+
+	entry, err := d.tokens.Create(ctx, token.Entry{
+		Policies:       []string{policy.ResponseWrapping},
+		DisplayName:    policy.ResponseWrapping,
+		NumUses:        1,
+		Path:           path,
+		TTL:            ttl,
+		ExplicitMaxTTL: ttl,
+	})
+	if err != nil {
+		return Response{}, err
+	}
+
+	err = d.cubbyhole.Put(ctx, entry.Accessor, wrappedResponsePath, map[string]interface{}{
+		"response": string(raw),
+	})
+	if err != nil {
+		return Response{}, errors.Join(err, d.tokens.Revoke(ctx, entry.ID))
+	}
+
+	var response Response
+	response.WrapInfo = WrapInfo{
+		Token:        entry.ID,
+		Accessor:     entry.Accessor,
+		Ttl:          int(ttl.Seconds()),
+		CreationTime: entry.CreationTime,
+		CreationPath: path,
+	}
+	response.RequestId = tools.GenerateXRequestID()
+
+	return response, nil
+}
+
+// wrappingToken resolves id to a token issued by wrap. Other tokens are
+// reported as invalid wrapping tokens.
+func (d *DVault) wrappingToken(ctx context.Context, id string) (token.Entry, error) {
+	if id == "" {
+		return token.Entry{}, ErrInvalidWrappingToken
+	}
+
+	entry, err := d.tokens.Lookup(ctx, id)
+	if errors.Is(err, token.ErrTokenNotFound) {
+		return token.Entry{}, ErrInvalidWrappingToken
+	}
+	if err != nil {
+		return token.Entry{}, err
+	}
+
+	if !slices.Equal(entry.Policies, []string{policy.ResponseWrapping}) {
+		return token.Entry{}, ErrInvalidWrappingToken
+	}
+
+	return entry, nil
+}
+
+func (d *DVault) wrappedResponse(ctx context.Context, id string) (token.Entry, []byte, error) {
+	entry, err := d.wrappingToken(ctx, id)
+	if err != nil {
+		return token.Entry{}, nil, err
+	}
+
+	data, err := d.cubbyhole.Get(ctx, entry.Accessor, wrappedResponsePath)
+	if errors.Is(err, cubbyhole.ErrPathNotFound) {
+		return token.Entry{}, nil, ErrInvalidWrappingToken
+	}
+	if err != nil {
+		return token.Entry{}, nil, err
+	}
+
+	raw, ok := data["response"].(string)
+	if !ok {
+		return token.Entry{}, nil, ErrInvalidWrappingToken
+	}
+
+	return entry, []byte(raw), nil
+}
+
+// revokeWrappingToken revokes the token, which destroys its cubbyhole with
+// the wrapped response.
+func (d *DVault) revokeWrappingToken(ctx context.Context, entry token.Entry) error {
+	return d.tokens.Revoke(ctx, entry.ID)
+}
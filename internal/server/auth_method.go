@@ -0,0 +1,118 @@
+package server
+
+import (
+	"net/http"
+
+	"github.com/Burzich/dvault/internal/dvault"
+	"github.com/go-chi/chi/v5"
+)
+
+// authMethods dispatches requests below /v1/auth/{auth_mount} to the routes of
+// the auth method type mounted there. Each type decides itself which of its
+// routes, like login, can be used without a token.
+func (s *Server) authMethods(methods map[string]http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		mountType, err := s.auth.AuthMethodType(r.Context(), chi.URLParam(r, "auth_mount"))
+		if err != nil {
+			writeError(w, err)
+			return
+		}
+
+		routes, ok := methods[mountType]
+		if !ok {
+			writeError(w, dvault.ErrAuthMethodNotFound)
+			return
+		}
+
+		routes.ServeHTTP(w, r)
+	})
+}
+
+func (s *Server) appRoleRoutes(h DVaultHandler) http.Handler {
+	r := chi.NewRouter()
+
+	r.Post("/login", h.LoginAppRole)
+
+	r.Group(func(r chi.Router) {
+		r.Use(s.authenticate, s.authorize, s.wrapResponse)
+
+		r.Get("/role", h.ListAppRoles)
+		r.Get("/role/", h.ListAppRoles)
+		r.Get("/role/{role_name}", h.GetAppRole)
+		r.Post("/role/{role_name}", h.SaveAppRole)
+		r.Delete("/role/{role_name}", h.DeleteAppRole)
+		r.Get("/role/{role_name}/role-id", h.GetAppRoleRoleID)
+		r.Post("/role/{role_name}/role-id", h.SaveAppRoleRoleID)
+		r.Get("/role/{role_name}/secret-id", h.ListAppRoleSecretIDAccessors)
+		r.Get("/role/{role_name}/secret-id/", h.ListAppRoleSecretIDAccessors)
+		r.Post("/role/{role_name}/secret-id", h.GenerateAppRoleSecretID)
+		r.Post("/role/{role_name}/custom-secret-id", h.CreateAppRoleCustomSecretID)
+		r.Post("/role/{role_name}/secret-id/lookup", h.LookupAppRoleSecretID)
+		r.Post("/role/{role_name}/secret-id/destroy", h.DestroyAppRoleSecretID)
+		r.Delete("/role/{role_name}/secret-id/destroy", h.DestroyAppRoleSecretID)
+		r.Post("/role/{role_name}/secret-id-accessor/lookup", h.LookupAppRoleSecretIDAccessor)
+		r.Post("/role/{role_name}/secret-id-accessor/destroy", h.DestroyAppRoleSecretIDAccessor)
+		r.Delete("/role/{role_name}/secret-id-accessor/destroy", h.DestroyAppRoleSecretIDAccessor)
+		r.Post("/tidy/secret-id", h.TidyAppRoleSecretIDs)
+	})
+
+	return r
+}
+
+func (s *Server) userpassRoutes(h DVaultHandler) http.Handler {
+	r := chi.NewRouter()
+
+	r.Post("/login/{username}", h.LoginUserpass)
+
+	r.Group(func(r chi.Router) {
+		r.Use(s.authenticate, s.authorize, s.wrapResponse)
+
+		r.Get("/users", h.ListUserpassUsers)
+		r.Get("/users/", h.ListUserpassUsers)
+		r.Get("/users/{username}", h.GetUserpassUser)
+		r.Post("/users/{username}", h.SaveUserpassUser)
+		r.Delete("/users/{username}", h.DeleteUserpassUser)
+		r.Post("/users/{username}/password", h.UpdateUserpassPassword)
+		r.Post("/users/{username}/policies", h.UpdateUserpassPolicies)
+	})
+
+	return r
+}
+
+func (s *Server) jwtRoutes(h DVaultHandler) http.Handler {
+	r := chi.NewRouter()
+
+	r.Post("/login", h.LoginJWT)
+
+	r.Group(func(r chi.Router) {
+		r.Use(s.authenticate, s.authorize, s.wrapResponse)
+
+		r.Get("/config", h.GetJWTConfig)
+		r.Post("/config", h.SaveJWTConfig)
+		r.Get("/role", h.ListJWTRoles)
+		r.Get("/role/", h.ListJWTRoles)
+		r.Get("/role/{role_name}", h.GetJWTRole)
+		r.Post("/role/{role_name}", h.SaveJWTRole)
+		r.Delete("/role/{role_name}", h.DeleteJWTRole)
+	})
+
+	return r
+}
+
+func (s *Server) certRoutes(h DVaultHandler) http.Handler {
+	r := chi.NewRouter()
+
+	r.Post("/login", h.LoginCert)
+
+	r.Group(func(r chi.Router) {
+		r.Use(s.authenticate, s.authorize, s.wrapResponse)
+
+		r.Get("/certs", h.ListCertRoles)
+		r.Get("/certs/", h.ListCertRoles)
+		r.Get("/certs/{name}", h.GetCertRole)
+		r.Post("/certs/{name}", h.SaveCertRole)
+		r.Delete("/certs/{name}", h.DeleteCertRole)
+	})
+
+	return r
+}
@@ -23,12 +23,25 @@ type DVaultHandler interface {
 	CreateMount(w http.ResponseWriter, r *http.Request)
 	DeleteMount(w http.ResponseWriter, r *http.Request)
 
+	ListAuthMethods(w http.ResponseWriter, r *http.Request)
+	GetAuthMethod(w http.ResponseWriter, r *http.Request)
+	EnableAuthMethod(w http.ResponseWriter, r *http.Request)
+	DisableAuthMethod(w http.ResponseWriter, r *http.Request)
+	GetAuthMethodTune(w http.ResponseWriter, r *http.Request)
+	TuneAuthMethod(w http.ResponseWriter, r *http.Request)
+
+	ListPolicies(w http.ResponseWriter, r *http.Request)
+	GetPolicy(w http.ResponseWriter, r *http.Request)
+	SavePolicy(w http.ResponseWriter, r *http.Request)
+	DeletePolicy(w http.ResponseWriter, r *http.Request)
+
 	GetTokenAccessors(w http.ResponseWriter, r *http.Request)
 	CreateToken(w http.ResponseWriter, r *http.Request)
 	CreateOrphanToken(w http.ResponseWriter, r *http.Request)
 	CreateRoleToken(w http.ResponseWriter, r *http.Request)
 	LookupToken(w http.ResponseWriter, r *http.Request)
 	LookupSelfToken(w http.ResponseWriter, r *http.Request)
+	LookupAccessorToken(w http.ResponseWriter, r *http.Request)
 	RenewToken(w http.ResponseWriter, r *http.Request)
 	RenewAccessorToken(w http.ResponseWriter, r *http.Request)
 	RenewSelfToken(w http.ResponseWriter, r *http.Request)
@@ -42,9 +55,114 @@ type DVaultHandler interface {
 	DeleteRoleByNameToken(w http.ResponseWriter, r *http.Request)
 	TidyToken(w http.ResponseWriter, r *http.Request)
 
+	ListAppRoles(w http.ResponseWriter, r *http.Request)
+	GetAppRole(w http.ResponseWriter, r *http.Request)
+	SaveAppRole(w http.ResponseWriter, r *http.Request)
+	DeleteAppRole(w http.ResponseWriter, r *http.Request)
+	GetAppRoleRoleID(w http.ResponseWriter, r *http.Request)
+	SaveAppRoleRoleID(w http.ResponseWriter, r *http.Request)
+	GenerateAppRoleSecretID(w http.ResponseWriter, r *http.Request)
+	CreateAppRoleCustomSecretID(w http.ResponseWriter, r *http.Request)
+	ListAppRoleSecretIDAccessors(w http.ResponseWriter, r *http.Request)
+	LookupAppRoleSecretID(w http.ResponseWriter, r *http.Request)
+	DestroyAppRoleSecretID(w http.ResponseWriter, r *http.Request)
+	LookupAppRoleSecretIDAccessor(w http.ResponseWriter, r *http.Request)
+	DestroyAppRoleSecretIDAccessor(w http.ResponseWriter, r *http.Request)
+	TidyAppRoleSecretIDs(w http.ResponseWriter, r *http.Request)
+	LoginAppRole(w http.ResponseWriter, r *http.Request)
+
+	ListUserpassUsers(w http.ResponseWriter, r *http.Request)
+	GetUserpassUser(w http.ResponseWriter, r *http.Request)
+	SaveUserpassUser(w http.ResponseWriter, r *http.Request)
+	DeleteUserpassUser(w http.ResponseWriter, r *http.Request)
+	UpdateUserpassPassword(w http.ResponseWriter, r *http.Request)
+	UpdateUserpassPolicies(w http.ResponseWriter, r *http.Request)
+	LoginUserpass(w http.ResponseWriter, r *http.Request)
+
+	GetJWTConfig(w http.ResponseWriter, r *http.Request)
+	SaveJWTConfig(w http.ResponseWriter, r *http.Request)
+	ListJWTRoles(w http.ResponseWriter, r *http.Request)
+	GetJWTRole(w http.ResponseWriter, r *http.Request)
+	SaveJWTRole(w http.ResponseWriter, r *http.Request)
+	DeleteJWTRole(w http.ResponseWriter, r *http.Request)
+	LoginJWT(w http.ResponseWriter, r *http.Request)
+
+	ListCertRoles(w http.ResponseWriter, r *http.Request)
+	GetCertRole(w http.ResponseWriter, r *http.Request)
+	SaveCertRole(w http.ResponseWriter, r *http.Request)
+	DeleteCertRole(w http.ResponseWriter, r *http.Request)
+	LoginCert(w http.ResponseWriter, r *http.Request)
+
+	CreateEntity(w http.ResponseWriter, r *http.Request)
+	ListEntities(w http.ResponseWriter, r *http.Request)
+	GetEntity(w http.ResponseWriter, r *http.Request)
+	UpdateEntity(w http.ResponseWriter, r *http.Request)
+	DeleteEntity(w http.ResponseWriter, r *http.Request)
+	ListEntityNames(w http.ResponseWriter, r *http.Request)
+	GetEntityByName(w http.ResponseWriter, r *http.Request)
+	SaveEntityByName(w http.ResponseWriter, r *http.Request)
+	DeleteEntityByName(w http.ResponseWriter, r *http.Request)
+
+	CreateEntityAlias(w http.ResponseWriter, r *http.Request)
+	ListEntityAliases(w http.ResponseWriter, r *http.Request)
+	GetEntityAlias(w http.ResponseWriter, r *http.Request)
+	UpdateEntityAlias(w http.ResponseWriter, r *http.Request)
+	DeleteEntityAlias(w http.ResponseWriter, r *http.Request)
+
+	CreateGroup(w http.ResponseWriter, r *http.Request)
+	ListGroups(w http.ResponseWriter, r *http.Request)
+	GetGroup(w http.ResponseWriter, r *http.Request)
+	UpdateGroup(w http.ResponseWriter, r *http.Request)
+	DeleteGroup(w http.ResponseWriter, r *http.Request)
+	ListGroupNames(w http.ResponseWriter, r *http.Request)
+	GetGroupByName(w http.ResponseWriter, r *http.Request)
+	SaveGroupByName(w http.ResponseWriter, r *http.Request)
+	DeleteGroupByName(w http.ResponseWriter, r *http.Request)
+
+	CreateGroupAlias(w http.ResponseWriter, r *http.Request)
+	ListGroupAliases(w http.ResponseWriter, r *http.Request)
+	GetGroupAlias(w http.ResponseWriter, r *http.Request)
+	UpdateGroupAlias(w http.ResponseWriter, r *http.Request)
+	DeleteGroupAlias(w http.ResponseWriter, r *http.Request)
+
+	ListTOTPMethods(w http.ResponseWriter, r *http.Request)
+	CreateTOTPMethod(w http.ResponseWriter, r *http.Request)
+	GetTOTPMethod(w http.ResponseWriter, r *http.Request)
+	UpdateTOTPMethod(w http.ResponseWriter, r *http.Request)
+	DeleteTOTPMethod(w http.ResponseWriter, r *http.Request)
+	GenerateTOTPSecret(w http.ResponseWriter, r *http.Request)
+	AdminGenerateTOTPSecret(w http.ResponseWriter, r *http.Request)
+	AdminDestroyTOTPSecret(w http.ResponseWriter, r *http.Request)
+	ListLoginEnforcements(w http.ResponseWriter, r *http.Request)
+	GetLoginEnforcement(w http.ResponseWriter, r *http.Request)
+	SaveLoginEnforcement(w http.ResponseWriter, r *http.Request)
+	DeleteLoginEnforcement(w http.ResponseWriter, r *http.Request)
+
+	GetCubbyholeSecret(w http.ResponseWriter, r *http.Request)
+	SaveCubbyholeSecret(w http.ResponseWriter, r *http.Request)
+	DeleteCubbyholeSecret(w http.ResponseWriter, r *http.Request)
+
+	Wrap(w http.ResponseWriter, r *http.Request)
+	Unwrap(w http.ResponseWriter, r *http.Request)
+	LookupWrapping(w http.ResponseWriter, r *http.Request)
+	Rewrap(w http.ResponseWriter, r *http.Request)
+
 	Unseal(w http.ResponseWriter, r *http.Request)
 	Seal(w http.ResponseWriter, r *http.Request)
+	Rotate(w http.ResponseWriter, r *http.Request)
+	KeyStatus(w http.ResponseWriter, r *http.Request)
 	SealStatus(w http.ResponseWriter, r *http.Request)
 	Init(w http.ResponseWriter, r *http.Request)
+	GetGenerateRootAttempt(w http.ResponseWriter, r *http.Request)
+	StartGenerateRootAttempt(w http.ResponseWriter, r *http.Request)
+	CancelGenerateRootAttempt(w http.ResponseWriter, r *http.Request)
+	UpdateGenerateRoot(w http.ResponseWriter, r *http.Request)
+	GetRekeyAttempt(w http.ResponseWriter, r *http.Request)
+	StartRekeyAttempt(w http.ResponseWriter, r *http.Request)
+	CancelRekeyAttempt(w http.ResponseWriter, r *http.Request)
+	UpdateRekey(w http.ResponseWriter, r *http.Request)
+	GetRekeyVerification(w http.ResponseWriter, r *http.Request)
+	CancelRekeyVerification(w http.ResponseWriter, r *http.Request)
+	VerifyRekey(w http.ResponseWriter, r *http.Request)
 	Health(w http.ResponseWriter, r *http.Request)
 }
@@ -0,0 +1,246 @@
+package server
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net"
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/Burzich/dvault/internal/dvault"
+)
+
+var errInvalidForwardedFor = errors.New("invalid X-Forwarded-For header")
+
+type Authenticator interface {
+	Authenticate(ctx context.Context, token string, clientIP string) (context.Context, error)
+	RevokeExhausted(ctx context.Context) error
+	Authorize(ctx context.Context, path string, operation string) error
+	AuthMethodType(ctx context.Context, path string) (string, error)
+}
+
+type Wrapper interface {
+	WrapResponse(ctx context.Context, path string, ttl time.Duration, response []byte) (dvault.Response, error)
+}
+
+// clientAddr attaches the client address to the request context. For
+// requests passing trusted proxies it is taken from X-Forwarded-For, so CIDR
+// restrictions apply to the client rather than to the proxy. The header is
+// rejected from any other peer, which could otherwise claim any address.
+func (s *Server) clientAddr(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		peer := remoteIP(r)
+
+		header := r.Header.Values("X-Forwarded-For")
+		if s.forwardedForDepth == 0 || len(header) == 0 {
+			next.ServeHTTP(w, r.WithContext(dvault.WithClientIP(r.Context(), peer)))
+			return
+		}
+
+		if !s.forwardedForAuthorized(peer) {
+			writeError(w, fmt.Errorf("%w: %s is not authorized to set it", errInvalidForwardedFor, peer))
+			return
+		}
+
+		var hops []string
+		for _, value := range header {
+			for _, hop := range strings.Split(value, ",") {
+				hops = append(hops, strings.TrimSpace(hop))
+			}
+		}
+
+		if len(hops) < s.forwardedForDepth {
+			writeError(w, fmt.Errorf("%w: X-Forwarded-For has %d entries, expected at least %d", errInvalidForwardedFor, len(hops), s.forwardedForDepth))
+			return
+		}
+
+		addr := hops[len(hops)-s.forwardedForDepth]
+		if net.ParseIP(addr) == nil {
+			writeError(w, fmt.Errorf("%w: invalid client address %q", errInvalidForwardedFor, addr))
+			return
+		}
+
+		next.ServeHTTP(w, r.WithContext(dvault.WithClientIP(r.Context(), addr)))
+	})
+}
+
+func (s *Server) forwardedForAuthorized(peer string) bool {
+	ip := net.ParseIP(peer)
+	if ip == nil {
+		return false
+	}
+
+	for _, ipNet := range s.forwardedForAddrs {
+		if ipNet.Contains(ip) {
+			return true
+		}
+	}
+
+	return false
+}
+
+// mfaCredentials passes the X-Vault-MFA passcodes on to logins and to paths
+// that require MFA.
+func (s *Server) mfaCredentials(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		credentials := r.Header.Values("X-Vault-MFA")
+		if len(credentials) == 0 {
+			next.ServeHTTP(w, r)
+			return
+		}
+
+		next.ServeHTTP(w, r.WithContext(dvault.WithMFACredentials(r.Context(), credentials)))
+	})
+}
+
+// authenticate resolves the request token, checks it against the CIDRs it is
+// bound to and uses up one of its uses. A token that used its last use is
+// revoked once the request is done.
+func (s *Server) authenticate(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		ctx, err := s.auth.Authenticate(r.Context(), requestToken(r), dvault.ClientIP(r.Context()))
+		if err != nil {
+			writeError(w, err)
+			return
+		}
+
+		next.ServeHTTP(w, r.WithContext(ctx))
+
+		// Expiration revokes the token should this fail.
+		_ = s.auth.RevokeExhausted(context.WithoutCancel(ctx))
+	})
+}
+
+func (s *Server) authorize(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		path := strings.Trim(strings.TrimPrefix(r.URL.Path, "/v1/"), "/")
+
+		if err := s.auth.Authorize(r.Context(), path, operation(r)); err != nil {
+			writeError(w, err)
+			return
+		}
+
+		next.ServeHTTP(w, r)
+	})
+}
+
+// wrapResponse replaces the response of a read with a wrapping token when the
+// client asks for it with the X-Vault-Wrap-TTL header. The response is only
+// wrapped when the request succeeded.
+func (s *Server) wrapResponse(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		header := r.Header.Get("X-Vault-Wrap-TTL")
+		op := operation(r)
+		if header == "" || (op != "read" && op != "list") {
+			next.ServeHTTP(w, r)
+			return
+		}
+
+		ttl, err := dvault.ParseWrapTTL(header)
+		if err != nil {
+			writeError(w, err)
+			return
+		}
+
+		rec := &responseRecorder{header: w.Header(), status: http.StatusOK}
+		next.ServeHTTP(rec, r)
+
+		if rec.status != http.StatusOK || !json.Valid(rec.body.Bytes()) {
+			w.WriteHeader(rec.status)
+			_, _ = w.Write(rec.body.Bytes())
+			return
+		}
+
+		path := strings.Trim(strings.TrimPrefix(r.URL.Path, "/v1/"), "/")
+		response, err := s.wrapper.WrapResponse(r.Context(), path, ttl, rec.body.Bytes())
+		if err != nil {
+			writeError(w, err)
+			return
+		}
+
+		w.Header().Del("Content-Length")
+		_ = json.NewEncoder(w).Encode(response)
+	})
+}
+
+// responseRecorder holds back a response until wrapResponse decided whether
+// to wrap it.
+type responseRecorder struct {
+	header http.Header
+	status int
+	body   bytes.Buffer
+}
+
+func (r *responseRecorder) Header() http.Header {
+	return r.header
+}
+
+func (r *responseRecorder) Write(b []byte) (int, error) {
+	return r.body.Write(b)
+}
+
+func (r *responseRecorder) WriteHeader(status int) {
+	r.status = status
+}
+
+func operation(r *http.Request) string {
+	switch r.Method {
+	case http.MethodGet, http.MethodHead:
+		if r.URL.Query().Get("list") == "true" {
+			return "list"
+		}
+		return "read"
+	case http.MethodDelete:
+		return "delete"
+	case "LIST":
+		return "list"
+	default:
+		return "update"
+	}
+}
+
+// remoteIP returns the address of the peer without its port.
+func remoteIP(r *http.Request) string {
+	host, _, err := net.SplitHostPort(r.RemoteAddr)
+	if err != nil {
+		return r.RemoteAddr
+	}
+
+	return host
+}
+
+func requestToken(r *http.Request) string {
+	if token := r.Header.Get("X-Vault-Token"); token != "" {
+		return token
+	}
+
+	if token, ok := strings.CutPrefix(r.Header.Get("Authorization"), "Bearer "); ok {
+		return strings.TrimSpace(token)
+	}
+
+	return ""
+}
+
+func writeError(w http.ResponseWriter, err error) {
+	switch {
+	case errors.Is(err, dvault.ErrPermissionDenied):
+		w.WriteHeader(http.StatusForbidden)
+	case errors.Is(err, dvault.ErrSealed):
+		w.WriteHeader(http.StatusServiceUnavailable)
+	case errors.Is(err, dvault.ErrAuthMethodNotFound):
+		w.WriteHeader(http.StatusNotFound)
+	case errors.Is(err, dvault.ErrInvalidWrapTTL),
+		errors.Is(err, errInvalidForwardedFor):
+		w.WriteHeader(http.StatusBadRequest)
+	default:
+		w.WriteHeader(http.StatusInternalServerError)
+	}
+
+	_ = json.NewEncoder(w).Encode(struct {
+		Errors []string `json:"errors"`
+	}{[]string{err.Error()}})
+}
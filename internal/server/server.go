@@ -2,105 +2,303 @@ package server
 
 import (
 	"context"
+	"crypto/tls"
+	"crypto/x509"
+	"errors"
+	"fmt"
+	"net"
 	"net/http"
 	"net/http/pprof"
+	"os"
+	"strings"
 
+	"github.com/Burzich/dvault/internal/config"
+	"github.com/Burzich/dvault/internal/dvault/token"
 	"github.com/go-chi/chi/v5"
 	"github.com/prometheus/client_golang/prometheus/promhttp"
 )
 
 type Server struct {
-	server  http.Server
-	handler DVaultHandler
+	server      http.Server
+	handler     DVaultHandler
+	auth        Authenticator
+	wrapper     Wrapper
+	tlsCertFile string
+	tlsKeyFile  string
+
+	forwardedForDepth int
+	// forwardedForAddrs are the proxies X-Forwarded-For is accepted from.
+	forwardedForAddrs []*net.IPNet
 }
 
-func NewServer(addr string, h DVaultHandler) *Server {
+func NewServer(cfg config.Server, h DVaultHandler, a Authenticator, wr Wrapper) (*Server, error) {
+	tlsConfig, err := newTLSConfig(cfg)
+	if err != nil {
+		return nil, err
+	}
+
+	forwardedForAddrs, err := parseCIDRs(cfg.ForwardedForAuthorizedAddrs)
+	if err != nil {
+		return nil, err
+	}
+
 	srv := &Server{
 		server: http.Server{
-			Addr: addr,
+			Addr:      cfg.Addr,
+			TLSConfig: tlsConfig,
 		},
-		handler: h,
+		handler:     h,
+		auth:        a,
+		wrapper:     wr,
+		tlsCertFile: cfg.TLSCertFile,
+		tlsKeyFile:  cfg.TLSKeyFile,
+
+		forwardedForDepth: cfg.ForwardedForDepth,
+		forwardedForAddrs: forwardedForAddrs,
 	}
 
 	r := chi.NewMux()
+	r.Use(srv.clientAddr, srv.mfaCredentials)
 
 	r.Route("/v1", func(r chi.Router) {
-		r.Route("/{mount}", func(r chi.Router) {
-			r.Get("/config", h.GetKVConfig)
-			r.Post("/config", h.UpdateKVConfig)
+		r.Route("/sys", func(r chi.Router) {
+			r.Get("/seal-status", h.SealStatus)
+			r.Post("/unseal", h.Unseal)
+			r.Post("/init", h.Init)
+			r.Get("/health", h.Health)
 
-			r.Get("/data/{path}", h.GetKVSecret)
-			r.Post("/data/{path}", h.CreateKVSecret)
-			r.Delete("/data/{path}", h.DeleteLatestKVSecret)
+			r.Get("/generate-root/attempt", h.GetGenerateRootAttempt)
+			r.Post("/generate-root/attempt", h.StartGenerateRootAttempt)
+			r.Delete("/generate-root/attempt", h.CancelGenerateRootAttempt)
+			r.Post("/generate-root/update", h.UpdateGenerateRoot)
 
-			r.Post("/delete/{path}", h.DeleteKVSecret)
-			r.Post("/destroy/{path}", h.DestroyKVSecret)
+			r.Get("/rekey/init", h.GetRekeyAttempt)
+			r.Post("/rekey/init", h.StartRekeyAttempt)
+			r.Put("/rekey/init", h.StartRekeyAttempt)
+			r.Delete("/rekey/init", h.CancelRekeyAttempt)
+			r.Post("/rekey/cancel", h.CancelRekeyAttempt)
+			r.Post("/rekey/update", h.UpdateRekey)
+			r.Put("/rekey/update", h.UpdateRekey)
+			r.Get("/rekey/verify", h.GetRekeyVerification)
+			r.Post("/rekey/verify", h.VerifyRekey)
+			r.Put("/rekey/verify", h.VerifyRekey)
+			r.Delete("/rekey/verify", h.CancelRekeyVerification)
 
-			r.Get("/metadata/{path}", h.GetKVMetadata)
-			r.Post("/metadata/{path}", h.UpdateKVMetadata)
-			r.Delete("/metadata/{path}", h.DeleteKVMetadata)
+			r.Post("/wrapping/lookup", h.LookupWrapping)
+			r.Post("/wrapping/unwrap", h.Unwrap)
+			r.Post("/wrapping/rewrap", h.Rewrap)
 
-			r.Get("/subkeys/{path}", h.GetKVSubkeys)
-			r.Post("/subkeys/{path}", h.CreateKVSubkeys)
-		})
+			r.Group(func(r chi.Router) {
+				r.Use(srv.authenticate, srv.authorize, srv.wrapResponse)
+
+				r.Get("/mounts", h.GetMounts)
+				r.Get("/mounts/{path}", h.GetMount)
+				r.Post("/mounts/{path}", h.CreateMount)
+				r.Delete("/mounts/{path}", h.DeleteMount)
 
-		r.Route("/auth/token", func(r chi.Router) {
-			r.Get("/accessors/", h.GetTokenAccessors)
-			r.Post("/create", h.CreateToken)
-			r.Post("/create-orphan", h.CreateOrphanToken)
-			r.Post("/create/{role_name}", h.CreateRoleToken)
-			r.Get("/lookup", h.LookupToken)
-			r.Post("/lookup", nil)
-			r.Post("/lookup-accessor", nil)
-			r.Get("/lookup-self", h.LookupToken)
-			r.Post("/lookup-self", h.LookupSelfToken)
-			r.Post("/renew", h.RenewToken)
-			r.Post("/renew-accessor", h.RenewAccessorToken)
-			r.Post("/renew-self", h.RenewSelfToken)
-			r.Post("/revoke", h.RevokeToken)
-			r.Post("/revoke-accessor", h.RevokeAccessorToken)
-			r.Post("/revoke-orphan", h.RevokeOrphanToken)
-			r.Post("/revoke-self", h.RevokeSelfToken)
-			r.Get("/roles/", h.GetRolesToken)
-			r.Get("/roles/{role_name}", h.GetRoleByNameToken)
-			r.Post("/roles/{role_name}", h.CreateRoleByNameToken)
-			r.Delete("/roles/{role_name}", h.DeleteRoleByNameToken)
-			r.Post("/tidy", h.TidyToken)
+				r.Post("/wrapping/wrap", h.Wrap)
+
+				r.Get("/auth", h.ListAuthMethods)
+				r.Get("/auth/{path}", h.GetAuthMethod)
+				r.Post("/auth/{path}", h.EnableAuthMethod)
+				r.Delete("/auth/{path}", h.DisableAuthMethod)
+				r.Get("/auth/{path}/tune", h.GetAuthMethodTune)
+				r.Post("/auth/{path}/tune", h.TuneAuthMethod)
+
+				r.Get("/policies/acl", h.ListPolicies)
+				r.Get("/policies/acl/{name}", h.GetPolicy)
+				r.Post("/policies/acl/{name}", h.SavePolicy)
+				r.Put("/policies/acl/{name}", h.SavePolicy)
+				r.Delete("/policies/acl/{name}", h.DeletePolicy)
+
+				r.Post("/seal", h.Seal)
+				r.Post("/rotate", h.Rotate)
+				r.Put("/rotate", h.Rotate)
+				r.Get("/key-status", h.KeyStatus)
+
+				r.Get("/metrics", promhttp.Handler().ServeHTTP)
+				r.HandleFunc("/pprof/*", pprof.Index)
+				r.HandleFunc("/pprof/cmdline", pprof.Cmdline)
+				r.HandleFunc("/pprof/profile", pprof.Profile)
+				r.HandleFunc("/pprof/symbol", pprof.Symbol)
+				r.HandleFunc("/pprof/trace", pprof.Trace)
+				r.Handle("/pprof/goroutine", pprof.Handler("goroutine"))
+				r.Handle("/pprof/threadcreate", pprof.Handler("threadcreate"))
+				r.Handle("/pprof/mutex", pprof.Handler("mutex"))
+				r.Handle("/pprof/heap", pprof.Handler("heap"))
+				r.Handle("/pprof/block", pprof.Handler("block"))
+				r.Handle("/pprof/allocs", pprof.Handler("allocs"))
+			})
 		})
 
-		r.Route("/sys", func(r chi.Router) {
-			r.Get("/mounts", h.GetMounts)
-			r.Get("/mounts/{path}", h.GetMount)
-			r.Post("/mounts/{path}", h.CreateMount)
-			r.Delete("/mounts/{path}", h.DeleteMount)
+		r.Mount("/auth/{auth_mount}", srv.authMethods(map[string]http.Handler{
+			"approle":  srv.appRoleRoutes(h),
+			"userpass": srv.userpassRoutes(h),
+			"jwt":      srv.jwtRoutes(h),
+			"cert":     srv.certRoutes(h),
+		}))
 
-			r.Post("/seal", h.Seal)
-			r.Get("/seal-status", h.SealStatus)
-			r.Post("/unseal", h.Unseal)
-			r.Post("/init", h.Init)
-			r.Get("/health", h.Health)
+		r.Group(func(r chi.Router) {
+			r.Use(srv.authenticate, srv.authorize, srv.wrapResponse)
+
+			r.Route("/identity", func(r chi.Router) {
+				r.Post("/entity", h.CreateEntity)
+				r.Get("/entity/id", h.ListEntities)
+				r.Get("/entity/id/{id}", h.GetEntity)
+				r.Post("/entity/id/{id}", h.UpdateEntity)
+				r.Delete("/entity/id/{id}", h.DeleteEntity)
+				r.Get("/entity/name", h.ListEntityNames)
+				r.Get("/entity/name/{name}", h.GetEntityByName)
+				r.Post("/entity/name/{name}", h.SaveEntityByName)
+				r.Delete("/entity/name/{name}", h.DeleteEntityByName)
+
+				r.Post("/entity-alias", h.CreateEntityAlias)
+				r.Get("/entity-alias/id", h.ListEntityAliases)
+				r.Get("/entity-alias/id/{id}", h.GetEntityAlias)
+				r.Post("/entity-alias/id/{id}", h.UpdateEntityAlias)
+				r.Delete("/entity-alias/id/{id}", h.DeleteEntityAlias)
+
+				r.Post("/group", h.CreateGroup)
+				r.Get("/group/id", h.ListGroups)
+				r.Get("/group/id/{id}", h.GetGroup)
+				r.Post("/group/id/{id}", h.UpdateGroup)
+				r.Delete("/group/id/{id}", h.DeleteGroup)
+				r.Get("/group/name", h.ListGroupNames)
+				r.Get("/group/name/{name}", h.GetGroupByName)
+				r.Post("/group/name/{name}", h.SaveGroupByName)
+				r.Delete("/group/name/{name}", h.DeleteGroupByName)
+
+				r.Post("/group-alias", h.CreateGroupAlias)
+				r.Get("/group-alias/id", h.ListGroupAliases)
+				r.Get("/group-alias/id/{id}", h.GetGroupAlias)
+				r.Post("/group-alias/id/{id}", h.UpdateGroupAlias)
+				r.Delete("/group-alias/id/{id}", h.DeleteGroupAlias)
 
-			r.Get("/metrics", promhttp.Handler().ServeHTTP)
-			r.HandleFunc("/pprof/*", pprof.Index)
-			r.HandleFunc("/pprof/cmdline", pprof.Cmdline)
-			r.HandleFunc("/pprof/profile", pprof.Profile)
-			r.HandleFunc("/pprof/symbol", pprof.Symbol)
-			r.HandleFunc("/pprof/trace", pprof.Trace)
-			r.Handle("/pprof/goroutine", pprof.Handler("goroutine"))
-			r.Handle("/pprof/threadcreate", pprof.Handler("threadcreate"))
-			r.Handle("/pprof/mutex", pprof.Handler("mutex"))
-			r.Handle("/pprof/heap", pprof.Handler("heap"))
-			r.Handle("/pprof/block", pprof.Handler("block"))
-			r.Handle("/pprof/allocs", pprof.Handler("allocs"))
+				r.Get("/mfa/method/totp", h.ListTOTPMethods)
+				r.Post("/mfa/method/totp", h.CreateTOTPMethod)
+				r.Post("/mfa/method/totp/generate", h.GenerateTOTPSecret)
+				r.Post("/mfa/method/totp/admin-generate", h.AdminGenerateTOTPSecret)
+				r.Post("/mfa/method/totp/admin-destroy", h.AdminDestroyTOTPSecret)
+				r.Get("/mfa/method/totp/{method_id}", h.GetTOTPMethod)
+				r.Post("/mfa/method/totp/{method_id}", h.UpdateTOTPMethod)
+				r.Delete("/mfa/method/totp/{method_id}", h.DeleteTOTPMethod)
+
+				r.Get("/mfa/login-enforcement", h.ListLoginEnforcements)
+				r.Get("/mfa/login-enforcement/{name}", h.GetLoginEnforcement)
+				r.Post("/mfa/login-enforcement/{name}", h.SaveLoginEnforcement)
+				r.Delete("/mfa/login-enforcement/{name}", h.DeleteLoginEnforcement)
+			})
+
+			r.Route("/cubbyhole", func(r chi.Router) {
+				r.Get("/*", h.GetCubbyholeSecret)
+				r.Post("/*", h.SaveCubbyholeSecret)
+				r.Put("/*", h.SaveCubbyholeSecret)
+				r.Delete("/*", h.DeleteCubbyholeSecret)
+			})
+
+			r.Route("/{mount}", func(r chi.Router) {
+				r.Get("/config", h.GetKVConfig)
+				r.Post("/config", h.UpdateKVConfig)
+
+				r.Get("/data/{path}", h.GetKVSecret)
+				r.Post("/data/{path}", h.CreateKVSecret)
+				r.Delete("/data/{path}", h.DeleteLatestKVSecret)
+
+				r.Post("/delete/{path}", h.DeleteKVSecret)
+				r.Post("/destroy/{path}", h.DestroyKVSecret)
+
+				r.Get("/metadata/{path}", h.GetKVMetadata)
+				r.Post("/metadata/{path}", h.UpdateKVMetadata)
+				r.Delete("/metadata/{path}", h.DeleteKVMetadata)
+
+				r.Get("/subkeys/{path}", h.GetKVSubkeys)
+				r.Post("/subkeys/{path}", h.CreateKVSubkeys)
+			})
+
+			r.Route("/auth/token", func(r chi.Router) {
+				r.Get("/accessors", h.GetTokenAccessors)
+				r.Get("/accessors/", h.GetTokenAccessors)
+				r.Post("/create", h.CreateToken)
+				r.Post("/create-orphan", h.CreateOrphanToken)
+				r.Post("/create/{role_name}", h.CreateRoleToken)
+				r.Get("/lookup", h.LookupToken)
+				r.Post("/lookup", h.LookupToken)
+				r.Post("/lookup-accessor", h.LookupAccessorToken)
+				r.Get("/lookup-self", h.LookupSelfToken)
+				r.Post("/lookup-self", h.LookupSelfToken)
+				r.Post("/renew", h.RenewToken)
+				r.Post("/renew-accessor", h.RenewAccessorToken)
+				r.Post("/renew-self", h.RenewSelfToken)
+				r.Post("/revoke", h.RevokeToken)
+				r.Post("/revoke-accessor", h.RevokeAccessorToken)
+				r.Post("/revoke-orphan", h.RevokeOrphanToken)
+				r.Post("/revoke-self", h.RevokeSelfToken)
+				r.Get("/roles", h.GetRolesToken)
+				r.Get("/roles/", h.GetRolesToken)
+				r.Get("/roles/{role_name}", h.GetRoleByNameToken)
+				r.Post("/roles/{role_name}", h.CreateRoleByNameToken)
+				r.Delete("/roles/{role_name}", h.DeleteRoleByNameToken)
+				r.Post("/tidy", h.TidyToken)
+			})
 		})
 	})
 
 	srv.server.Handler = r
 
-	return srv
+	return srv, nil
+}
+
+// newTLSConfig requests a client certificate on every connection, so the cert
+// auth method can use it. The certificate is verified during the handshake
+// only when a client CA file is configured, otherwise each cert role checks
+// it against its own CA bundle.
+func parseCIDRs(cidrs []string) ([]*net.IPNet, error) {
+	ipNets := make([]*net.IPNet, 0, len(cidrs))
+	for _, cidr := range cidrs {
+		ipNet, err := token.ParseCIDR(strings.TrimSpace(cidr))
+		if err != nil {
+			return nil, fmt.Errorf("x_forwarded_for_authorized_addrs: %w", err)
+		}
+
+		ipNets = append(ipNets, ipNet)
+	}
+
+	return ipNets, nil
+}
+
+func newTLSConfig(cfg config.Server) (*tls.Config, error) {
+	if cfg.TLSCertFile == "" {
+		return nil, nil
+	}
+
+	tlsConfig := &tls.Config{
+		MinVersion: tls.VersionTLS12,
+		ClientAuth: tls.RequestClientCert,
+	}
+
+	if cfg.TLSClientCAFile != "" {
+		caPEM, err := os.ReadFile(cfg.TLSClientCAFile)
+		if err != nil {
+			return nil, err
+		}
+
+		pool := x509.NewCertPool()
+		if !pool.AppendCertsFromPEM(caPEM) {
+			return nil, errors.New("no certificates found in client CA file")
+		}
+
+		tlsConfig.ClientCAs = pool
+		tlsConfig.ClientAuth = tls.VerifyClientCertIfGiven
+	}
+
+	return tlsConfig, nil
 }
 
 func (s *Server) ListenAndServe() error {
+	if s.server.TLSConfig != nil {
+		return s.server.ListenAndServeTLS(s.tlsCertFile, s.tlsKeyFile)
+	}
+
 	return s.server.ListenAndServe()
 }
 
@@ -39,6 +39,10 @@ func (a AESEncryptor) Encrypt(data []byte) ([]byte, error) {
 }
 
 func (a AESEncryptor) Decrypt(data []byte) ([]byte, error) {
+	if len(data) < a.aead.NonceSize() {
+		return nil, ErrCiphertextTooShort
+	}
+
 	decryptedData, err := a.aead.Open(nil, data[:a.aead.NonceSize()], data[a.aead.NonceSize():], nil)
 	if err != nil {
 		return nil, err
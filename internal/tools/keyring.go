@@ -0,0 +1,239 @@
+package tools
+
+import (
+	"crypto/rand"
+	"encoding/binary"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"slices"
+	"sync"
+	"time"
+)
+
+// termSize is the length of the term prefix of keyring ciphertexts.
+const termSize = 4
+
+var ErrUnknownTerm = errors.New("ciphertext encrypted with unknown key term")
+
+// KeyringKey is one generation of the encryption key.
+type KeyringKey struct {
+	Term        uint32    `json:"term"`
+	Key         []byte    `json:"key"`
+	InstallTime time.Time `json:"install_time"`
+}
+
+// Keyring encrypts with its newest key and prefixes each ciphertext with the
+// term of that key, so data stays readable after rotations.
+//
+// Ciphertexts written before keys were versioned carry no prefix. They are
+// decrypted with the first term.
+type Keyring struct {
+	method string
+
+	mu         sync.RWMutex
+	keys       []KeyringKey
+	encryptors map[uint32]Encryptor
+}
+
+type keyringData struct {
+	Keys []KeyringKey `json:"keys"`
+}
+
+// NewKeyring creates a keyring with a random key as its first term.
+func NewKeyring(method string) (*Keyring, error) {
+	key, err := generateKey()
+	if err != nil {
+		return nil, err
+	}
+
+	return newKeyring(method, []KeyringKey{{Term: 1, Key: key, InstallTime: time.Now()}})
+}
+
+// ParseKeyring restores a keyring serialized with MarshalBinary. A bare
+// 32-byte key, as stored before keyrings existed, becomes the first term.
+func ParseKeyring(method string, data []byte) (*Keyring, error) {
+	var kd keyringData
+	if err := json.Unmarshal(data, &kd); err != nil {
+		if len(data) != 32 {
+			return nil, fmt.Errorf("parse keyring: %w", err)
+		}
+		kd.Keys = []KeyringKey{{Term: 1, Key: data}}
+	}
+
+	return newKeyring(method, kd.Keys)
+}
+
+func newKeyring(method string, keys []KeyringKey) (*Keyring, error) {
+	if len(keys) == 0 {
+		return nil, errors.New("keyring has no keys")
+	}
+
+	k := &Keyring{
+		method:     method,
+		encryptors: make(map[uint32]Encryptor, len(keys)),
+	}
+	for _, key := range keys {
+		if err := k.install(key); err != nil {
+			return nil, err
+		}
+	}
+
+	return k, nil
+}
+
+func (k *Keyring) Encrypt(plaintext []byte) ([]byte, error) {
+	k.mu.RLock()
+	defer k.mu.RUnlock()
+
+	active := k.keys[len(k.keys)-1]
+	ciphertext, err := k.encryptors[active.Term].Encrypt(plaintext)
+	if err != nil {
+		return nil, err
+	}
+
+	return append(binary.BigEndian.AppendUint32(make([]byte, 0, termSize+len(ciphertext)), active.Term), ciphertext...), nil
+}
+
+func (k *Keyring) Decrypt(data []byte) ([]byte, error) {
+	k.mu.RLock()
+	defer k.mu.RUnlock()
+
+	if len(data) >= termSize {
+		if encryptor, ok := k.encryptors[binary.BigEndian.Uint32(data)]; ok {
+			plaintext, err := encryptor.Decrypt(data[termSize:])
+			if err == nil {
+				return plaintext, nil
+			}
+		}
+	}
+
+	// The prefix may as well be the start of an unprefixed ciphertext.
+	if legacy, ok := k.encryptors[1]; ok {
+		return legacy.Decrypt(data)
+	}
+
+	return nil, ErrUnknownTerm
+}
+
+// Current reports whether data is encrypted with the newest key.
+func (k *Keyring) Current(data []byte) bool {
+	k.mu.RLock()
+	defer k.mu.RUnlock()
+
+	return len(data) >= termSize && binary.BigEndian.Uint32(data) == k.keys[len(k.keys)-1].Term
+}
+
+// Active returns the key new data is encrypted with.
+func (k *Keyring) Active() KeyringKey {
+	k.mu.RLock()
+	defer k.mu.RUnlock()
+
+	return k.keys[len(k.keys)-1]
+}
+
+// Len returns the number of terms in the keyring.
+func (k *Keyring) Len() int {
+	k.mu.RLock()
+	defer k.mu.RUnlock()
+
+	return len(k.keys)
+}
+
+// Rotate adds a new key, which encrypts all data from then on. The keyring
+// with the new key is passed to persist first; if that fails the keyring is
+// left unchanged.
+func (k *Keyring) Rotate(persist func(data []byte) error) (KeyringKey, error) {
+	key, err := generateKey()
+	if err != nil {
+		return KeyringKey{}, err
+	}
+
+	k.mu.Lock()
+	defer k.mu.Unlock()
+
+	next := KeyringKey{
+		Term:        k.keys[len(k.keys)-1].Term + 1,
+		Key:         key,
+		InstallTime: time.Now(),
+	}
+
+	data, err := marshalKeys(append(slices.Clip(k.keys), next))
+	if err != nil {
+		return KeyringKey{}, err
+	}
+	if err = persist(data); err != nil {
+		return KeyringKey{}, err
+	}
+
+	if err = k.install(next); err != nil {
+		return KeyringKey{}, err
+	}
+
+	return next, nil
+}
+
+// Prune drops the keys older than term once no data is encrypted with them
+// anymore. Like Rotate, it persists the result before applying it.
+func (k *Keyring) Prune(term uint32, persist func(data []byte) error) error {
+	k.mu.Lock()
+	defer k.mu.Unlock()
+
+	i := slices.IndexFunc(k.keys, func(key KeyringKey) bool {
+		return key.Term >= term
+	})
+	if i <= 0 {
+		return nil
+	}
+
+	data, err := marshalKeys(k.keys[i:])
+	if err != nil {
+		return err
+	}
+	if err = persist(data); err != nil {
+		return err
+	}
+
+	for _, key := range k.keys[:i] {
+		delete(k.encryptors, key.Term)
+	}
+	k.keys = slices.Clone(k.keys[i:])
+
+	return nil
+}
+
+func (k *Keyring) MarshalBinary() ([]byte, error) {
+	k.mu.RLock()
+	defer k.mu.RUnlock()
+
+	return marshalKeys(k.keys)
+}
+
+func (k *Keyring) install(key KeyringKey) error {
+	if len(k.keys) != 0 && key.Term <= k.keys[len(k.keys)-1].Term {
+		return fmt.Errorf("keyring term %d out of order", key.Term)
+	}
+
+	encryptor, err := NewEncryptor(k.method, key.Key)
+	if err != nil {
+		return err
+	}
+
+	k.keys = append(k.keys, key)
+	k.encryptors[key.Term] = encryptor
+
+	return nil
+}
+
+func marshalKeys(keys []KeyringKey) ([]byte, error) {
+	return json.Marshal(keyringData{Keys: keys})
+}
+
+func generateKey() ([]byte, error) {
+	key := make([]byte, 32)
+	if _, err := rand.Read(key); err != nil {
+		return nil, err
+	}
+
+	return key, nil
+}
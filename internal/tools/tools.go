@@ -28,6 +28,8 @@ func GenerateXRequestID() string {
 	return uuid.NewString()
 }
 
+var ErrCiphertextTooShort = errors.New("ciphertext too short")
+
 func NewEncryptor(name string, secret []byte) (Encryptor, error) {
 	switch name {
 	case "aes":
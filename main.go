@@ -33,7 +33,11 @@ func main() {
 	}
 	vaultHandler := handler.NewHandler(vault)
 
-	srv := server.NewServer(cfg.Server.Addr, vaultHandler)
+	srv, err := server.NewServer(cfg.Server, vaultHandler, vault, vault)
+	if err != nil {
+		logger.Error(err.Error())
+		return
+	}
 
 	ctx, stop := signal.NotifyContext(context.Background(), syscall.SIGINT, syscall.SIGTERM)
 	ready := make(chan struct{})